@@ -1,14 +1,73 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
-// generateVersionHpp generates version.hpp directly from project name and version
-func generateVersionHpp(projectName, projectVersion string) string {
+// licenseTexts holds licenses/<spdx>.txt; {{year}} and {{authors}} are filled in
+//
+//go:embed licenses/*.txt
+var licenseTexts embed.FS
+
+// licenseNames maps the supported SPDX identifiers to display names
+var licenseNames = map[string]string{
+	"MIT":          "MIT License",
+	"Apache-2.0":   "Apache License 2.0",
+	"BSD-3-Clause": "BSD 3-Clause License",
+	"GPL-3.0":      "GNU General Public License v3.0",
+}
+
+// licenseIDs returns the supported SPDX identifiers, sorted
+func licenseIDs() []string {
+	ids := make([]string, 0, len(licenseNames))
+	for id := range licenseNames {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// validateLicense checks that license is empty or a supported SPDX identifier
+func validateLicense(license string) error {
+	if _, ok := licenseNames[license]; license != "" && !ok {
+		return fmt.Errorf("unsupported license '%s' (supported: %s)", license, strings.Join(licenseIDs(), ", "))
+	}
+	return nil
+}
+
+// generateLicense returns the LICENSE text for a supported SPDX identifier
+func generateLicense(license string, authors []string, projectName string) (string, error) {
+	data, err := licenseTexts.ReadFile("licenses/" + license + ".txt")
+	if err != nil {
+		return "", fmt.Errorf("unsupported license '%s'", license)
+	}
+	holder := strings.Join(authors, ", ")
+	if holder == "" {
+		holder = "The " + projectName + " Authors"
+	}
+	return strings.NewReplacer(
+		"{{year}}", fmt.Sprint(time.Now().Year()),
+		"{{authors}}", holder,
+	).Replace(string(data)), nil
+}
+
+// generateVersionHpp generates version.hpp directly from project namespace and version
+func generateVersionHpp(namespace, projectVersion string) string {
 	if projectVersion == "" {
 		projectVersion = "1.0.0"
 	}
@@ -28,7 +87,7 @@ func generateVersionHpp(projectName, projectVersion string) string {
 		patch = parts[2]
 	}
 
-	projectNameUpper := strings.ToUpper(projectName)
+	projectNameUpper := namespaceMacroPrefix(namespace)
 	guard := projectNameUpper + "_VERSION_H_"
 
 	return fmt.Sprintf(`#ifndef %s
@@ -43,28 +102,162 @@ func generateVersionHpp(projectName, projectVersion string) string {
 `, guard, guard, projectNameUpper, projectVersion, projectNameUpper, major, projectNameUpper, minor, projectNameUpper, patch, guard)
 }
 
-// generateProjectFiles generates all project files locally (except dependencies.cmake)
+// namespaceMacroPrefix converts a (possibly nested) C++ namespace into the
+// upper-case prefix used for include guards and version macros.
+// e.g. "mycompany::app" -> "MYCOMPANY_APP"
+func namespaceMacroPrefix(namespace string) string {
+	return strings.ToUpper(strings.ReplaceAll(namespace, "::", "_"))
+}
+
+// namespaceOpen emits the opening lines for a (possibly nested) namespace.
+// Nested namespaces are written one per line so the output stays valid before C++17.
+func namespaceOpen(namespace string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(namespace, "::") {
+		sb.WriteString(fmt.Sprintf("namespace %s {\n", part))
+	}
+	return sb.String()
+}
+
+// namespaceClose emits the closing lines matching namespaceOpen.
+func namespaceClose(namespace string) string {
+	parts := strings.Split(namespace, "::")
+	var sb strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		sb.WriteString(fmt.Sprintf("}  // namespace %s\n", parts[i]))
+	}
+	return sb.String()
+}
+
+// generateProjectFiles generates all project files locally (except dependencies.cmake).
+// The files are written to a staging directory next to outputDir and only moved
+// into place once all of them succeeded, so a failure leaves outputDir untouched.
 func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMake string) error {
+	stageDir, err := makeStagingDir(outputDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stageDir)
+
+	// .clang-format is updated in place rather than replaced, so start from the project's
+	if data, err := os.ReadFile(filepath.Join(outputDir, ".clang-format")); err == nil {
+		if err := os.WriteFile(filepath.Join(stageDir, ".clang-format"), data, 0644); err != nil {
+			return fmt.Errorf("failed to stage .clang-format: %w", err)
+		}
+	}
+
+	if err := writeProjectFiles(config, outputDir, stageDir, dependenciesCMake); err != nil {
+		return err
+	}
+	return commitStagedFiles(stageDir, outputDir)
+}
+
+// makeStagingDir creates an empty directory on the same filesystem as outputDir,
+// so staged files can be renamed into place: next to it, or inside it when the
+// parent isn't writable
+func makeStagingDir(outputDir string) (string, error) {
+	abs, err := filepath.Abs(outputDir)
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(filepath.Dir(abs), "."+filepath.Base(abs)+".forge-")
+	if err != nil {
+		if info, statErr := os.Stat(abs); statErr == nil && info.IsDir() {
+			dir, err = os.MkdirTemp(abs, ".forge-generate-")
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return dir, nil
+}
+
+// checkProjectPath rejects a relative project path that is absolute or leaves the project
+func checkProjectPath(path string) error {
+	clean := filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return withExitCode(ExitConfig, fmt.Errorf("invalid path '%s': it must stay inside the project", path))
+	}
+	return nil
+}
+
+// commitStagedFiles moves everything under stageDir into outputDir. A missing
+// outputDir is replaced by the staging directory as a whole; otherwise each file
+// is renamed over its counterpart, skipping files whose content didn't change.
+// A file where the project has a directory (or the other way round) is skipped
+// with a warning instead of failing halfway through.
+func commitStagedFiles(stageDir, outputDir string) error {
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		if err := os.Rename(stageDir, outputDir); err != nil {
+			return fmt.Errorf("failed to move generated files into %s: %w", outputDir, err)
+		}
+		return nil
+	}
+
+	return filepath.WalkDir(stageDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(stageDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(outputDir, rel)
+		existing, statErr := os.Stat(dst)
+		if d.IsDir() {
+			if statErr == nil && !existing.IsDir() {
+				fmt.Printf("%s⚠️  Skipping %s/: a file already exists at that path%s\n", Yellow, filepath.ToSlash(rel), Reset)
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(dst, 0755)
+		}
+		if statErr == nil && existing.IsDir() {
+			fmt.Printf("%s⚠️  Skipping %s: a directory already exists at that path%s\n", Yellow, filepath.ToSlash(rel), Reset)
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if existing, err := os.ReadFile(dst); err == nil && bytes.Equal(existing, data) {
+			return nil
+		}
+		if err := os.Rename(path, dst); err != nil {
+			return fmt.Errorf("failed to move %s into place: %w", filepath.ToSlash(rel), err)
+		}
+		return nil
+	})
+}
+
+// writeProjectFiles writes the generated files to outputDir. projectDir is the
+// project being generated, consulted for the files that are only created when missing.
+func writeProjectFiles(config ForgeConfig, projectDir, outputDir string, dependenciesCMake string) error {
 	projectName := config.Package.Name
 	if projectName == "" {
 		projectName = "my_project"
 	}
 
+	namespace := getNamespaceFromConfig(&config)
+	if config.Package.Namespace != "" && !namespaceRegex.MatchString(namespace) {
+		return fmt.Errorf("invalid namespace '%s': must be C++ identifiers separated by '::'", namespace)
+	}
+
 	projectVersion := config.Package.Version
 	if projectVersion == "" {
 		projectVersion = "1.0.0"
 	}
 
-	cppStandard := config.Package.CppStandard
-	if cppStandard == 0 {
-		cppStandard = 17
+	cppStandard := getCppStandardFromConfig(&config)
+	if err := validateCppStandard(cppStandard); err != nil {
+		return err
 	}
 
-	projectType := "exe"
-	if config.Build.SharedLibs {
-		projectType = "lib"
+	if err := validateLicense(config.Package.License); err != nil {
+		return err
 	}
 
+	projectType := getProjectTypeFromConfig(&config)
+
 	includeTests := config.Testing.Framework != "" && config.Testing.Framework != "none"
 	testingFramework := config.Testing.Framework
 	if testingFramework == "" {
@@ -78,13 +271,37 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 	for libID := range config.Dependencies {
 		libraryIDs = append(libraryIDs, libID)
 	}
+	sort.Strings(libraryIDs)
+
+	warnings, err := getWarningsFromConfig(&config)
+	if err != nil {
+		return err
+	}
+
+	// pkg-config files only make sense for installed libraries
+	install := getInstallFromConfig(&config)
+	pkgConfig := config.Build.PkgConfig && projectType == "lib" && install
+
+	// Headers go under the first public include directory
+	includes := getIncludeDirsFromConfig(&config)
+	headerDir := includes.Public[0] + "/" + projectName
 
 	// Create directories
 	dirs := []string{
 		".cmake/forge",
-		"include/" + projectName,
+		headerDir,
 		"src",
-		"tests",
+	}
+	if includeTests {
+		dirs = append(dirs, "tests")
+	}
+	dirs = append(dirs, includes.Public[1:]...)
+	dirs = append(dirs, includes.Private...)
+	// The include dirs and the package name come from forge.yaml; nothing may be written outside the project
+	for _, dir := range append(dirs, "src/"+projectName+".cpp") {
+		if err := checkProjectPath(dir); err != nil {
+			return err
+		}
 	}
 	for _, dir := range dirs {
 		if err := os.MkdirAll(filepath.Join(outputDir, dir), 0755); err != nil {
@@ -95,16 +312,16 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 	// Write dependencies.cmake (from server)
 	if err := os.WriteFile(
 		filepath.Join(outputDir, ".cmake/forge/dependencies.cmake"),
-		[]byte(dependenciesCMake),
+		[]byte(stampDependenciesCMake(dependenciesCMake)),
 		0644,
 	); err != nil {
 		return fmt.Errorf("failed to write dependencies.cmake: %w", err)
 	}
 
 	// Generate and write version.hpp directly (no CMake pipeline needed)
-	versionHpp := generateVersionHpp(projectName, projectVersion)
+	versionHpp := generateVersionHpp(namespace, projectVersion)
 	if err := os.WriteFile(
-		filepath.Join(outputDir, "include/"+projectName+"/version.hpp"),
+		filepath.Join(outputDir, headerDir+"/version.hpp"),
 		[]byte(versionHpp),
 		0644,
 	); err != nil {
@@ -112,7 +329,7 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 	}
 
 	// Generate and write CMakeLists.txt
-	cmakeLists, err := generateCMakeLists(projectName, cppStandard, libraryIDs, includeTests, testingFramework, buildShared, projectType, projectVersion)
+	cmakeLists, err := generateCMakeLists(projectName, getBinNameFromConfig(&config), cppStandard, libraryIDs, includeTests, testingFramework, buildShared, projectType, projectVersion, config.Testing.Fuzz, includes, warnings, pkgConfig, config.Build.FeatureDefines, config.Build.FeatureCxxFlags, getTestsBuildByDefaultFromConfig(&config), install, getSourcesFromConfig(&config), config.Subdirectories, config.Build.GNUInstallDirs, config.Build.Unity, config.Build.UnityBatchSize)
 	if err != nil {
 		return fmt.Errorf("failed to generate CMakeLists.txt: %w", err)
 	}
@@ -125,9 +342,9 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 	}
 
 	// Generate and write header file (always generated for both exe and lib)
-	libHeader := generateLibHeader(projectName)
+	libHeader := generateLibHeader(projectName, namespace, config.Package.Description, config.Package.Authors)
 	if err := os.WriteFile(
-		filepath.Join(outputDir, "include/"+projectName+"/"+projectName+".hpp"),
+		filepath.Join(outputDir, headerDir+"/"+projectName+".hpp"),
 		[]byte(libHeader),
 		0644,
 	); err != nil {
@@ -136,7 +353,7 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 
 	// Generate and write main.cpp for executable projects
 	if projectType == "exe" {
-		mainCpp := generateMainCpp(projectName, libraryIDs)
+		mainCpp := generateMainCpp(projectName, namespace, libraryIDs)
 		if err := os.WriteFile(
 			filepath.Join(outputDir, "src/main.cpp"),
 			[]byte(mainCpp),
@@ -147,7 +364,7 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 	}
 
 	// Generate and write project source file (always generated, uses libSource which includes version())
-	libSource := generateLibSource(projectName, libraryIDs)
+	libSource := generateLibSource(projectName, namespace, libraryIDs)
 	if err := os.WriteFile(
 		filepath.Join(outputDir, "src/"+projectName+".cpp"),
 		[]byte(libSource),
@@ -157,7 +374,7 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 	}
 
 	// Generate and write README.md
-	readme := generateReadme(projectName, libraryIDs, cppStandard, projectType)
+	readme := generateReadme(projectName, config.Package.Description, config.Package.Authors, libraryIDs, resolvedDependencies(dependenciesCMake), cppStandard, projectType, config.Package.License, config.Package.Repository)
 	if err := os.WriteFile(
 		filepath.Join(outputDir, "README.md"),
 		[]byte(readme),
@@ -166,6 +383,20 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 		return fmt.Errorf("failed to write README.md: %w", err)
 	}
 
+	// Generate LICENSE, keeping an existing one
+	if config.Package.License != "" {
+		licensePath := filepath.Join(outputDir, "LICENSE")
+		if _, err := os.Stat(filepath.Join(projectDir, "LICENSE")); os.IsNotExist(err) {
+			license, err := generateLicense(config.Package.License, config.Package.Authors, projectName)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(licensePath, []byte(license), 0644); err != nil {
+				return fmt.Errorf("failed to write LICENSE: %w", err)
+			}
+		}
+	}
+
 	// Generate and write .gitignore
 	gitignore := generateGitignore()
 	if err := os.WriteFile(
@@ -176,9 +407,69 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 		return fmt.Errorf("failed to write .gitignore: %w", err)
 	}
 
+	// Generate .clang-format, keeping manual edits while the settings are unchanged
+	if _, err := updateClangFormatIfNeeded(&config, outputDir, true); err != nil {
+		return err
+	}
+
+	// Generate and write .gitattributes
+	gitattributes := generateGitAttributes()
+	if err := os.WriteFile(
+		filepath.Join(outputDir, ".gitattributes"),
+		[]byte(gitattributes),
+		0644,
+	); err != nil {
+		return fmt.Errorf("failed to write .gitattributes: %w", err)
+	}
+
+	// Generate pkg-config template, configured and installed by CMakeLists.txt
+	if pkgConfig {
+		if err := os.WriteFile(
+			filepath.Join(outputDir, ".cmake/forge/"+projectName+".pc.in"),
+			[]byte(generatePkgConfig(projectName, config.Package.Description, config.Package.Repository, config.Build.GNUInstallDirs)),
+			0644,
+		); err != nil {
+			return fmt.Errorf("failed to write %s.pc.in: %w", projectName, err)
+		}
+	}
+
+	// Generate the script behind the uninstall target
+	if install {
+		if err := os.WriteFile(
+			filepath.Join(outputDir, ".cmake/forge/cmake_uninstall.cmake.in"),
+			[]byte(generateUninstallScript()),
+			0644,
+		); err != nil {
+			return fmt.Errorf("failed to write cmake_uninstall.cmake.in: %w", err)
+		}
+	}
+
+	// Generate fuzz harness if enabled, keeping an existing fuzz target
+	if config.Testing.Fuzz {
+		if err := os.MkdirAll(filepath.Join(outputDir, "fuzz/corpus"), 0755); err != nil {
+			return fmt.Errorf("failed to create fuzz directory: %w", err)
+		}
+
+		fuzzCMake := generateFuzzCMake(projectName, includes)
+		if err := os.WriteFile(
+			filepath.Join(outputDir, "fuzz/CMakeLists.txt"),
+			[]byte(fuzzCMake),
+			0644,
+		); err != nil {
+			return fmt.Errorf("failed to write fuzz/CMakeLists.txt: %w", err)
+		}
+
+		fuzzTarget := filepath.Join(outputDir, "fuzz/fuzz_target.cpp")
+		if _, err := os.Stat(filepath.Join(projectDir, "fuzz/fuzz_target.cpp")); os.IsNotExist(err) {
+			if err := os.WriteFile(fuzzTarget, []byte(generateFuzzTarget(projectName, namespace)), 0644); err != nil {
+				return fmt.Errorf("failed to write fuzz/fuzz_target.cpp: %w", err)
+			}
+		}
+	}
+
 	// Generate test files if needed
 	if includeTests {
-		testCMake := generateTestCMake(projectName, libraryIDs, testingFramework)
+		testCMake := generateTestCMake(projectName, libraryIDs, testingFramework, getTestLabelsFromConfig(&config), includes, config.Testing.PerSource)
 		if err := os.WriteFile(
 			filepath.Join(outputDir, "tests/CMakeLists.txt"),
 			[]byte(testCMake),
@@ -187,7 +478,7 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 			return fmt.Errorf("failed to write tests/CMakeLists.txt: %w", err)
 		}
 
-		testMain := generateTestMain(projectName, libraryIDs, testingFramework)
+		testMain := generateTestMain(projectName, namespace, libraryIDs, testingFramework)
 		if err := os.WriteFile(
 			filepath.Join(outputDir, "tests/test_main.cpp"),
 			[]byte(testMain),
@@ -195,11 +486,111 @@ func generateProjectFiles(config ForgeConfig, outputDir string, dependenciesCMak
 		); err != nil {
 			return fmt.Errorf("failed to write tests/test_main.cpp: %w", err)
 		}
+
+		if config.Testing.PerSource {
+			if err := writeSourceTestStubs(projectDir, outputDir, libraryIDs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// nonIdentifierChars matches characters that can't appear in a C++ or CMake identifier
+var nonIdentifierChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// writeSourceTestStubs adds a tests/test_<source>.cpp with a placeholder test
+// for every source under src/ (except src/main.cpp) that doesn't have one yet.
+// Sources and stubs are looked up in both the project and the generated files;
+// existing stubs are never touched, so regenerating keeps the tests written in them.
+func writeSourceTestStubs(projectDir, outputDir string, libraryIDs []string) error {
+	seen := make(map[string]bool)
+	var sources []string
+	for _, dir := range []string{outputDir, projectDir} {
+		srcDir := filepath.Join(dir, "src")
+		err := filepath.WalkDir(srcDir, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			switch filepath.Ext(path) {
+			case ".cpp", ".cc", ".cxx":
+			default:
+				return nil
+			}
+			rel, err := filepath.Rel(srcDir, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if rel != "main.cpp" && !seen[rel] {
+				seen[rel] = true
+				sources = append(sources, rel)
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to scan src/: %w", err)
+		}
 	}
 
+	for _, src := range sources {
+		// src/net/socket.cpp -> tests/test_net_socket.cpp
+		module := nonIdentifierChars.ReplaceAllString(strings.TrimSuffix(src, path.Ext(src)), "_")
+		if module == "" {
+			// src/.cpp has no name to test
+			continue
+		}
+		testFile := filepath.Join("tests", "test_"+module+".cpp")
+		if _, err := os.Stat(filepath.Join(projectDir, testFile)); err == nil {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(outputDir, testFile), []byte(generateSourceTestStub(src, module, libraryIDs)), 0644); err != nil {
+			return fmt.Errorf("failed to write tests/test_%s.cpp: %w", module, err)
+		}
+	}
 	return nil
 }
 
+// generateSourceTestStub returns a passing placeholder test for src/<source>.
+// Only test_main.cpp may define main, so the framework-less stub has no test of its own.
+func generateSourceTestStub(source, module string, libraryIDs []string) string {
+	capName := strings.ToUpper(module[:1]) + module[1:]
+	switch {
+	case slices.Contains(libraryIDs, "googletest"):
+		return fmt.Sprintf(`// Tests for src/%s
+#include <gtest/gtest.h>
+
+TEST(%sTest, Placeholder) {
+    // TODO: test src/%s
+    SUCCEED();
+}
+`, source, capName, source)
+	case slices.Contains(libraryIDs, "catch2"):
+		return fmt.Sprintf(`// Tests for src/%s
+#include <catch2/catch_test_macros.hpp>
+
+TEST_CASE("%s placeholder", "[%s]") {
+    // TODO: test src/%s
+    SUCCEED();
+}
+`, source, source, module, source)
+	case slices.Contains(libraryIDs, "doctest"):
+		return fmt.Sprintf(`// Tests for src/%s
+#include <doctest/doctest.h>
+
+TEST_CASE("%s placeholder") {
+    // TODO: test src/%s
+    CHECK(true);
+}
+`, source, source, source)
+	default:
+		return fmt.Sprintf(`// Tests for src/%s
+// TODO: add tests here and call them from main() in test_main.cpp
+`, source)
+	}
+}
+
 // Generation functions (simplified versions that work with library IDs only)
 
 func generateVersionCMake(projectVersion string) string {
@@ -366,7 +757,22 @@ func generateVersionHppIn() string {
 `
 }
 
-func generateCMakeLists(projectName string, cppStandard int, libraryIDs []string, includeTests bool, testingFramework string, buildShared bool, projectType string, projectVersion string) (string, error) {
+// Entry formats for target_include_directories in the top-level and tests/fuzz CMakeLists
+const (
+	topLevelIncludeFormat = "        $<BUILD_INTERFACE:${CMAKE_CURRENT_SOURCE_DIR}/%s>\n"
+	subdirIncludeFormat   = "        ${CMAKE_CURRENT_SOURCE_DIR}/../%s\n"
+)
+
+// includeDirLines renders one target_include_directories entry per dir
+func includeDirLines(dirs []string, format string) string {
+	var sb strings.Builder
+	for _, dir := range dirs {
+		sb.WriteString(fmt.Sprintf(format, dir))
+	}
+	return sb.String()
+}
+
+func generateCMakeLists(projectName, binName string, cppStandard int, libraryIDs []string, includeTests bool, testingFramework string, buildShared bool, projectType string, projectVersion string, fuzz bool, includes IncludeConfig, warnings string, pkgConfig bool, defines, compileOptions []string, testsByDefault bool, install bool, sources, subdirectories []string, gnuInstallDirs, unity bool, unityBatchSize int) (string, error) {
 	buildSharedStr := "OFF"
 	if buildShared {
 		buildSharedStr = "ON"
@@ -400,6 +806,16 @@ include(${CMAKE_CURRENT_SOURCE_DIR}/.cmake/forge/dependencies.cmake)
 
 `, projectName, projectVersion, cppStandard, buildSharedStr))
 
+	// Install destinations: literal bin/lib/include, or the distro layout from GNUInstallDirs
+	binDir, libDir, includeDir := "bin", "lib", "include"
+	if gnuInstallDirs {
+		binDir, libDir, includeDir = "${CMAKE_INSTALL_BINDIR}", "${CMAKE_INSTALL_LIBDIR}", "${CMAKE_INSTALL_INCLUDEDIR}"
+		sb.WriteString("# Standard install directories (build.gnu_install_dirs)\ninclude(GNUInstallDirs)\n\n")
+	}
+
+	sb.WriteString(generateSources(sources))
+	sb.WriteString(generateSubdirectories(subdirectories))
+
 	if projectType == "exe" {
 		// FIXED: Changed $${...} to ${...} inside Sprintf
 		sb.WriteString(fmt.Sprintf(`# =============================================================================
@@ -408,56 +824,127 @@ include(${CMAKE_CURRENT_SOURCE_DIR}/.cmake/forge/dependencies.cmake)
 
 add_executable(%s
     src/main.cpp
-    src/%s.cpp
+    ${FORGE_SOURCES}
 )
 
 target_include_directories(%s
     PRIVATE
-        $<BUILD_INTERFACE:${CMAKE_CURRENT_SOURCE_DIR}/include>
-)
+%s)
 
 target_link_libraries(%s
     PRIVATE
         ${FORGE_LINK_LIBRARIES}
+        ${FORGE_PRIVATE_LINK_LIBRARIES}
+        ${FORGE_SUBDIRECTORY_TARGETS}
+    PUBLIC
+        ${FORGE_PUBLIC_LINK_LIBRARIES}
+    INTERFACE
+        ${FORGE_INTERFACE_LINK_LIBRARIES}
 )
 
-`, projectName, projectName, projectName, projectName))
+`, binName, binName, includeDirLines(includes.all(), topLevelIncludeFormat), binName))
+
+		if install {
+			sb.WriteString(fmt.Sprintf(`# =============================================================================
+# Installation
+# =============================================================================
+
+install(TARGETS %s RUNTIME DESTINATION %s)
+
+`, binName, binDir))
+		}
 	} else {
+		// Private headers stay out of the exported interface and the install tree
+		privateIncludes := ""
+		if len(includes.Private) > 0 {
+			privateIncludes = "    PRIVATE\n" + includeDirLines(includes.Private, topLevelIncludeFormat)
+		}
+		var installDirs string
+		for _, dir := range includes.Public {
+			installDirs += fmt.Sprintf("install(DIRECTORY %s/ DESTINATION %s)\n", dir, includeDir)
+		}
+
 		// FIXED: Changed $${...} to ${...} inside Sprintf
 		sb.WriteString(fmt.Sprintf(`# =============================================================================
 # Main Library
 # =============================================================================
 
 add_library(%s
-    src/%s.cpp
+    ${FORGE_SOURCES}
 )
 
 target_include_directories(%s
     PUBLIC
-        $<BUILD_INTERFACE:${CMAKE_CURRENT_SOURCE_DIR}/include>
-        $<INSTALL_INTERFACE:include>
-)
+%s        $<INSTALL_INTERFACE:%s>
+%s)
 
 target_link_libraries(%s
     PUBLIC
         ${FORGE_LINK_LIBRARIES}
+        ${FORGE_PUBLIC_LINK_LIBRARIES}
+        ${FORGE_SUBDIRECTORY_TARGETS}
+    PRIVATE
+        ${FORGE_PRIVATE_LINK_LIBRARIES}
+    INTERFACE
+        ${FORGE_INTERFACE_LINK_LIBRARIES}
 )
 
-# =============================================================================
+`, projectName, projectName, includeDirLines(includes.Public, topLevelIncludeFormat), includeDir, privateIncludes, projectName))
+
+		if install {
+			sb.WriteString(fmt.Sprintf(`# =============================================================================
 # Installation
 # =============================================================================
 
 install(TARGETS %s
     EXPORT %sTargets
-    LIBRARY DESTINATION lib
-    ARCHIVE DESTINATION lib
-    INCLUDES DESTINATION include
+    LIBRARY DESTINATION %s
+    ARCHIVE DESTINATION %s
+    INCLUDES DESTINATION %s
+)
+
+%s
+`, projectName, projectName, libDir, libDir, includeDir, installDirs))
+		}
+
+		if pkgConfig {
+			sb.WriteString(fmt.Sprintf(`# pkg-config file for non-CMake consumers
+configure_file(
+    ${CMAKE_CURRENT_SOURCE_DIR}/.cmake/forge/%s.pc.in
+    ${CMAKE_CURRENT_BINARY_DIR}/%s.pc
+    @ONLY
 )
+install(FILES ${CMAKE_CURRENT_BINARY_DIR}/%s.pc DESTINATION %s/pkgconfig)
+
+`, projectName, projectName, projectName, libDir))
+		}
+	}
 
-install(DIRECTORY include/ DESTINATION include)
+	// Removes what install_manifest.txt lists: cmake --build build --target uninstall
+	if install {
+		sb.WriteString(`# Uninstall (after cmake --install): cmake --build build --target uninstall
+if(NOT TARGET uninstall)
+    configure_file(
+        ${CMAKE_CURRENT_SOURCE_DIR}/.cmake/forge/cmake_uninstall.cmake.in
+        ${CMAKE_CURRENT_BINARY_DIR}/cmake_uninstall.cmake
+        @ONLY
+    )
+    add_custom_target(uninstall
+        COMMAND ${CMAKE_COMMAND} -P ${CMAKE_CURRENT_BINARY_DIR}/cmake_uninstall.cmake
+    )
+endif()
+
+`)
+	}
 
-`, projectName, projectName, projectName, projectName, projectName, projectName))
+	// Warning flags for the main target
+	target := projectName
+	if projectType == "exe" {
+		target = binName
 	}
+	sb.WriteString(generateWarningOptions(target, warnings))
+	sb.WriteString(generateFeatureSettings(target, projectType, defines, compileOptions))
+	sb.WriteString(generateUnityBuild(target, unity, unityBatchSize))
 
 	// Test configuration
 	if includeTests {
@@ -467,14 +954,198 @@ install(DIRECTORY include/ DESTINATION include)
 
 enable_testing()
 
-add_subdirectory(tests)
+`)
+		if testsByDefault {
+			sb.WriteString("add_subdirectory(tests)\n")
+		} else {
+			// testing.build_by_default: false keeps tests out of a bare build
+			sb.WriteString("add_subdirectory(tests EXCLUDE_FROM_ALL)\n")
+		}
+		sb.WriteString(fmt.Sprintf(`
+# Build and run the test suite: cmake --build build --target check
+add_custom_target(check
+    COMMAND ${CMAKE_CTEST_COMMAND} --output-on-failure
+    DEPENDS %s_tests
+    WORKING_DIRECTORY ${CMAKE_BINARY_DIR}
+    USES_TERMINAL
+)
+`, projectName))
+	}
+
+	// Fuzzing configuration (libFuzzer, clang only)
+	if fuzz {
+		sb.WriteString(`
+# =============================================================================
+# Fuzzing (build with: forge fuzz)
+# =============================================================================
+
+option(FORGE_ENABLE_FUZZING "Build libFuzzer targets (requires clang)" OFF)
+if(FORGE_ENABLE_FUZZING)
+    add_subdirectory(fuzz)
+endif()
 `)
 	}
 
 	return sb.String(), nil
 }
 
-func generateMainCpp(projectName string, libraryIDs []string) string {
+// generateUninstallScript returns cmake_uninstall.cmake.in, which removes every
+// file recorded in install_manifest.txt (honouring DESTDIR)
+func generateUninstallScript() string {
+	return `if(NOT EXISTS "@CMAKE_BINARY_DIR@/install_manifest.txt")
+    message(FATAL_ERROR "Cannot find install manifest: @CMAKE_BINARY_DIR@/install_manifest.txt")
+endif()
+
+file(READ "@CMAKE_BINARY_DIR@/install_manifest.txt" files)
+string(REGEX REPLACE "\n" ";" files "${files}")
+foreach(file ${files})
+    message(STATUS "Uninstalling $ENV{DESTDIR}${file}")
+    if(IS_SYMLINK "$ENV{DESTDIR}${file}" OR EXISTS "$ENV{DESTDIR}${file}")
+        file(REMOVE "$ENV{DESTDIR}${file}")
+    else()
+        message(STATUS "File $ENV{DESTDIR}${file} does not exist.")
+    endif()
+endforeach()
+`
+}
+
+// generatePkgConfig returns a <name>.pc.in template; CMake fills in the
+// install prefix and version with configure_file(@ONLY). package.repository
+// becomes the URL field.
+func generatePkgConfig(projectName, description, repository string, gnuInstallDirs bool) string {
+	if description == "" {
+		description = projectName
+	}
+	var url string
+	if repository != "" {
+		url = "URL: " + repository + "\n"
+	}
+	libDir, includeDir := "lib", "include"
+	if gnuInstallDirs {
+		libDir, includeDir = "@CMAKE_INSTALL_LIBDIR@", "@CMAKE_INSTALL_INCLUDEDIR@"
+	}
+	return fmt.Sprintf(`prefix=@CMAKE_INSTALL_PREFIX@
+exec_prefix=${prefix}
+libdir=${prefix}/%s
+includedir=${prefix}/%s
+
+Name: %s
+Description: %s
+%sVersion: @PROJECT_VERSION@
+Libs: -L${libdir} -l%s
+Cflags: -I${includedir}
+`, libDir, includeDir, projectName, description, url, projectName)
+}
+
+// generateSources collects the main target's sources into FORGE_SOURCES (absolute
+// paths, so tests/ and fuzz/ can compile them too). Entries with wildcards are
+// globbed at configure time, "**/" recursively; src/main.cpp is always left out
+// since only the executable uses it.
+func generateSources(sources []string) string {
+	var sb strings.Builder
+	sb.WriteString(`# =============================================================================
+# Sources (forge.yaml sources; globs are re-checked on every build)
+# =============================================================================
+set(FORGE_SOURCES)
+`)
+	for _, src := range sources {
+		if !strings.ContainsAny(src, "*?[") {
+			sb.WriteString(fmt.Sprintf("list(APPEND FORGE_SOURCES ${CMAKE_CURRENT_SOURCE_DIR}/%s)\n", src))
+			continue
+		}
+		mode := "GLOB"
+		if strings.Contains(src, "**/") {
+			mode = "GLOB_RECURSE"
+			src = strings.ReplaceAll(src, "**/", "")
+		}
+		sb.WriteString(fmt.Sprintf("file(%s _forge_glob CONFIGURE_DEPENDS ${CMAKE_CURRENT_SOURCE_DIR}/%s)\n", mode, src))
+		sb.WriteString("list(APPEND FORGE_SOURCES ${_forge_glob})\n")
+	}
+	sb.WriteString(`list(REMOVE_ITEM FORGE_SOURCES ${CMAKE_CURRENT_SOURCE_DIR}/src/main.cpp)
+list(REMOVE_DUPLICATES FORGE_SOURCES)
+
+`)
+	return sb.String()
+}
+
+// generateSubdirectories adds each forge.yaml subdirectory and collects the
+// targets they define (named after the directory) into FORGE_SUBDIRECTORY_TARGETS
+func generateSubdirectories(subdirectories []string) string {
+	if len(subdirectories) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(`# =============================================================================
+# Subdirectories (forge.yaml subdirectories; each defines a target named after it)
+# =============================================================================
+`)
+	var targets []string
+	for _, dir := range subdirectories {
+		dir = strings.TrimSuffix(filepath.ToSlash(dir), "/")
+		sb.WriteString(fmt.Sprintf("add_subdirectory(%s)\n", dir))
+		targets = append(targets, path.Base(dir))
+	}
+	sb.WriteString(fmt.Sprintf("set(FORGE_SUBDIRECTORY_TARGETS %s)\n\n", strings.Join(targets, " ")))
+	return sb.String()
+}
+
+// generateFeatureSettings emits the defines and cxx_flags of enabled features on the
+// main target. Library defines are PUBLIC so tests and consumers see the same macros.
+func generateFeatureSettings(target, projectType string, defines, compileOptions []string) string {
+	if len(defines) == 0 && len(compileOptions) == 0 {
+		return ""
+	}
+
+	definesScope := "PUBLIC"
+	if projectType == "exe" {
+		definesScope = "PRIVATE"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`# =============================================================================
+# Feature defines and compile options (features)
+# =============================================================================
+
+`)
+	if len(defines) > 0 {
+		sb.WriteString(fmt.Sprintf("target_compile_definitions(%s\n    %s\n", target, definesScope))
+		for _, define := range defines {
+			sb.WriteString(fmt.Sprintf("        %s\n", define))
+		}
+		sb.WriteString(")\n")
+	}
+	if len(compileOptions) > 0 {
+		sb.WriteString(fmt.Sprintf("target_compile_options(%s\n    PRIVATE\n", target))
+		for _, option := range compileOptions {
+			sb.WriteString(fmt.Sprintf("        %s\n", option))
+		}
+		sb.WriteString(")\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// generateWarningOptions emits target_compile_options for a build.warnings level,
+// choosing GCC/Clang or MSVC flags with generator expressions
+func generateWarningOptions(target, warnings string) string {
+	flags := warningFlags[warnings]
+	if flags[0] == "" {
+		return ""
+	}
+	return fmt.Sprintf(`# =============================================================================
+# Compiler Warnings (build.warnings: %s)
+# =============================================================================
+
+target_compile_options(%s
+    PRIVATE
+        "$<$<CXX_COMPILER_ID:MSVC>:%s>"
+        "$<$<NOT:$<CXX_COMPILER_ID:MSVC>>:%s>"
+)
+
+`, warnings, target, flags[1], flags[0])
+}
+
+func generateMainCpp(projectName, namespace string, libraryIDs []string) string {
 	var includes []string
 	hasSpdlog := false
 	hasCLI11 := false
@@ -504,8 +1175,7 @@ func generateMainCpp(projectName string, libraryIDs []string) string {
 	}
 
 	var sb strings.Builder
-	projectNameUpper := strings.ToUpper(projectName)
-	versionMacro := projectNameUpper + "_VERSION"
+	versionMacro := namespaceMacroPrefix(namespace) + "_VERSION"
 	sb.WriteString(fmt.Sprintf(`#include <%s/%s.hpp>
 #include <%s/version.hpp>
 #include <iostream>%s
@@ -559,20 +1229,33 @@ int main(int argc, char* argv[]) {
     
     return 0;
 }
-`, projectName))
+`, namespace))
 
 	return sb.String()
 }
 
-func generateLibHeader(projectName string) string {
-	guard := strings.ToUpper(projectName) + "_HPP"
-	return fmt.Sprintf(`#ifndef %s
+func generateLibHeader(projectName, namespace, description string, authors []string) string {
+	guard := namespaceMacroPrefix(namespace) + "_HPP"
+
+	// Doxygen file comment from package.description and package.authors
+	var fileDoc strings.Builder
+	if description != "" || len(authors) > 0 {
+		fileDoc.WriteString(fmt.Sprintf("/**\n * @file %s.hpp\n", projectName))
+		if description != "" {
+			fileDoc.WriteString(fmt.Sprintf(" * @brief %s\n", description))
+		}
+		for _, author := range authors {
+			fileDoc.WriteString(fmt.Sprintf(" * @author %s\n", author))
+		}
+		fileDoc.WriteString(" */\n\n")
+	}
+
+	return fileDoc.String() + fmt.Sprintf(`#ifndef %s
 #define %s
 
 #include <string>
 
-namespace %s {
-
+%s
 /**
  * @brief Greet function
  */
@@ -584,13 +1267,12 @@ void greet();
  */
 std::string version();
 
-}  // namespace %s
-
+%s
 #endif  // %s
-`, guard, guard, projectName, projectName, guard)
+`, guard, guard, namespaceOpen(namespace), namespaceClose(namespace), guard)
 }
 
-func generateLibSource(projectName string, libraryIDs []string) string {
+func generateLibSource(projectName, namespace string, libraryIDs []string) string {
 	hasSpdlog := false
 	hasFmt := false
 
@@ -617,8 +1299,8 @@ func generateLibSource(projectName string, libraryIDs []string) string {
 	var sb strings.Builder
 	sb.WriteString(strings.Join(includes, "\n"))
 	sb.WriteString("\n\n")
-	sb.WriteString(fmt.Sprintf("namespace %s {\n\n", projectName))
-	sb.WriteString("void greet() {\n")
+	sb.WriteString(namespaceOpen(namespace))
+	sb.WriteString("\nvoid greet() {\n")
 
 	if hasSpdlog {
 		sb.WriteString(fmt.Sprintf(`    spdlog::info("Hello from %s!");
@@ -634,7 +1316,8 @@ std::string version() {
     return "1.0.0";
 }
 
-}  // namespace ` + projectName + "\n")
+`)
+	sb.WriteString(namespaceClose(namespace))
 
 	return sb.String()
 }
@@ -684,7 +1367,33 @@ func generateProjectCpp(projectName string, libraryIDs []string) string {
 	return sb.String()
 }
 
-func generateTestCMake(projectName string, libraryIDs []string, testingFramework string) string {
+// generateUnityBuild returns the FORGE_UNITY_BUILD option, defaulting to build.unity,
+// which turns on UNITY_BUILD for the main target (and the tests, see generateTestCMake)
+func generateUnityBuild(target string, unity bool, batchSize int) string {
+	def := "OFF"
+	if unity {
+		def = "ON"
+	}
+	properties := "UNITY_BUILD ON"
+	if batchSize > 0 {
+		properties += fmt.Sprintf(" UNITY_BUILD_BATCH_SIZE %d", batchSize)
+	}
+	return fmt.Sprintf(`# =============================================================================
+# Unity Build (build.unity, forge build --unity)
+# =============================================================================
+
+option(FORGE_UNITY_BUILD "Compile project sources in unity batches" %s)
+if(FORGE_UNITY_BUILD)
+    set_target_properties(%s PROPERTIES %s)
+endif()
+
+`, def, target, properties)
+}
+
+// generateTestCMake generates tests/CMakeLists.txt. labels[0] is the label of the
+// tests/ target; every other labelled directory gets a test target built from its
+// .cpp files. Labels are attached as CTest LABELS so suites can be run with ctest -L.
+func generateTestCMake(projectName string, libraryIDs []string, testingFramework string, labels []testLabel, includes IncludeConfig, perSource bool) string {
 	hasGtest := false
 	hasCatch2 := false
 
@@ -697,46 +1406,145 @@ func generateTestCMake(projectName string, libraryIDs []string, testingFramework
 		}
 	}
 
+	testFiles := "test_main.cpp"
+	if perSource {
+		// testing.per_source: every tests/test_*.cpp, picked up again on reconfigure
+		testFiles = "${FORGE_TEST_FILES}"
+	}
+
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf(`# Test configuration for %s
+`, projectName))
+	if perSource {
+		sb.WriteString(`
+file(GLOB FORGE_TEST_FILES CONFIGURE_DEPENDS ${CMAKE_CURRENT_SOURCE_DIR}/test_*.cpp)
+`)
+	}
+	target := projectName + "_tests"
+	sb.WriteString(generateTestTarget(target, testFiles, includes))
+	sb.WriteString(generateTestDiscovery(target, labels[0].Label, hasGtest, hasCatch2))
+
+	for _, label := range labels[1:] {
+		name := testTargetName(label.Dir)
+		target := fmt.Sprintf("%s_%s_tests", projectName, name)
+		filesVar := fmt.Sprintf("FORGE_%s_TEST_FILES", strings.ToUpper(name))
+		sb.WriteString(fmt.Sprintf(`
+# %s (testing.labels: %s)
+file(GLOB %s CONFIGURE_DEPENDS ${PROJECT_SOURCE_DIR}/%s/*.cpp)
+`, label.Dir, label.Label, filesVar, label.Dir))
+		sb.WriteString(generateTestTarget(target, "${"+filesVar+"}", includes))
+		sb.WriteString(generateTestDiscovery(target, label.Label, hasGtest, hasCatch2))
+	}
 
-add_executable(%s_tests
-    test_main.cpp
-    ${CMAKE_CURRENT_SOURCE_DIR}/../src/%s.cpp
+	return sb.String()
+}
+
+// generateTestTarget defines a test executable linked like the tests/ target
+func generateTestTarget(target, testFiles string, includes IncludeConfig) string {
+	return fmt.Sprintf(`
+add_executable(%s
+    %s
+    ${FORGE_SOURCES}
 )
 
-target_include_directories(%s_tests
+target_include_directories(%s
     PRIVATE
-        ${CMAKE_CURRENT_SOURCE_DIR}/../include
-)
+%s)
 
-# Link libraries from dependencies.cmake (FORGE_LINK_LIBRARIES + FORGE_TEST_LINK_LIBRARIES)
-target_link_libraries(%s_tests
+# Link libraries from dependencies.cmake (every FORGE_*LINK_LIBRARIES scope + FORGE_TEST_LINK_LIBRARIES)
+target_link_libraries(%s
     PRIVATE
         ${FORGE_LINK_LIBRARIES}
+        ${FORGE_PUBLIC_LINK_LIBRARIES}
+        ${FORGE_PRIVATE_LINK_LIBRARIES}
+        ${FORGE_INTERFACE_LINK_LIBRARIES}
+        ${FORGE_SUBDIRECTORY_TARGETS}
         ${FORGE_TEST_LINK_LIBRARIES}
 )
 
-`, projectName, projectName, projectName, projectName, projectName))
+# Unity build follows the main target
+if(FORGE_UNITY_BUILD)
+    set_target_properties(%s PROPERTIES UNITY_BUILD ON)
+endif()
 
+`, target, testFiles, target, includeDirLines(includes.all(), subdirIncludeFormat), target, target)
+}
+
+// generateTestDiscovery registers target's tests with CTest under label
+func generateTestDiscovery(target, label string, hasGtest, hasCatch2 bool) string {
 	if hasGtest {
-		sb.WriteString(fmt.Sprintf(`include(GoogleTest)
-gtest_discover_tests(%s_tests)
-`, projectName))
-	} else if hasCatch2 {
-		sb.WriteString(fmt.Sprintf(`include(CTest)
+		return fmt.Sprintf(`include(GoogleTest)
+gtest_discover_tests(%s PROPERTIES LABELS "%s")
+`, target, label)
+	}
+	if hasCatch2 {
+		return fmt.Sprintf(`include(CTest)
 include(Catch)
-catch_discover_tests(%s_tests)
-`, projectName))
-	} else {
-		sb.WriteString(fmt.Sprintf(`add_test(NAME %s_tests COMMAND %s_tests)
-`, projectName, projectName))
+catch_discover_tests(%s PROPERTIES LABELS "%s")
+`, target, label)
 	}
+	return fmt.Sprintf(`add_test(NAME %s COMMAND %s)
+set_tests_properties(%s PROPERTIES LABELS "%s")
+`, target, target, target, label)
+}
 
-	return sb.String()
+// testTargetName turns a labelled test directory into a target name part:
+// tests/integration becomes integration, bench/micro becomes bench_micro
+func testTargetName(dir string) string {
+	dir = strings.TrimPrefix(dir, "tests/")
+	return strings.Trim(nonIdentifierChars.ReplaceAllString(dir, "_"), "_")
+}
+
+// generateFuzzCMake builds <project>_fuzz with libFuzzer and AddressSanitizer
+func generateFuzzCMake(projectName string, includes IncludeConfig) string {
+	return fmt.Sprintf(`# Fuzz targets for %s (enabled with -DFORGE_ENABLE_FUZZING=ON)
+
+if(NOT CMAKE_CXX_COMPILER_ID MATCHES "Clang")
+    message(FATAL_ERROR "Fuzzing requires clang (libFuzzer), got ${CMAKE_CXX_COMPILER_ID}")
+endif()
+
+add_executable(%s_fuzz
+    fuzz_target.cpp
+    ${FORGE_SOURCES}
+)
+
+target_include_directories(%s_fuzz
+    PRIVATE
+%s)
+
+target_compile_options(%s_fuzz PRIVATE -fsanitize=fuzzer,address -g)
+target_link_options(%s_fuzz PRIVATE -fsanitize=fuzzer,address)
+
+target_link_libraries(%s_fuzz
+    PRIVATE
+        ${FORGE_LINK_LIBRARIES}
+        ${FORGE_PUBLIC_LINK_LIBRARIES}
+        ${FORGE_PRIVATE_LINK_LIBRARIES}
+        ${FORGE_INTERFACE_LINK_LIBRARIES}
+        ${FORGE_SUBDIRECTORY_TARGETS}
+)
+`, projectName, projectName, projectName, includeDirLines(includes.all(), subdirIncludeFormat), projectName, projectName, projectName)
+}
+
+// generateFuzzTarget returns a LLVMFuzzerTestOneInput stub
+func generateFuzzTarget(projectName, namespace string) string {
+	return fmt.Sprintf(`#include <%s/%s.hpp>
+
+#include <cstddef>
+#include <cstdint>
+#include <string>
+
+// libFuzzer entry point: called once per generated input.
+// Feed the bytes into the code under test; crashes and sanitizer reports are findings.
+extern "C" int LLVMFuzzerTestOneInput(const uint8_t* data, size_t size) {
+    std::string input(reinterpret_cast<const char*>(data), size);
+    (void)input;  // TODO: pass input to %s:: parsing code
+    return 0;
+}
+`, projectName, projectName, namespace)
 }
 
-func generateTestMain(projectName string, libraryIDs []string, testingFramework string) string {
+func generateTestMain(projectName, namespace string, libraryIDs []string, testingFramework string) string {
 	hasGtest := false
 	hasCatch2 := false
 	hasDoctest := false
@@ -768,7 +1576,7 @@ TEST(%sTest, GreetTest) {
     // Should not throw
     EXPECT_NO_THROW(%s::greet());
 }
-`, projectName, projectName, capName, projectName, capName, projectName)
+`, projectName, projectName, capName, namespace, capName, namespace)
 	} else if hasCatch2 {
 		return fmt.Sprintf(`#include <catch2/catch_test_macros.hpp>
 #include <%s/%s.hpp>
@@ -780,7 +1588,7 @@ TEST_CASE("%s::version returns correct version", "[version]") {
 TEST_CASE("%s::greet does not throw", "[greet]") {
     REQUIRE_NOTHROW(%s::greet());
 }
-`, projectName, projectName, projectName, projectName, projectName, projectName)
+`, projectName, projectName, namespace, namespace, namespace, namespace)
 	} else if hasDoctest {
 		return fmt.Sprintf(`#define DOCTEST_CONFIG_IMPLEMENT_WITH_MAIN
 #include <doctest/doctest.h>
@@ -793,7 +1601,7 @@ TEST_CASE("testing version") {
 TEST_CASE("testing greet") {
     CHECK_NOTHROW(%s::greet());
 }
-`, projectName, projectName, projectName, projectName)
+`, projectName, projectName, namespace, namespace)
 	} else {
 		return fmt.Sprintf(`// Basic test file - add a test framework for better testing support
 #include <%s/%s.hpp>
@@ -806,15 +1614,54 @@ int main() {
     std::cout << "All tests passed!" << std::endl;
     return 0;
 }
-`, projectName, projectName, projectName, projectName)
+`, projectName, projectName, namespace, namespace)
 	}
 }
 
-func generateReadme(projectName string, libraryIDs []string, cppStandard int, projectType string) string {
+// generateReadme lists each dependency with the tag and repository that
+// dependencies.cmake fetches (resolved), matching forge.lock
+func generateReadme(projectName, description string, authors []string, libraryIDs []string, resolved map[string]LockEntry, cppStandard int, projectType string, license, repository string) string {
+	// package.description replaces the generic subtitle
+	subtitle := "A C++ project using modern CMake and FetchContent for dependency management."
+	if projectType == "lib" {
+		subtitle = "A C++ library using modern CMake and FetchContent for dependency management."
+	}
+	if description != "" {
+		subtitle = description
+	}
+
+	var authorsSection string
+	if len(authors) > 0 {
+		authorsSection = "## Authors\n\n"
+		for _, author := range authors {
+			authorsSection += "- " + author + "\n"
+		}
+		authorsSection += "\n"
+	}
+
+	// package.repository adds clone instructions ahead of the build steps
+	var cloneSection string
+	if repository != "" {
+		cloneDir := strings.TrimSuffix(path.Base(strings.TrimRight(repository, "/")), ".git")
+		cloneSection = "## Getting the Source\n\n```bash\ngit clone " + repository + "\ncd " + cloneDir + "\n```\n\n"
+	}
+
+	// Without package.license the README keeps the historical MIT line
+	licenseSection := "MIT License"
+	if name, ok := licenseNames[license]; ok {
+		licenseSection = fmt.Sprintf("Licensed under the %s. See [LICENSE](LICENSE) for details.", name)
+	}
+
 	var libList strings.Builder
 	if len(libraryIDs) > 0 {
+		libList.WriteString("| Library | Version | Source |\n")
+		libList.WriteString("|---------|---------|--------|\n")
 		for _, libID := range libraryIDs {
-			libList.WriteString(fmt.Sprintf("- %s\n", libID))
+			if entry, ok := resolved[libID]; ok {
+				libList.WriteString(fmt.Sprintf("| %s | %s | %s |\n", libID, entry.Tag, entry.Git))
+			} else {
+				libList.WriteString(fmt.Sprintf("| %s | system | find_package |\n", libID))
+			}
 		}
 	} else {
 		libList.WriteString("No external dependencies.")
@@ -823,7 +1670,7 @@ func generateReadme(projectName string, libraryIDs []string, cppStandard int, pr
 	if projectType == "lib" {
 		return fmt.Sprintf(`# %s
 
-A C++ library using modern CMake and FetchContent for dependency management.
+%s
 
 ## Requirements
 
@@ -834,7 +1681,7 @@ A C++ library using modern CMake and FetchContent for dependency management.
 
 %s
 
-## Building
+%s## Building
 
 `+"```bash\nmkdir build && cd build\ncmake ..\ncmake --build .\n```"+`
 
@@ -861,14 +1708,14 @@ To update dependencies, edit `+"`forge.yaml`"+` and run:
 
 This regenerates .cmake/forge/dependencies.cmake without modifying your CMakeLists.txt.
 
-## License
+%s## License
 
-MIT License
-`, projectName, cppStandard, libList.String(), projectName, projectName, projectName, projectName, projectName, projectName)
+%s
+`, projectName, subtitle, cppStandard, libList.String(), cloneSection, projectName, projectName, projectName, projectName, projectName, projectName, authorsSection, licenseSection)
 	} else {
 		return fmt.Sprintf(`# %s
 
-A C++ project using modern CMake and FetchContent for dependency management.
+%s
 
 ## Requirements
 
@@ -879,7 +1726,7 @@ A C++ project using modern CMake and FetchContent for dependency management.
 
 %s
 
-## Building
+%s## Building
 
 `+"```bash\nmkdir build && cd build\ncmake ..\ncmake --build .\n```"+`
 
@@ -902,22 +1749,29 @@ To update dependencies, edit `+"`forge.yaml`"+` and run:
 
 This regenerates .cmake/forge/dependencies.cmake without modifying your CMakeLists.txt.
 
-## License
+%s## License
 
-MIT License
-`, projectName, cppStandard, libList.String(), projectName, projectName, projectName, projectName, projectName)
+%s
+`, projectName, subtitle, cppStandard, libList.String(), cloneSection, projectName, projectName, projectName, projectName, projectName, authorsSection, licenseSection)
 	}
 }
 
 func generateGitignore() string {
 	return `# Build directories
 build/
+build-fuzz/
 cmake-build-*/
 out/
 
+# Profile data (forge build --pgo-generate)
+.forge/pgo/
+
 # IDE
 .idea/
-.vscode/
+.vscode/*
+!.vscode/settings.json
+!.vscode/c_cpp_properties.json
+!.vscode/tasks.json
 *.swp
 *.swo
 *~
@@ -946,3 +1800,202 @@ Testing/
 *.tar.gz
 `
 }
+
+// clangFormatStyles are the .clang-format settings forge writes for each build.clang_format style
+// (the server has its own copy; both are tested against the same golden files)
+var clangFormatStyles = map[string]string{
+	"Google": `BasedOnStyle: Google
+IndentWidth: 4
+ColumnLimit: 100
+AllowShortFunctionsOnASingleLine: Empty
+AllowShortIfStatementsOnASingleLine: Never
+AllowShortLoopsOnASingleLine: false
+BreakBeforeBraces: Attach
+PointerAlignment: Left
+SpaceAfterCStyleCast: false
+SpaceBeforeParens: ControlStatements
+`,
+	"LLVM": `BasedOnStyle: LLVM
+IndentWidth: 2
+ColumnLimit: 80
+AllowShortFunctionsOnASingleLine: All
+AllowShortIfStatementsOnASingleLine: Never
+BreakBeforeBraces: Attach
+PointerAlignment: Right
+SpaceBeforeParens: ControlStatements
+`,
+	"Chromium": `BasedOnStyle: Chromium
+IndentWidth: 2
+ColumnLimit: 80
+AllowShortFunctionsOnASingleLine: Inline
+AllowShortIfStatementsOnASingleLine: Never
+BreakBeforeBraces: Attach
+PointerAlignment: Left
+DerivePointerAlignment: false
+`,
+	"Mozilla": `BasedOnStyle: Mozilla
+IndentWidth: 2
+ColumnLimit: 80
+AllowShortFunctionsOnASingleLine: Inline
+BreakBeforeBraces: Mozilla
+PointerAlignment: Left
+AlwaysBreakAfterDefinitionReturnType: TopLevel
+`,
+	"WebKit": `BasedOnStyle: WebKit
+IndentWidth: 4
+ColumnLimit: 0
+AllowShortFunctionsOnASingleLine: All
+BreakBeforeBraces: WebKit
+PointerAlignment: Left
+NamespaceIndentation: Inner
+`,
+	"Microsoft": `BasedOnStyle: Microsoft
+IndentWidth: 4
+ColumnLimit: 120
+AllowShortFunctionsOnASingleLine: None
+BreakBeforeBraces: Allman
+PointerAlignment: Left
+AccessModifierOffset: -4
+AlignAfterOpenBracket: Align
+`,
+	"GNU": `BasedOnStyle: GNU
+IndentWidth: 2
+ColumnLimit: 79
+AllowShortFunctionsOnASingleLine: None
+BreakBeforeBraces: GNU
+PointerAlignment: Right
+SpaceBeforeParens: Always
+`,
+}
+
+// clangFormatHeaderRegex matches the first line of a forge-generated .clang-format
+// and captures the digest of the settings it came from
+var clangFormatHeaderRegex = regexp.MustCompile(`^# Generated by forge .*\(settings ([0-9a-f]+)\)`)
+
+// clangFormatDigest identifies a style and its overrides
+func clangFormatDigest(style string, overrides map[string]interface{}) string {
+	data, _ := yaml.Marshal(overrides) // map keys are sorted
+	sum := sha256.Sum256(append([]byte(style+"\n"), data...))
+	return hex.EncodeToString(sum[:6])
+}
+
+// generateClangFormat renders .clang-format for style with overrides replacing or
+// extending its keys. Styles forge has no settings for are only based on.
+func generateClangFormat(style string, overrides map[string]interface{}) (string, error) {
+	base, ok := clangFormatStyles[style]
+	if !ok {
+		base = fmt.Sprintf("BasedOnStyle: %s\n", style)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Generated by forge from build.clang_format and build.clang_format_overrides (settings %s)\n", clangFormatDigest(style, overrides)))
+	sb.WriteString("# Edits are kept until those settings change\n")
+	for _, line := range strings.SplitAfter(base, "\n") {
+		key, _, _ := strings.Cut(line, ":")
+		if _, overridden := overrides[key]; overridden && key != "BasedOnStyle" {
+			continue
+		}
+		sb.WriteString(line)
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		if key != "BasedOnStyle" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		out, err := yaml.Marshal(map[string]interface{}{key: overrides[key]})
+		if err != nil {
+			return "", fmt.Errorf("invalid clang_format_overrides.%s: %w", key, err)
+		}
+		sb.Write(out)
+	}
+	return sb.String(), nil
+}
+
+func generateGitAttributes() string {
+	return `# Normalize line endings to LF for all text files
+* text=auto eol=lf
+
+# Windows scripts need CRLF
+*.bat text eol=crlf
+*.cmd text eol=crlf
+*.ps1 text eol=crlf
+
+# Binary files
+*.png binary
+*.jpg binary
+*.jpeg binary
+*.gif binary
+*.ico binary
+*.pdf binary
+*.zip binary
+*.gz binary
+*.a binary
+*.lib binary
+*.so binary
+*.dylib binary
+*.dll binary
+*.exe binary
+`
+}
+
+// generateVSCodeFiles returns .vscode settings pointing clangd and the C/C++
+// extension at build/compile_commands.json, plus tasks for forge build/test
+func generateVSCodeFiles(config *ForgeConfig) (map[string]string, error) {
+	compileCommands := "${workspaceFolder}/build/compile_commands.json"
+
+	settings := map[string]interface{}{
+		"C_Cpp.default.compileCommands": compileCommands,
+		"clangd.arguments":              []string{"--compile-commands-dir=${workspaceFolder}/build"},
+		"cmake.buildDirectory":          "${workspaceFolder}/build",
+	}
+
+	var includePath []string
+	for _, dir := range getIncludeDirsFromConfig(config).all() {
+		includePath = append(includePath, "${workspaceFolder}/"+dir)
+	}
+	properties := map[string]interface{}{
+		"configurations": []map[string]interface{}{{
+			"name":             "forge",
+			"includePath":      includePath,
+			"compileCommands":  compileCommands,
+			"cppStandard":      fmt.Sprintf("c++%d", getCppStandardFromConfig(config)),
+			"intelliSenseMode": "${default}",
+		}},
+		"version": 4,
+	}
+
+	forgeTask := func(label, command string, group map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"label":          label,
+			"type":           "shell",
+			"command":        command,
+			"group":          group,
+			"problemMatcher": []string{"$gcc"},
+		}
+	}
+	tasks := map[string]interface{}{
+		"version": "2.0.0",
+		"tasks": []map[string]interface{}{
+			forgeTask("forge build", "forge build", map[string]interface{}{"kind": "build", "isDefault": true}),
+			forgeTask("forge test", "forge test", map[string]interface{}{"kind": "test", "isDefault": true}),
+		},
+	}
+
+	files := make(map[string]string)
+	for path, content := range map[string]interface{}{
+		".vscode/settings.json":         settings,
+		".vscode/c_cpp_properties.json": properties,
+		".vscode/tasks.json":            tasks,
+	} {
+		data, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", path, err)
+		}
+		files[path] = string(data) + "\n"
+	}
+	return files, nil
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerateUtilsCMakeVersionCommandSourceDir(t *testing.T) {
+	out := generateUtilsCMake()
+	if !strings.Contains(out, `set(FORGE_SOURCE_DIR "${CMAKE_CURRENT_SOURCE_DIR}")`) {
+		t.Errorf("version.hpp command doesn't set FORGE_SOURCE_DIR to the source dir:\n%s", out)
+	}
+	if strings.Contains(out, "topLevelIncludeFormat") {
+		t.Errorf("a Go identifier leaked into the CMake template")
+	}
+}
+
+// testConfig parses a forge.yaml document
+func testConfig(t *testing.T, manifest string) ForgeConfig {
+	t.Helper()
+	var config ForgeConfig
+	if err := yaml.Unmarshal([]byte(manifest), &config); err != nil {
+		t.Fatalf("parse manifest: %v", err)
+	}
+	return config
+}
+
+// snapshotDir maps every file under dir to its content
+func snapshotDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("snapshot %s: %v", dir, err)
+	}
+	return files
+}
+
+func TestGenerateProjectFilesFailureLeavesProjectUntouched(t *testing.T) {
+	parent := t.TempDir()
+	project := filepath.Join(parent, "demo")
+	if err := os.MkdirAll(filepath.Join(project, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"forge.yaml":     "package:\n  name: demo\n",
+		"CMakeLists.txt": "# hand-edited\n",
+		"src/demo.cpp":   "// user code\n",
+	} {
+		if err := os.WriteFile(filepath.Join(project, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := snapshotDir(t, project)
+
+	// A private include directory named CMakeLists.txt makes writing CMakeLists.txt
+	// fail after dependencies.cmake and the headers were already generated
+	config := testConfig(t, "package:\n  name: demo\ninclude:\n  private: [CMakeLists.txt]\n")
+	err := generateProjectFiles(config, project, "# deps\n")
+	if err == nil || !strings.Contains(err.Error(), "CMakeLists.txt") {
+		t.Fatalf("generateProjectFiles error = %v, want a CMakeLists.txt write failure", err)
+	}
+
+	if after := snapshotDir(t, project); !reflect.DeepEqual(before, after) {
+		t.Errorf("project changed by a failed generate:\nbefore %v\nafter  %v", before, after)
+	}
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("staging directory left behind: %v", entries)
+	}
+}
+
+func TestGenerateProjectFilesKeepsExistingFiles(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "demo")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "LICENSE"), []byte("custom\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := testConfig(t, "package:\n  name: demo\n  license: MIT\ntesting:\n  framework: googletest\n  fuzz: true\n")
+	if err := generateProjectFiles(config, project, "# deps\n"); err != nil {
+		t.Fatalf("generateProjectFiles: %v", err)
+	}
+
+	files := snapshotDir(t, project)
+	if files["LICENSE"] != "custom\n" {
+		t.Errorf("existing LICENSE was replaced: %q", files["LICENSE"])
+	}
+	for _, name := range []string{".cmake/forge/dependencies.cmake", "CMakeLists.txt", "src/demo.cpp", "tests/test_main.cpp", "fuzz/fuzz_target.cpp"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("%s was not generated", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(project, "fuzz/corpus")); err != nil {
+		t.Errorf("empty fuzz/corpus directory was not created: %v", err)
+	}
+}
+
+func TestGenerateProjectFilesCreatesMissingDirectory(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "demo")
+	if err := generateProjectFiles(testConfig(t, "package:\n  name: demo\n"), project, "# deps\n"); err != nil {
+		t.Fatalf("generateProjectFiles: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(project, "CMakeLists.txt")); err != nil {
+		t.Errorf("CMakeLists.txt missing: %v", err)
+	}
+}
+
+// generatedFiles generates a project from manifest and returns its files
+func generatedFiles(t *testing.T, manifest string) map[string]string {
+	t.Helper()
+	project := filepath.Join(t.TempDir(), "demo")
+	if err := generateProjectFiles(testConfig(t, manifest), project, "# deps\n"); err != nil {
+		t.Fatalf("generateProjectFiles: %v", err)
+	}
+	return snapshotDir(t, project)
+}
+
+func TestGenerateUnityBuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "off by default",
+			build:   "",
+			want:    []string{`option(FORGE_UNITY_BUILD "Compile project sources in unity batches" OFF)`, "set_target_properties(demo PROPERTIES UNITY_BUILD ON)"},
+			notWant: []string{"UNITY_BUILD_BATCH_SIZE"},
+		},
+		{
+			name:  "enabled",
+			build: "build:\n  unity: true\n",
+			want:  []string{`option(FORGE_UNITY_BUILD "Compile project sources in unity batches" ON)`},
+		},
+		{
+			name:  "batch size",
+			build: "build:\n  unity: true\n  unity_batch_size: 16\n",
+			want:  []string{"set_target_properties(demo PROPERTIES UNITY_BUILD ON UNITY_BUILD_BATCH_SIZE 16)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := generatedFiles(t, "package:\n  name: demo\ntesting:\n  framework: googletest\n"+tt.build)
+			for _, want := range tt.want {
+				if !strings.Contains(files["CMakeLists.txt"], want) {
+					t.Errorf("CMakeLists.txt does not contain %q", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(files["CMakeLists.txt"], notWant) {
+					t.Errorf("CMakeLists.txt contains %q", notWant)
+				}
+			}
+			if !strings.Contains(files["tests/CMakeLists.txt"], "set_target_properties(demo_tests PROPERTIES UNITY_BUILD ON)") {
+				t.Errorf("tests/CMakeLists.txt doesn't follow the unity option:\n%s", files["tests/CMakeLists.txt"])
+			}
+		})
+	}
+}
+
+func TestFeatureWithDependencyAndDefine(t *testing.T) {
+	config := testConfig(t, `package:
+  name: demo
+features:
+  telemetry:
+    dependencies:
+      spdlog: {}
+    defines: [-DENABLE_TELEMETRY, TELEMETRY_LEVEL=2]
+    cxx_flags: "-fno-omit-frame-pointer"
+`)
+	if _, err := resolveFeatures(&config, "telemetry"); err != nil {
+		t.Fatalf("resolveFeatures: %v", err)
+	}
+	if _, ok := config.Dependencies["spdlog"]; !ok {
+		t.Errorf("feature dependency not enabled: %v", config.Dependencies)
+	}
+
+	project := filepath.Join(t.TempDir(), "demo")
+	if err := generateProjectFiles(config, project, "# deps\n"); err != nil {
+		t.Fatalf("generateProjectFiles: %v", err)
+	}
+	cmake := snapshotDir(t, project)["CMakeLists.txt"]
+	for _, want := range []string{
+		"target_compile_definitions(demo\n    PRIVATE\n        ENABLE_TELEMETRY\n        TELEMETRY_LEVEL=2\n)",
+		"target_compile_options(demo\n    PRIVATE\n        -fno-omit-frame-pointer\n)",
+	} {
+		if !strings.Contains(cmake, want) {
+			t.Errorf("CMakeLists.txt does not contain %q:\n%s", want, cmake)
+		}
+	}
+	if strings.Contains(cmake, "add_compile_definitions") || strings.Contains(cmake, "add_compile_options") {
+		t.Errorf("feature settings applied directory-wide:\n%s", cmake)
+	}
+
+	// Feature settings come from the enabled features, so saving must not copy them into build
+	saved, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(saved), "TELEMETRY_LEVEL=2") != 1 {
+		t.Errorf("feature defines saved to the manifest:\n%s", saved)
+	}
+}
+
+func TestGenerateTestCMakeLabels(t *testing.T) {
+	config := testConfig(t, `package:
+  name: demo
+testing:
+  framework: googletest
+  labels:
+    ./tests/integration/: integration
+    bench: perf
+    tests/: fast
+`)
+	labels := getTestLabelsFromConfig(&config)
+	wantLabels := []testLabel{{Dir: "tests", Label: "fast"}, {Dir: "bench", Label: "perf"}, {Dir: "tests/integration", Label: "integration"}}
+	if !reflect.DeepEqual(labels, wantLabels) {
+		t.Fatalf("getTestLabelsFromConfig = %v, want %v", labels, wantLabels)
+	}
+	if labels := getTestLabelsFromConfig(&ForgeConfig{}); !reflect.DeepEqual(labels, []testLabel{{Dir: "tests", Label: "unit"}}) {
+		t.Errorf("default labels = %v", labels)
+	}
+
+	tests := []struct {
+		name      string
+		libraries []string
+		want      []string
+	}{
+		{
+			name:      "googletest",
+			libraries: []string{"googletest"},
+			want: []string{
+				`gtest_discover_tests(demo_tests PROPERTIES LABELS "fast")`,
+				"file(GLOB FORGE_BENCH_TEST_FILES CONFIGURE_DEPENDS ${PROJECT_SOURCE_DIR}/bench/*.cpp)",
+				"add_executable(demo_bench_tests\n    ${FORGE_BENCH_TEST_FILES}\n",
+				`gtest_discover_tests(demo_bench_tests PROPERTIES LABELS "perf")`,
+				"file(GLOB FORGE_INTEGRATION_TEST_FILES CONFIGURE_DEPENDS ${PROJECT_SOURCE_DIR}/tests/integration/*.cpp)",
+				`gtest_discover_tests(demo_integration_tests PROPERTIES LABELS "integration")`,
+			},
+		},
+		{
+			name: "no framework",
+			want: []string{
+				`set_tests_properties(demo_tests PROPERTIES LABELS "fast")`,
+				`set_tests_properties(demo_bench_tests PROPERTIES LABELS "perf")`,
+				`set_tests_properties(demo_integration_tests PROPERTIES LABELS "integration")`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := generateTestCMake("demo", tt.libraries, "googletest", labels, IncludeConfig{Public: []string{"include"}}, false)
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("tests/CMakeLists.txt does not contain %q:\n%s", want, out)
+				}
+			}
+			if n := strings.Count(out, "LABELS"); n != 3 {
+				t.Errorf("got %d LABELS, want one per directory:\n%s", n, out)
+			}
+		})
+	}
+}
+
+func TestWriteSourceTestStubs(t *testing.T) {
+	projectDir := t.TempDir()
+	outputDir := t.TempDir()
+	for name, content := range map[string]string{
+		"src/main.cpp":             "int main() {}\n",
+		"src/.cpp":                 "\n",
+		"src/net/socket-io.cpp":    "\n",
+		"src/parser.cc":            "\n",
+		"src/notes.txt":            "\n",
+		"tests/test_parser.cpp":    "// written by hand\n",
+		"tests/test_placeholder.h": "\n",
+	} {
+		path := filepath.Join(projectDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(outputDir, "tests"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeSourceTestStubs(projectDir, outputDir, []string{"googletest"}); err != nil {
+		t.Fatalf("writeSourceTestStubs: %v", err)
+	}
+	files := snapshotDir(t, outputDir)
+	if len(files) != 1 {
+		t.Errorf("generated %v, want only tests/test_net_socket_io.cpp", files)
+	}
+	if stub := files["tests/test_net_socket_io.cpp"]; !strings.Contains(stub, "TEST(Net_socket_ioTest, Placeholder)") {
+		t.Errorf("unexpected stub:\n%s", stub)
+	}
+}
+
+func TestGenerateProjectFilesRejectsPathsOutsideProject(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+	}{
+		{name: "public include dir", manifest: "package:\n  name: demo\ninclude:\n  public: [../escape]\n"},
+		{name: "private include dir", manifest: "package:\n  name: demo\ninclude:\n  private: [include/../../../escape]\n"},
+		{name: "absolute include dir", manifest: "package:\n  name: demo\ninclude:\n  private: [" + filepath.ToSlash(filepath.Join(t.TempDir(), "escape")) + "]\n"},
+		{name: "package name", manifest: "package:\n  name: ../../../escape\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			project := filepath.Join(parent, "demo")
+			err := generateProjectFiles(testConfig(t, tt.manifest), project, "# deps\n")
+			if err == nil || !strings.Contains(err.Error(), "must stay inside the project") {
+				t.Fatalf("generateProjectFiles error = %v, want a path error", err)
+			}
+			if exitCode(err) != ExitConfig {
+				t.Errorf("exit code = %d, want %d", exitCode(err), ExitConfig)
+			}
+			if files := snapshotDir(t, parent); len(files) != 0 {
+				t.Errorf("files written despite the error: %v", files)
+			}
+		})
+	}
+}
+
+func TestGenerateProjectFilesFileDirectoryCollision(t *testing.T) {
+	project := t.TempDir()
+	// A directory where README.md goes and a file where src/ goes
+	if err := os.MkdirAll(filepath.Join(project, "README.md"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "README.md", "notes.txt"), []byte("keep\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "src"), []byte("not a directory\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := generateProjectFiles(testConfig(t, "package:\n  name: demo\n"), project, "# deps\n"); err != nil {
+		t.Fatalf("generateProjectFiles: %v", err)
+	}
+	files := snapshotDir(t, project)
+	if files["README.md/notes.txt"] != "keep\n" || files["src"] != "not a directory\n" {
+		t.Errorf("colliding paths were replaced: %v", files)
+	}
+	if _, ok := files["CMakeLists.txt"]; !ok {
+		t.Error("other files were not generated")
+	}
+}
+
+// TestGenerateClangFormatMatchesServer checks the CLI's copy of the styles against
+// the golden files the server's generator is tested with
+func TestGenerateClangFormatMatchesServer(t *testing.T) {
+	dir := filepath.Join("..", "forge-server", "internal", "generator", "testdata", "clang-format")
+	data, err := os.ReadFile(filepath.Join(dir, "cases.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []struct {
+		Name      string                 `yaml:"name"`
+		Style     string                 `yaml:"style"`
+		Overrides map[string]interface{} `yaml:"overrides"`
+	}
+	if err := yaml.Unmarshal(data, &cases); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join(dir, tt.Name+".clang-format"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := generateClangFormat(tt.Style, tt.Overrides)
+			if err != nil {
+				t.Fatalf("generateClangFormat: %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("generateClangFormat(%q) =\n%s\nwant (server)\n%s", tt.Style, got, want)
+			}
+		})
+	}
+}
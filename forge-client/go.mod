@@ -2,4 +2,10 @@ module github.com/ozacod/forge
 
 go 1.21
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/pelletier/go-toml/v2 v2.1.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.4.0 // indirect
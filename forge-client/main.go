@@ -4,21 +4,32 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
+	crand "crypto/rand"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"math/rand"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/pelletier/go-toml/v2"
 	"gopkg.in/yaml.v3"
 )
 
@@ -26,9 +37,74 @@ const (
 	Version        = "1.0.43"
 	DefaultServer  = "https://forgecpp.vercel.app"
 	DefaultCfgFile = "forge.yaml"
+	TomlCfgFile    = "forge.toml"
 	LockFile       = "forge.lock"
+
+	installScriptURL = "https://raw.githubusercontent.com/ozacod/forge/master/install.sh"
+)
+
+// Exit codes, so scripts can tell failure modes apart
+const (
+	ExitError   = 1 // generic failure
+	ExitUsage   = 2 // bad arguments (flag parsing errors exit with 2 as well)
+	ExitConfig  = 3 // forge.yaml missing or invalid
+	ExitNetwork = 4 // server unreachable or returned an error
+	ExitBuild   = 5 // configure, build or test failure
+
+	ExitInterrupted = 130 // stopped by SIGINT or SIGTERM, as shells report it
 )
 
+// codedError attaches an exit code to an error
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// withExitCode tags err with an exit code (nil stays nil)
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// errInterrupted is returned by runChild when forge was stopped by a signal
+var errInterrupted = errors.New("interrupted")
+
+// exitCode returns the outermost exit code attached to err, or ExitError.
+// An interruption anywhere in the chain exits with ExitInterrupted, however
+// the failing step wrapped it.
+func exitCode(err error) int {
+	if errors.Is(err, errInterrupted) {
+		return ExitInterrupted
+	}
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return ExitError
+}
+
+// namespaceRegex validates package.namespace (identifiers separated by ::)
+var namespaceRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(::[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// knownCppStandards lists accepted cpp_standard values in ascending order;
+// supporting a new standard is a one-line change here
+var knownCppStandards = []int{11, 14, 17, 20, 23, 26}
+
+// minCMakeForCppStandard is the first CMake release that knows each newer standard
+var minCMakeForCppStandard = map[int]string{
+	20: "3.12",
+	23: "3.20",
+	26: "3.25",
+}
+
+// envVarRegex matches $$, ${VAR}, ${VAR:-default} and $VAR
+var envVarRegex = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
 // Colors for terminal output
 const (
 	Reset   = "\033[0m"
@@ -47,25 +123,117 @@ type ForgeConfig struct {
 		Name        string   `yaml:"name"`
 		Version     string   `yaml:"version"`
 		CppStandard int      `yaml:"cpp_standard"`
+		Namespace   string   `yaml:"namespace,omitempty"`
+		BinName     string   `yaml:"bin_name,omitempty"`
+		ProjectType string   `yaml:"project_type,omitempty"` // exe or lib (default: lib with build.shared_libs)
 		Authors     []string `yaml:"authors,omitempty"`
 		Description string   `yaml:"description,omitempty"`
+		License     string   `yaml:"license,omitempty"` // SPDX id: MIT, Apache-2.0, BSD-3-Clause, GPL-3.0
+		Repository  string   `yaml:"repository,omitempty"`
 	} `yaml:"package"`
 	Build struct {
 		SharedLibs  bool   `yaml:"shared_libs"`
 		ClangFormat string `yaml:"clang_format"`
 		BuildType   string `yaml:"build_type,omitempty"`
 		CxxFlags    string `yaml:"cxx_flags,omitempty"`
+		Compiler    string `yaml:"compiler,omitempty"`
+		CCompiler   string `yaml:"c_compiler,omitempty"`
+		LTO         bool   `yaml:"lto,omitempty"`
+		Warnings    string `yaml:"warnings,omitempty"` // strict, standard (default), off
+		PkgConfig   bool   `yaml:"pkgconfig,omitempty"`
+		// Extra .clang-format keys on top of the clang_format style, e.g. ColumnLimit: 120
+		ClangFormatOverrides map[string]interface{} `yaml:"clang_format_overrides,omitempty"`
+		// Compile project sources in unity batches (FORGE_UNITY_BUILD); 0 keeps CMake's batch size of 8
+		Unity          bool `yaml:"unity,omitempty"`
+		UnityBatchSize int  `yaml:"unity_batch_size,omitempty"`
+		// Install to GNUInstallDirs locations (e.g. lib/x86_64-linux-gnu) instead of lib/ and include/
+		GNUInstallDirs bool `yaml:"gnu_install_dirs,omitempty"`
+		// Install rules and an uninstall target; nil means true for libraries, false for executables
+		Install *bool `yaml:"install,omitempty"`
+		// Collected from enabled features by resolveFeatures, never read from or saved to forge.yaml
+		FeatureDefines  []string `yaml:"-"`
+		FeatureCxxFlags []string `yaml:"-"`
 	} `yaml:"build"`
 	Testing struct {
-		Framework string `yaml:"framework"`
+		Framework string            `yaml:"framework"`
+		Labels    map[string]string `yaml:"labels,omitempty"` // test directory -> CTest label
+		Fuzz      bool              `yaml:"fuzz,omitempty"`   // generate a libFuzzer harness in fuzz/
+		// generate a tests/test_<source>.cpp stub for every source in src/
+		PerSource bool `yaml:"per_source,omitempty"`
+		// nil means true: <name>_tests is part of the default (ALL) build
+		BuildByDefault *bool `yaml:"build_by_default,omitempty"`
 	} `yaml:"testing"`
+	// Files or globs for the main target
+	Sources []string `yaml:"sources,omitempty"`
+	// Hand-written CMake subprojects, added with add_subdirectory and linked into the main target
+	Subdirectories  []string                          `yaml:"subdirectories,omitempty"`
+	Registry        RegistryConfig                    `yaml:"registry,omitempty"`
+	Docs            DocsConfig                        `yaml:"docs,omitempty"`
+	Include         IncludeConfig                     `yaml:"include,omitempty"`
+	Profiles        map[string]BuildOverrides         `yaml:"profiles,omitempty"`
 	Features        map[string]FeatureConfig          `yaml:"features,omitempty"`
 	Dependencies    map[string]map[string]interface{} `yaml:"dependencies"`
 	DevDependencies map[string]map[string]interface{} `yaml:"dev-dependencies,omitempty"`
 }
 
+// RegistryConfig holds team-wide CLI defaults committed in forge.yaml
+type RegistryConfig struct {
+	Server   string   `yaml:"server,omitempty"`
+	Features []string `yaml:"features,omitempty"` // enabled when --features is not given
+	Profile  string   `yaml:"profile,omitempty"`  // applied when --profile is not given
+	// Advisories file or URL for forge audit (default: .forge/advisories.json)
+	Advisories string `yaml:"advisories,omitempty"`
+}
+
+// IncludeConfig declares public (exported) and private header directories.
+// A list (include: [../common.yaml]) names shared manifests instead.
+type IncludeConfig struct {
+	Public    []string `yaml:"public,omitempty"`    // default: include
+	Private   []string `yaml:"private,omitempty"`   // e.g. internal
+	Manifests []string `yaml:"manifests,omitempty"` // merged under this one, see mergeIncludes
+}
+
+// includeConfigFields keeps the default decoding for the mapping form
+type includeConfigFields IncludeConfig
+
+func (c *IncludeConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		*c = IncludeConfig{}
+		return value.Decode(&c.Manifests)
+	}
+	return value.Decode((*includeConfigFields)(c))
+}
+
+// MarshalYAML writes a manifest-only include back in its list form
+func (c IncludeConfig) MarshalYAML() (interface{}, error) {
+	if len(c.Public) == 0 && len(c.Private) == 0 && len(c.Manifests) > 0 {
+		return c.Manifests, nil
+	}
+	return includeConfigFields(c), nil
+}
+
+// DocsConfig configures the Doxyfile generated by forge doc
+type DocsConfig struct {
+	Input   []string `yaml:"input,omitempty"`   // default: src include
+	Exclude []string `yaml:"exclude,omitempty"` // EXCLUDE_PATTERNS
+	Graphs  bool     `yaml:"graphs,omitempty"`  // HAVE_DOT
+	Formats []string `yaml:"formats,omitempty"` // html, latex, xml, man (default: html)
+	Output  string   `yaml:"output,omitempty"`  // default: docs
+}
+
+// BuildOverrides is a named build profile merged over the build section
+type BuildOverrides struct {
+	BuildType string `yaml:"build_type,omitempty"`
+	CxxFlags  string `yaml:"cxx_flags,omitempty"`
+	Compiler  string `yaml:"compiler,omitempty"`
+	CCompiler string `yaml:"c_compiler,omitempty"`
+	LTO       *bool  `yaml:"lto,omitempty"`
+}
+
 type FeatureConfig struct {
 	Dependencies map[string]map[string]interface{} `yaml:"dependencies,omitempty"`
+	Defines      []string                          `yaml:"defines,omitempty"`   // e.g. ENABLE_TELEMETRY or LEVEL=2
+	CxxFlags     string                            `yaml:"cxx_flags,omitempty"` // target_compile_options on the project target
 }
 
 // LockConfig represents the forge.lock structure
@@ -89,10 +257,27 @@ type Library struct {
 	HeaderOnly   bool              `json:"header_only"`
 	CppStandard  int               `json:"cpp_standard"`
 	GithubURL    string            `json:"github_url"`
+	License      string            `json:"license,omitempty"` // SPDX expression
+	VcpkgName    string            `json:"vcpkg_name,omitempty"`
+	ConanRef     string            `json:"conan_ref,omitempty"`
 	Stars        int               `json:"stars,omitempty"`
 	Tags         []string          `json:"tags"`
 	Options      []LibraryOption   `json:"options"`
 	FetchContent map[string]string `json:"fetch_content"`
+	UsageExample string            `json:"usage_example,omitempty"`
+	Deprecated   bool              `json:"deprecated,omitempty"`
+	ReplacedBy   string            `json:"replaced_by,omitempty"`
+}
+
+// deprecationWarning describes a deprecated library and its replacement, or "" if not deprecated
+func deprecationWarning(lib *Library) string {
+	if !lib.Deprecated {
+		return ""
+	}
+	if lib.ReplacedBy != "" {
+		return fmt.Sprintf("%s is deprecated; use %s", lib.ID, lib.ReplacedBy)
+	}
+	return fmt.Sprintf("%s is deprecated", lib.ID)
 }
 
 type LibraryOption struct {
@@ -101,6 +286,7 @@ type LibraryOption struct {
 	Description string      `json:"description"`
 	Type        string      `json:"type"`
 	Default     interface{} `json:"default"`
+	Choices     []string    `json:"choices,omitempty"`
 	CMakeVar    string      `json:"cmake_var"`
 }
 
@@ -131,16 +317,36 @@ func main() {
 		cmdRun(os.Args[2:])
 	case "test":
 		cmdTest(os.Args[2:])
+	case "size":
+		cmdSize(os.Args[2:])
+	case "fuzz":
+		cmdFuzz(os.Args[2:])
 	case "clean":
 		cmdClean(os.Args[2:])
 	case "new", "init":
 		cmdNew(os.Args[2:])
+	case "generate", "gen":
+		cmdGenerate(os.Args[2:])
 	case "add":
 		cmdAdd(os.Args[2:])
 	case "remove", "rm":
 		cmdRemove(os.Args[2:])
 	case "update":
 		cmdUpdate(os.Args[2:])
+	case "outdated":
+		cmdOutdated(os.Args[2:])
+	case "audit":
+		cmdAudit(os.Args[2:])
+	case "licenses":
+		cmdLicenses(os.Args[2:])
+	case "export":
+		cmdExport(os.Args[2:])
+	case "deps":
+		cmdDeps(os.Args[2:])
+	case "sbom":
+		cmdSbom(os.Args[2:])
+	case "expand":
+		cmdExpand(os.Args[2:])
 	case "list":
 		cmdList(os.Args[2:])
 	case "search":
@@ -153,8 +359,18 @@ func main() {
 		cmdLint(os.Args[2:])
 	case "check":
 		cmdCheck(os.Args[2:])
+	case "doctor":
+		cmdDoctor(os.Args[2:])
+	case "self-test":
+		cmdSelfTest(os.Args[2:])
 	case "doc":
 		cmdDoc(os.Args[2:])
+	case "amalgamate":
+		cmdAmalgamate(os.Args[2:])
+	case "ide":
+		cmdIDE(os.Args[2:])
+	case "server":
+		cmdServer(os.Args[2:])
 	case "release":
 		cmdRelease(os.Args[2:])
 	case "upgrade":
@@ -162,7 +378,7 @@ func main() {
 	default:
 		fmt.Fprintf(os.Stderr, "%sError:%s Unknown command: %s\n", Red, Reset, command)
 		printUsage()
-		os.Exit(1)
+		os.Exit(ExitUsage)
 	}
 }
 
@@ -176,18 +392,33 @@ func printUsage() {
     %sbuild%s       Compile the project with CMake (-O0/1/2/3/s/fast, --clean)
     %srun%s         Build and run the project
     %stest%s        Build and run tests
+    %sfuzz%s        Build and run the libFuzzer target
+    %ssize%s        Show the built executable's size (--sections, --compare)
     %sclean%s       Remove build artifacts
     %snew%s         Create a new project (in current or new directory)
+    %sgenerate%s    Regenerate project files from forge.yaml
     %sadd%s         Add a dependency
     %sremove%s      Remove a dependency
     %supdate%s      Update dependencies to latest versions
+    %soutdated%s    Show locked vs recipe vs latest upstream versions
+    %saudit%s       Check locked versions against security advisories
+    %slicenses%s    List dependency licenses (--format json|markdown)
+    %sexport%s      Export dependencies as vcpkg.json or conanfile.txt
+    %sdeps%s        Show resolved dependencies with tags and sources (--json)
+    %ssbom%s        Write a CycloneDX software bill of materials
+    %sexpand%s      Print the fully-resolved effective forge.yaml
     %slist%s        List available libraries
     %ssearch%s      Search for libraries
     %sinfo%s        Show detailed library information
     %sfmt%s         Format code with clang-format
     %slint%s        Run clang-tidy static analysis
     %scheck%s       Check code compiles without building
+    %sdoctor%s      Check that required tools are installed (--json)
+    %sself-test%s   Generate, build and run a throwaway project
     %sdoc%s         Generate documentation
+    %samalgamate%s  Bundle the library's headers into one header
+    %side%s         Write editor configuration (vscode)
+    %sserver%s      List, add and switch between named servers
     %srelease%s     Bump version number
     %supgrade%s     Upgrade forge to the latest version
     %sversion%s     Show version
@@ -196,10 +427,16 @@ func printUsage() {
 EXAMPLES:
     forge new my_project          Create project named 'my_project' in current directory
     forge new my_lib --lib        Create library project
+    forge new -i                  Create a project interactively
     forge new                     Create project (uses folder name)
     forge new -t web-server       Create with template
+    forge new app --template-url https://github.com/me/cpp-template
+                                  Create from a Git template repository
     forge add spdlog              Add dependency
+    forge generate --features gui Regenerate with optional features enabled
     forge add --dev catch2        Add dev dependency
+    forge add --optional --feature gui imgui
+                                  Add optional dependency enabled by a feature
     forge build                   Compile with CMake
     forge run                     Build and run
     forge test                    Run tests
@@ -213,19 +450,33 @@ Run 'forge <COMMAND> --help' for more information on a command.
 		Green, Reset, // build
 		Green, Reset, // run
 		Green, Reset, // test
+		Green, Reset, // fuzz
+		Green, Reset, // size
 		Green, Reset, // clean
-		Green, Reset, // init
 		Green, Reset, // new
+		Green, Reset, // generate
 		Green, Reset, // add
 		Green, Reset, // remove
 		Green, Reset, // update
+		Green, Reset, // outdated
+		Green, Reset, // audit
+		Green, Reset, // licenses
+		Green, Reset, // export
+		Green, Reset, // deps
+		Green, Reset, // sbom
+		Green, Reset, // expand
 		Green, Reset, // list
 		Green, Reset, // search
 		Green, Reset, // info
 		Green, Reset, // fmt
 		Green, Reset, // lint
 		Green, Reset, // check
+		Green, Reset, // doctor
+		Green, Reset, // self-test
 		Green, Reset, // doc
+		Green, Reset, // amalgamate
+		Green, Reset, // ide
+		Green, Reset, // server
 		Green, Reset, // release
 		Green, Reset, // upgrade
 		Green, Reset, // version
@@ -233,33 +484,87 @@ Run 'forge <COMMAND> --help' for more information on a command.
 }
 
 // generateProject generates CMake project files from forge.yaml
-// This function is called by forge new and can be called manually if needed
-func generateProject(serverURL, configFile, outputDir string, features string) error {
+// This function is called by forge new and can be called manually if needed.
+// An outputDir of "-" writes the project as a ZIP to stdout instead. With locked,
+// generation fails instead of changing forge.lock.
+func generateProject(serverURL, configFile, outputDir string, features string, locked, merge bool) error {
+	// In stdout mode status output goes to stderr, so stdout carries only the ZIP
+	var out io.Writer = os.Stdout
+	toStdout := outputDir == "-"
+	if toStdout {
+		out = os.Stderr
+
+		tmpDir, err := os.MkdirTemp("", "forge-generate-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		outputDir = tmpDir
+	}
+
 	// Read config file
+	configFile = resolveConfigPath(configFile)
 	data, err := os.ReadFile(configFile)
 	if err != nil {
 		return fmt.Errorf("failed to read config file '%s': %w", configFile, err)
 	}
 
+	// Expand environment variables, then re-escape $ so the server sees the
+	// resolved values verbatim instead of expanding against its own environment
+	expandedText, err := expandEnvVars(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	// forge.toml is sent to the server as YAML
+	expandedYAML, err := configToYAML(configFile, []byte(expandedText))
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	expandedYAML, err = mergeIncludes(configFile, expandedYAML, true)
+	if err != nil {
+		return err
+	}
+	expanded := string(expandedYAML)
+	data = []byte(strings.ReplaceAll(expanded, "$", "$$"))
+
 	// Parse YAML to get project name
 	var config ForgeConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if err := yaml.Unmarshal([]byte(expanded), &config); err != nil {
 		return fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	// Merge dependencies of enabled features and send the merged manifest
+	enabled, err := resolveFeatures(&config, features)
+	if err != nil {
+		return err
+	}
+	if len(enabled) > 0 {
+		fmt.Fprintf(out, "   Features: %s\n", strings.Join(enabled, ", "))
+		merged, err := yaml.Marshal(&config)
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+		data = []byte(strings.ReplaceAll(string(merged), "$", "$$"))
+	}
+
 	projectName := getProjectNameFromConfig(&config)
 
-	fmt.Printf("%s📦 Generating project '%s' from %s...%s\n", Cyan, projectName, configFile, Reset)
-	fmt.Printf("   Server: %s\n", serverURL)
+	fmt.Fprintf(out, "%s📦 Generating project '%s' from %s...%s\n", Cyan, projectName, configFile, Reset)
+	fmt.Fprintf(out, "   Server: %s\n", serverURL)
+
+	if err := validateTestingFramework(serverURL, config.Testing.Framework); err != nil {
+		return err
+	}
 
 	// Request only dependencies.cmake from server
-	fmt.Printf("%s📥 Fetching dependencies.cmake from server...%s\n", Cyan, Reset)
+	fmt.Fprintf(out, "%s📥 Fetching dependencies.cmake from server...%s\n", Cyan, Reset)
 
 	// Create multipart form
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
 
-	part, err := writer.CreateFormFile("file", filepath.Base(configFile))
+	part, err := writer.CreateFormFile("file", DefaultCfgFile)
 	if err != nil {
 		return fmt.Errorf("failed to create form file: %w", err)
 	}
@@ -268,28 +573,35 @@ func generateProject(serverURL, configFile, outputDir string, features string) e
 		return fmt.Errorf("failed to write form data: %w", err)
 	}
 
+	if err := writeLocalRecipes(out, writer, serverURL, filepath.Dir(configFile)); err != nil {
+		return err
+	}
+
 	if err := writer.Close(); err != nil {
 		return fmt.Errorf("failed to close writer: %w", err)
 	}
 
 	// Make request to server for dependencies only
 	url := fmt.Sprintf("%s/api/forge/dependencies", serverURL)
-	req, err := http.NewRequest("POST", url, &buf)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := doServerRequest(func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req, nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to server: %w\n\nMake sure the server is running:\n  cd forge-server && ./server", err)
 	}
 	defer resp.Body.Close()
 
+	if err := checkServerResponse(resp, "dependencies.cmake"); err != nil {
+		return err
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("server error (%d): %s", resp.StatusCode, string(body))
+		return withExitCode(ExitNetwork, fmt.Errorf("server error (%d): %s", resp.StatusCode, string(body)))
 	}
 
 	// Read dependencies.cmake content
@@ -298,29 +610,114 @@ func generateProject(serverURL, configFile, outputDir string, features string) e
 		return fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Generate all other files locally
-	fmt.Printf("%s🔧 Generating project files locally...%s\n", Cyan, Reset)
+	if locked && !toStdout {
+		lock, err := loadLockFile(filepath.Join(outputDir, LockFile))
+		if err != nil {
+			return withExitCode(ExitConfig, fmt.Errorf("--locked: failed to read %s: %w", LockFile, err))
+		}
+		want := lockForConfig(config, lock, resolvedDependencies(string(dependenciesCMake)))
+		if diffs := lockDifferences(lock, want); len(diffs) > 0 {
+			return withExitCode(ExitConfig, fmt.Errorf("--locked: generating would change %s:\n  %s", LockFile, strings.Join(diffs, "\n  ")))
+		}
+	}
+
+	// Generate all other files locally. Merging generates them aside and then
+	// copies over only the files the project doesn't have yet.
+	fmt.Fprintf(out, "%s🔧 Generating project files locally...%s\n", Cyan, Reset)
+
+	genDir := outputDir
+	if merge {
+		tmpDir, err := os.MkdirTemp("", "forge-merge-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		genDir = tmpDir
+	}
 
-	if err := generateProjectFiles(config, outputDir, string(dependenciesCMake)); err != nil {
+	if err := generateProjectFiles(config, genDir, string(dependenciesCMake)); err != nil {
 		return fmt.Errorf("failed to generate project files: %w", err)
 	}
+	if toStdout {
+		if err := writeZip(os.Stdout, outputDir); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s✅ Project '%s' written to stdout as a ZIP%s\n", Green, projectName, Reset)
+		return nil
+	}
 
 	// Generate lock file
-	if err := generateLockFile(config, outputDir); err != nil {
-		fmt.Printf("%s⚠️  Warning: Could not generate lock file: %v%s\n", Yellow, err, Reset)
+	if err := generateLockFile(config, genDir, resolvedDependencies(string(dependenciesCMake))); err != nil {
+		fmt.Fprintf(out, "%s⚠️  Warning: Could not generate lock file: %v%s\n", Yellow, err, Reset)
 	}
 
-	fmt.Printf("%s✅ Project '%s' generated successfully!%s\n\n", Green, projectName, Reset)
-	fmt.Printf("Next steps:\n")
+	if merge {
+		created, skipped, err := mergeGeneratedFiles(genDir, outputDir)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s   Created %d file(s):%s\n", Green, len(created), Reset)
+		for _, path := range created {
+			fmt.Fprintf(out, "     + %s\n", path)
+		}
+		if len(skipped) > 0 {
+			fmt.Fprintf(out, "%s   Skipped %d existing file(s):%s\n", Yellow, len(skipped), Reset)
+			for _, path := range skipped {
+				fmt.Fprintf(out, "     = %s\n", path)
+			}
+		}
+	}
+
+	// Subdirectory CMakeLists are hand-written; point out ones that don't exist yet
+	for _, dir := range config.Subdirectories {
+		if _, err := os.Stat(filepath.Join(outputDir, dir, "CMakeLists.txt")); os.IsNotExist(err) {
+			fmt.Fprintf(out, "%s⚠️  Warning: subdirectory '%s' has no CMakeLists.txt yet%s\n", Yellow, dir, Reset)
+		}
+	}
+
+	fmt.Fprintf(out, "%s✅ Project '%s' generated successfully!%s\n\n", Green, projectName, Reset)
+	fmt.Fprintf(out, "Next steps:\n")
 	if outputDir != "." {
-		fmt.Printf("  cd %s\n", outputDir)
+		fmt.Fprintf(out, "  cd %s\n", outputDir)
 	}
-	fmt.Printf("  %sforge build%s      # Compile the project\n", Cyan, Reset)
-	fmt.Printf("  %sforge run%s        # Build and run\n", Cyan, Reset)
+	fmt.Fprintf(out, "  %sforge build%s      # Compile the project\n", Cyan, Reset)
+	fmt.Fprintf(out, "  %sforge run%s        # Build and run\n", Cyan, Reset)
 
 	return nil
 }
 
+// mergeGeneratedFiles copies the files under srcDir that don't exist in dstDir
+// and returns the relative paths it created and the ones it left alone
+func mergeGeneratedFiles(srcDir, dstDir string) (created, skipped []string, err error) {
+	err = filepath.WalkDir(srcDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstDir, rel)
+		if _, err := os.Stat(dst); err == nil {
+			skipped = append(skipped, filepath.ToSlash(rel))
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", rel, err)
+		}
+		if err := os.WriteFile(dst, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", rel, err)
+		}
+		created = append(created, filepath.ToSlash(rel))
+		return nil
+	})
+	return created, skipped, err
+}
+
 // ============================================================================
 // BUILD COMMAND - Compile the project with CMake
 // ============================================================================
@@ -333,36 +730,103 @@ func cmdBuild(args []string) {
 	target := fs.String("target", "", "Specific target to build")
 	clean := fs.Bool("clean", false, "Clean build directory before building")
 	optLevel := fs.String("opt", "", "Optimization level: 0, 1, 2, 3, s, fast")
+	compiler := fs.String("compiler", "", "C++ compiler to use (e.g. clang++-17)")
+	cCompiler := fs.String("c-compiler", "", "C compiler to use (e.g. clang-17)")
+	profile := fs.String("profile", "", "Build profile from forge.yaml to apply")
+	verbose := fs.Bool("verbose", false, "Print full compiler command lines")
+	locked := fs.Bool("locked", false, "Fail if forge.lock is missing or out of date")
+	frozen := fs.Bool("frozen", false, "Like --locked, and don't download dependencies")
+	timings := fs.Bool("timings", false, "Print how long configure and compile took")
+	trace := fs.Bool("trace", false, "Like --timings, and write a CMake configure trace (and -ftime-trace with clang)")
+	unity := fs.Bool("unity", false, "Unity build: compile project sources in batches")
+	remote := fs.String("remote", "", "Sync to user@host:/path with rsync and build there over ssh")
+	pgoGenerate := fs.Bool("pgo-generate", false, "Release build instrumented to collect profile data in "+pgoDir+"/")
+	pgoUse := fs.Bool("pgo-use", false, "Release build optimized with the profile data in "+pgoDir+"/")
 	fs.BoolVar(release, "r", false, "Build in release mode (shorthand)")
 	fs.IntVar(jobs, "j", 0, "Number of parallel jobs (shorthand)")
 	fs.BoolVar(clean, "c", false, "Clean before building (shorthand)")
 	fs.StringVar(optLevel, "O", "", "Optimization level (shorthand)")
+	fs.StringVar(profile, "p", "", "Build profile (shorthand)")
+	fs.BoolVar(verbose, "v", false, "Print full compiler command lines (shorthand)")
 	fs.Parse(args)
 
-	if err := buildProject(*release, *debug, *jobs, *target, *clean, *optLevel); err != nil {
+	if *remote != "" {
+		if err := runRemote(*remote, "build", withoutFlag(args, "remote")); err != nil {
+			fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	if *pgoGenerate && *pgoUse {
+		fmt.Fprintf(os.Stderr, "%sError:%s --pgo-generate and --pgo-use are separate builds, pass one of them\n", Red, Reset)
+		os.Exit(ExitUsage)
+	}
+	pgo := ""
+	if *pgoGenerate {
+		pgo = "generate"
+	} else if *pgoUse {
+		pgo = "use"
+	}
+
+	if err := buildProject(*release, *debug, *jobs, *target, *clean, *optLevel, *compiler, *cCompiler, *profile, *verbose, *locked || *frozen, *frozen, *timings || *trace, *trace, *unity, pgo); err != nil {
 		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func buildProject(release, debug bool, jobs int, target string, clean bool, optLevel string) error {
+func buildProject(release, debug bool, jobs int, target string, clean bool, optLevel, compiler, cCompiler, profile string, verbose, locked, frozen, timings, trace, unity bool, pgo string) error {
+	start := time.Now()
 	config, err := loadConfig(DefaultCfgFile)
 	if err != nil {
 		return err
 	}
 
+	if locked {
+		if err := checkLocked(config); err != nil {
+			return err
+		}
+	}
+
+	if profile, err = applyProfile(config, profile); err != nil {
+		return err
+	}
+
 	projectName := getProjectNameFromConfig(config)
 
 	buildDir := "build"
 
+	// Flags take precedence over forge.yaml
+	if compiler == "" {
+		compiler = config.Build.Compiler
+	}
+	if cCompiler == "" {
+		cCompiler = config.Build.CCompiler
+	}
+
+	// Changing the compiler requires a fresh CMake cache
+	if !clean {
+		if changed, cached := compilerChanged(buildDir, "CMAKE_CXX_COMPILER", compiler); changed {
+			fmt.Printf("%s⚠️  C++ compiler changed (%s → %s), cleaning build directory%s\n", Yellow, cached, compiler, Reset)
+			clean = true
+		} else if changed, cached := compilerChanged(buildDir, "CMAKE_C_COMPILER", cCompiler); changed {
+			fmt.Printf("%s⚠️  C compiler changed (%s → %s), cleaning build directory%s\n", Yellow, cached, cCompiler, Reset)
+			clean = true
+		}
+	}
+
 	// Clean if requested
 	if clean {
 		fmt.Printf("%s🧹 Cleaning build directory...%s\n", Cyan, Reset)
 		os.RemoveAll(buildDir)
 	}
 
-	// Determine build type and optimization
-	buildType, cxxFlags := determineBuildType(release, optLevel)
+	// Profile-guided builds optimize unless a build type was asked for
+	if pgo != "" && !debug && optLevel == "" {
+		release = true
+	}
+
+	buildType, cxxFlags := profileBuildType(config, profile, release, debug, optLevel)
 	optInfo := ""
 	if cxxFlags != "" {
 		optInfo = fmt.Sprintf(" [%s]", cxxFlags)
@@ -384,26 +848,104 @@ func buildProject(release, debug bool, jobs int, target string, clean bool, optL
 		touchCMakeCache(buildDir)
 	}
 
+	var phases []buildPhase
+
 	// Configure CMake if needed or if clean was done
-	needsConfigure := clean
+	// A profile always reconfigures so its flags replace the cached ones
+	needsConfigure := clean || profile != ""
 	if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
 		needsConfigure = true
 	}
 
+	// --frozen keeps FetchContent offline; the setting is cached, so switching reconfigures
+	disconnected := "OFF"
+	if frozen {
+		disconnected = "ON"
+	}
+	if cached, _ := readCMakeCacheVar(buildDir, "FETCHCONTENT_FULLY_DISCONNECTED"); cached != disconnected && (frozen || cached == "ON") {
+		needsConfigure = true
+	}
+
+	// Unity builds are a cached option too; projects generated before it existed don't have it
+	unityBuild := "OFF"
+	if unity || config.Build.Unity {
+		unityBuild = "ON"
+	}
+	cachedUnity, _ := readCMakeCacheVar(buildDir, "FORGE_UNITY_BUILD")
+	if cachedUnity != unityBuild && (cachedUnity != "" || unityBuild == "ON") {
+		needsConfigure = true
+	}
+
+	// --trace reconfigures to profile CMake and, with clang, adds -ftime-trace;
+	// the next build without it reconfigures to drop the flag again
+	configureTrace := filepath.Join(buildDir, "forge-configure-trace.json")
+	resetCxxFlags := false
+	if trace {
+		needsConfigure = true
+		if isClangCompiler(buildDir, compiler) {
+			cxxFlags = strings.TrimSpace(cxxFlags + " -ftime-trace")
+		} else {
+			fmt.Printf("%s⚠️  -ftime-trace needs clang (use --compiler clang++), only tracing configure%s\n", Yellow, Reset)
+		}
+	} else if cached, _ := readCMakeCacheVar(buildDir, "CMAKE_CXX_FLAGS"); strings.Contains(cached, "-ftime-trace") {
+		needsConfigure = true
+		resetCxxFlags = true
+	}
+
+	// --pgo-generate/--pgo-use add the profile flags to compile (C and C++) and
+	// link; like -ftime-trace, the next build without them reconfigures to drop them
+	pgoFlags := ""
+	resetPGOFlags := false
+	if pgo != "" {
+		if pgoFlags, err = pgoCompilerFlags(pgo, isClangCompiler(buildDir, compiler), compiler); err != nil {
+			return err
+		}
+		needsConfigure = true
+		cxxFlags = strings.TrimSpace(cxxFlags + " " + pgoFlags)
+	} else if cached, _ := readCMakeCacheVar(buildDir, "CMAKE_CXX_FLAGS"); strings.Contains(cached, "-fprofile-") {
+		needsConfigure = true
+		resetCxxFlags = true
+		resetPGOFlags = true
+	}
+
 	if needsConfigure {
 		fmt.Printf("%s⚙️  Configuring CMake...%s\n", Cyan, Reset)
+		warnIfCppStandardUnsupported(getCppStandardFromConfig(config))
 		cmakeArgs := []string{"-B", buildDir, "-DCMAKE_BUILD_TYPE=" + buildType}
 
-		if cxxFlags != "" {
+		if cxxFlags != "" || resetCxxFlags {
 			cmakeArgs = append(cmakeArgs, "-DCMAKE_CXX_FLAGS="+cxxFlags)
 		}
+		if pgoFlags != "" || resetPGOFlags {
+			// Keep whatever else is cached in these, only the profile flags are ours
+			for _, name := range []string{"CMAKE_C_FLAGS", "CMAKE_EXE_LINKER_FLAGS", "CMAKE_SHARED_LINKER_FLAGS"} {
+				cached, _ := readCMakeCacheVar(buildDir, name)
+				cmakeArgs = append(cmakeArgs, "-D"+name+"="+replacePGOFlags(cached, pgoFlags))
+			}
+		}
+		if compiler != "" {
+			cmakeArgs = append(cmakeArgs, "-DCMAKE_CXX_COMPILER="+compiler)
+		}
+		if cCompiler != "" {
+			cmakeArgs = append(cmakeArgs, "-DCMAKE_C_COMPILER="+cCompiler)
+		}
+		if config.Build.LTO {
+			cmakeArgs = append(cmakeArgs, "-DCMAKE_INTERPROCEDURAL_OPTIMIZATION=ON")
+		}
+		cmakeArgs = append(cmakeArgs, "-DFETCHCONTENT_FULLY_DISCONNECTED="+disconnected)
+		if cachedUnity != "" || unityBuild == "ON" {
+			cmakeArgs = append(cmakeArgs, "-DFORGE_UNITY_BUILD="+unityBuild)
+		}
+		// Needs CMake 3.18; loads in chrome://tracing or Perfetto
+		if trace {
+			cmakeArgs = append(cmakeArgs, "--profiling-format=google-trace", "--profiling-output="+configureTrace)
+		}
 
-		cmd := exec.Command("cmake", cmakeArgs...)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("cmake configure failed: %w", err)
+		configureStart := time.Now()
+		if err := runCMakeConfigure(cmakeArgs...); err != nil {
+			return err
 		}
+		phases = append(phases, buildPhase{"configure", time.Since(configureStart)})
 	}
 
 	// Build
@@ -420,17 +962,142 @@ func buildProject(release, debug bool, jobs int, target string, clean bool, optL
 		buildArgs = append(buildArgs, "--target", target)
 	}
 
+	// --verbose makes both the Makefile and Ninja generators echo each command
+	if verbose {
+		buildArgs = append(buildArgs, "--verbose")
+	}
+
 	buildCmd := exec.Command("cmake", buildArgs...)
 	buildCmd.Stdout = os.Stdout
 	buildCmd.Stderr = os.Stderr
-	if err := buildCmd.Run(); err != nil {
-		return fmt.Errorf("build failed: %w", err)
+	compileStart := time.Now()
+	if err := runChild(buildCmd); err != nil {
+		return withExitCode(ExitBuild, fmt.Errorf("build failed: %w", err))
 	}
+	phases = append(phases, buildPhase{"compile", time.Since(compileStart)})
 
 	fmt.Printf("%s✅ Build complete!%s\n", Green, Reset)
+	if pgo == "generate" {
+		fmt.Printf("\n%sRun representative workloads now; profile data is written to %s/.%s\n", Cyan, pgoDir, Reset)
+		fmt.Printf("Then rebuild with: %sforge build --pgo-use%s\n", Bold, Reset)
+	}
+
+	if timings {
+		fmt.Printf("\n%s⏱️  Timings:%s\n", Bold, Reset)
+		for _, phase := range phases {
+			fmt.Printf("   %-10s %8s\n", phase.name, phase.duration.Round(time.Millisecond))
+		}
+		fmt.Printf("   %-10s %8s\n", "total", time.Since(start).Round(time.Millisecond))
+		if trace {
+			fmt.Printf("   Configure trace: %s\n", configureTrace)
+			if strings.Contains(cxxFlags, "-ftime-trace") {
+				fmt.Printf("   Per-file compile traces: *.json next to the object files in %s\n", buildDir)
+			}
+		}
+	}
 	return nil
 }
 
+// pgoDir holds the profile data of forge build --pgo-generate. It lives outside
+// build/ so a --clean or compiler change between the two builds keeps it.
+const pgoDir = ".forge/pgo"
+
+// replacePGOFlags drops the flags of an earlier --pgo-generate/--pgo-use build
+// from flags and appends pgoFlags (empty when resetting)
+func replacePGOFlags(flags, pgoFlags string) string {
+	var kept []string
+	for _, flag := range strings.Fields(flags) {
+		if strings.HasPrefix(flag, "-fprofile-") || flag == "-Wno-missing-profile" {
+			continue
+		}
+		kept = append(kept, flag)
+	}
+	return strings.TrimSpace(strings.Join(kept, " ") + " " + pgoFlags)
+}
+
+// compilerVersionSuffix captures the version of a versioned compiler name such as clang++-17
+var compilerVersionSuffix = regexp.MustCompile(`-(\d+)$`)
+
+// pgoCompilerFlags returns the compile and link flags for a profile-guided build.
+// "generate" clears old data and instruments the build to write into pgoDir;
+// "use" checks that the instrumented binary has been run and, for clang, merges
+// the raw profiles into default.profdata with llvm-profdata first.
+func pgoCompilerFlags(pgo string, clang bool, compiler string) (string, error) {
+	dir, err := filepath.Abs(pgoDir)
+	if err != nil {
+		return "", err
+	}
+
+	if pgo == "generate" {
+		if err := os.RemoveAll(dir); err != nil {
+			return "", fmt.Errorf("failed to clear %s: %w", pgoDir, err)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", pgoDir, err)
+		}
+		fmt.Printf("%s📈 Instrumenting for profile data in %s/%s\n", Cyan, pgoDir, Reset)
+		return "-fprofile-generate=" + dir, nil
+	}
+
+	// GCC writes .gcda files (with mangled object paths), clang default_*.profraw
+	ext := ".gcda"
+	if clang {
+		ext = ".profraw"
+	}
+	var profiles []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(path) == ext {
+			profiles = append(profiles, path)
+		}
+		return nil
+	})
+	if len(profiles) == 0 {
+		return "", withExitCode(ExitConfig, fmt.Errorf("no profile data in %s/ (build with 'forge build --pgo-generate' and run your workloads first)", pgoDir))
+	}
+	fmt.Printf("%s📈 Optimizing with %d profile file(s) from %s/%s\n", Cyan, len(profiles), pgoDir, Reset)
+
+	if !clang {
+		// -fprofile-correction tolerates counts from multithreaded runs
+		return "-fprofile-use=" + dir + " -fprofile-correction -Wno-missing-profile", nil
+	}
+
+	// llvm-profdata has to match the clang that wrote the profiles: clang++-17 -> llvm-profdata-17
+	bin := ""
+	if m := compilerVersionSuffix.FindStringSubmatch(filepath.Base(compiler)); m != nil {
+		if path, err := exec.LookPath("llvm-profdata-" + m[1]); err == nil {
+			bin = path
+		}
+	}
+	if bin == "" {
+		if bin, err = resolveClangTool("llvm-profdata", ""); err != nil {
+			return "", withExitCode(ExitConfig, err)
+		}
+	}
+	profdata := filepath.Join(dir, "default.profdata")
+	mergeCmd := exec.Command(bin, append([]string{"merge", "-output=" + profdata}, profiles...)...)
+	mergeCmd.Stdout = os.Stdout
+	mergeCmd.Stderr = os.Stderr
+	if err := mergeCmd.Run(); err != nil {
+		return "", withExitCode(ExitBuild, fmt.Errorf("llvm-profdata merge failed: %w", err))
+	}
+	return "-fprofile-use=" + profdata + " -Wno-profile-instr-unprofiled", nil
+}
+
+// buildPhase is one timed step of forge build --timings
+type buildPhase struct {
+	name     string
+	duration time.Duration
+}
+
+// isClangCompiler reports whether the build uses clang, judging by the name of
+// the requested compiler or else the one cached by a previous configure
+func isClangCompiler(buildDir, compiler string) bool {
+	if compiler == "" {
+		compiler, _ = readCMakeCacheVar(buildDir, "CMAKE_CXX_COMPILER")
+	}
+	return strings.Contains(filepath.Base(compiler), "clang")
+}
+
 // ============================================================================
 // RUN COMMAND
 // ============================================================================
@@ -438,39 +1105,112 @@ func buildProject(release, debug bool, jobs int, target string, clean bool, optL
 func cmdRun(args []string) {
 	fs := flag.NewFlagSet("run", flag.ExitOnError)
 	release := fs.Bool("release", false, "Build in release mode")
+	debug := fs.Bool("debug", false, "Build in debug mode")
 	target := fs.String("target", "", "Specific target to run")
+	watch := fs.Bool("watch", false, "Rebuild and rerun when source or header files change")
+	fs.BoolVar(watch, "w", false, "Watch for changes (shorthand)")
+	var env envFlag
+	fs.Var(&env, "env", "Set an environment variable for the executable, KEY=VAL (repeatable)")
+	remote := fs.String("remote", "", "Sync to user@host:/path with rsync, then build and run there over ssh")
 	fs.Parse(args)
 
+	if *remote != "" {
+		if *watch {
+			fmt.Fprintf(os.Stderr, "%sError:%s --watch cannot be combined with --remote\n", Red, Reset)
+			os.Exit(ExitUsage)
+		}
+		if err := runRemote(*remote, "run", withoutFlag(args, "remote")); err != nil {
+			fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
 	// Get remaining args to pass to the executable
 	execArgs := fs.Args()
 
-	if err := runProject(*release, *target, execArgs); err != nil {
+	// Without --release or --debug the last configured build type is kept
+	buildType := ""
+	if *release {
+		buildType = "Release"
+	} else if *debug {
+		buildType = "Debug"
+	}
+
+	run := runProject
+	if *watch {
+		run = watchProject
+	}
+	if err := run(buildType, *target, execArgs, env); err != nil {
 		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func runProject(release bool, target string, execArgs []string) error {
-	config, err := loadConfig(DefaultCfgFile)
+// envFlag collects repeated KEY=VAL flags
+type envFlag []string
+
+func (f *envFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *envFlag) Set(value string) error {
+	key, _, ok := strings.Cut(value, "=")
+	if !ok || !envKeyRegex.MatchString(key) {
+		return fmt.Errorf("expected KEY=VAL, got '%s'", value)
+	}
+	*f = append(*f, value)
+	return nil
+}
+
+// envKeyRegex matches portable environment variable names
+var envKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func runProject(buildType, target string, execArgs, env []string) error {
+	execPath, err := buildExecutable(buildType)
 	if err != nil {
 		return err
 	}
 
+	runCmd := exec.Command(execPath, execArgs...)
+	runCmd.Env = append(os.Environ(), env...)
+	runCmd.Stdout = os.Stdout
+	runCmd.Stderr = os.Stderr
+	runCmd.Stdin = os.Stdin
+	return runChild(runCmd)
+}
+
+// buildExecutable configures and builds the project and returns the executable path.
+// An empty buildType keeps the configured one (build.build_type or Debug for a new
+// build directory); a different one reconfigures.
+func buildExecutable(buildType string) (string, error) {
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return "", err
+	}
+
 	projectName := getProjectNameFromConfig(config)
 
-	buildType, _ := determineBuildType(release, "")
+	buildDir := "build"
+	cached, configured := readCMakeCacheVar(buildDir, "CMAKE_BUILD_TYPE")
+	needsConfigure := !configured
+	switch {
+	case buildType == "" && configured && cached != "":
+		buildType = cached
+	case buildType == "" && config.Build.BuildType != "":
+		buildType = config.Build.BuildType
+	case buildType == "":
+		buildType = "Debug"
+	case configured && cached != buildType:
+		fmt.Printf("%s⚠️  Build type changed (%s → %s), reconfiguring%s\n", Yellow, cached, buildType, Reset)
+		needsConfigure = true
+	}
 
 	fmt.Printf("%s🔨 Building '%s' (%s)...%s\n", Cyan, projectName, buildType, Reset)
 
 	// Configure CMake if needed
-	buildDir := "build"
-	if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
+	if needsConfigure {
 		fmt.Printf("%s⚙️  Configuring CMake...%s\n", Cyan, Reset)
-		cmd := exec.Command("cmake", "-B", buildDir, "-DCMAKE_BUILD_TYPE="+buildType)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("cmake configure failed: %w", err)
+		if err := runCMakeConfigure("-B", buildDir, "-DCMAKE_BUILD_TYPE="+buildType); err != nil {
+			return "", err
 		}
 	}
 
@@ -479,1180 +1219,5525 @@ func runProject(release bool, target string, execArgs []string) error {
 	buildCmd := exec.Command("cmake", "--build", buildDir, "--config", buildType)
 	buildCmd.Stdout = os.Stdout
 	buildCmd.Stderr = os.Stderr
-	if err := buildCmd.Run(); err != nil {
-		return fmt.Errorf("build failed: %w", err)
+	if err := runChild(buildCmd); err != nil {
+		return "", withExitCode(ExitBuild, fmt.Errorf("build failed: %w", err))
 	}
 
-	// Find and run executable
-	execName := projectName
-	if runtime.GOOS == "windows" {
-		execName += ".exe"
+	execPath, err := findExecutable(config, buildDir, buildType)
+	if err != nil {
+		return "", err
 	}
 
-	execPath := filepath.Join(buildDir, execName)
-	if _, err := os.Stat(execPath); os.IsNotExist(err) {
-		// Try in build type subdirectory (MSVC)
-		execPath = filepath.Join(buildDir, buildType, execName)
+	fmt.Printf("\n%s🚀 Running '%s'...%s\n", Green, projectName, Reset)
+	fmt.Println(strings.Repeat("─", 50))
+
+	return execPath, nil
+}
+
+// cmakeErrorHints maps recognizable CMake configure failures to a targeted hint.
+// Hints are printf formats filled with the pattern's submatches.
+var cmakeErrorHints = []struct {
+	pattern *regexp.Regexp
+	hint    string
+}{
+	{regexp.MustCompile(`CMake (\d+(?:\.\d+)*) or higher is required\.\s+You are running version (\S+)`),
+		"CMake %s+ required, you have %s - upgrade CMake"},
+	{regexp.MustCompile(`The CMAKE_CXX_COMPILER:\s+(\S+)\s+is not a full path and was not found`),
+		"compiler %s not found - install it or fix build.compiler in forge.yaml"},
+	{regexp.MustCompile(`No CMAKE_CXX_COMPILER could be found`),
+		"no C++ compiler found - install one or set build.compiler in forge.yaml"},
+	{regexp.MustCompile(`(?:repository '([^']+)' not found|Failed to clone repository: '([^']+)')`),
+		"could not fetch %s, check network or the dependency's tag"},
+	{regexp.MustCompile(`Build step for (\S+) failed`),
+		"could not fetch %s, check network or the dependency's tag"},
+}
+
+// cmakeConfigureHint returns a hint for the first known failure in cmake's stderr, or ""
+func cmakeConfigureHint(stderr string) string {
+	for _, h := range cmakeErrorHints {
+		m := h.pattern.FindStringSubmatch(stderr)
+		if m == nil {
+			continue
+		}
+		var args []interface{}
+		for _, sub := range m[1:] {
+			if sub != "" {
+				args = append(args, sub)
+			}
+		}
+		return fmt.Sprintf(h.hint, args...)
 	}
+	return ""
+}
 
-	if _, err := os.Stat(execPath); os.IsNotExist(err) {
-		return fmt.Errorf("executable not found: tried %s", execPath)
+// childShutdownGrace is how long runChild waits for an interrupted child before killing it
+const childShutdownGrace = 5 * time.Second
+
+// runChild runs cmd like cmd.Run, but in its own process group so that SIGINT and
+// SIGTERM reach the child and everything it spawned (compilers, test binaries).
+// On a signal it is forwarded to the group, the child gets childShutdownGrace to
+// exit, then the rest of the group is killed and an error carrying ExitInterrupted
+// is returned for the command to exit with after its deferred cleanup has run.
+// A child reading the terminal stays in forge's group, where it can read stdin;
+// the terminal delivers Ctrl+C to it directly, so only SIGTERM is forwarded.
+func runChild(cmd *exec.Cmd) error {
+	ownGroup := cmd.Stdin != os.Stdin || !stdinIsTerminal()
+	if ownGroup {
+		setProcessGroup(cmd)
 	}
 
-	fmt.Printf("\n%s🚀 Running '%s'...%s\n", Green, projectName, Reset)
-	fmt.Println(strings.Repeat("─", 50))
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
 
-	runCmd := exec.Command(execPath, execArgs...)
-	runCmd.Stdout = os.Stdout
-	runCmd.Stderr = os.Stderr
-	runCmd.Stdin = os.Stdin
-	return runCmd.Run()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case sig := <-signals:
+		if ownGroup {
+			signalProcessGroup(cmd, sig)
+		} else if sig != os.Interrupt {
+			cmd.Process.Signal(sig)
+		}
+		exited := false
+		select {
+		case <-done:
+			exited = true
+		case <-time.After(childShutdownGrace):
+			fmt.Fprintf(os.Stderr, "%s⚠️  %s didn't exit after %v, killing it%s\n", Yellow, filepath.Base(cmd.Path), childShutdownGrace, Reset)
+			if !ownGroup {
+				cmd.Process.Kill()
+			}
+		}
+		// Also kill whatever the child left behind in its group
+		if ownGroup {
+			killProcessGroup(cmd)
+		}
+		if !exited {
+			<-done
+		}
+		fmt.Fprintf(os.Stderr, "\n%s✋ Interrupted%s\n", Yellow, Reset)
+		return withExitCode(ExitInterrupted, errInterrupted)
+	}
 }
 
-// ============================================================================
-// TEST COMMAND
-// ============================================================================
+// runCMakeConfigure runs cmake with the given configure args, streaming its output
+// and appending a hint to the error for recognizable failures
+func runCMakeConfigure(args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("cmake", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if err := runChild(cmd); err != nil {
+		if hint := cmakeConfigureHint(stderr.String()); hint != "" {
+			return withExitCode(ExitBuild, fmt.Errorf("cmake configure failed: %w\n  hint: %s", err, hint))
+		}
+		return withExitCode(ExitBuild, fmt.Errorf("cmake configure failed: %w", err))
+	}
+	return nil
+}
 
-func cmdTest(args []string) {
-	fs := flag.NewFlagSet("test", flag.ExitOnError)
-	verbose := fs.Bool("verbose", false, "Show verbose output")
-	filter := fs.String("filter", "", "Filter tests by name")
-	fs.BoolVar(verbose, "v", false, "Show verbose output (shorthand)")
-	fs.Parse(args)
+// findExecutable locates the built executable in buildDir, or in its build type
+// subdirectory for multi-config generators (MSVC)
+func findExecutable(config *ForgeConfig, buildDir, buildType string) (string, error) {
+	execName := getBinNameFromConfig(config)
+	if runtime.GOOS == "windows" {
+		execName += ".exe"
+	}
 
-	if err := runTests(*verbose, *filter); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+	execPath := filepath.Join(buildDir, execName)
+	if _, err := os.Stat(execPath); os.IsNotExist(err) {
+		// Try in build type subdirectory (MSVC)
+		execPath = filepath.Join(buildDir, buildType, execName)
+	}
+
+	if _, err := os.Stat(execPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("executable not found: tried %s", execPath)
 	}
+	return execPath, nil
 }
 
-func runTests(verbose bool, filter string) error {
+// watchDebounce is how long watchProject waits for events to settle before rebuilding
+const watchDebounce = 300 * time.Millisecond
+
+// watchProject runs the project and rebuilds/reruns it whenever a source,
+// include, test or subdirectory directory changes, until interrupted
+func watchProject(buildType, target string, execArgs, env []string) error {
 	config, err := loadConfig(DefaultCfgFile)
 	if err != nil {
 		return err
 	}
 
-	projectName := getProjectNameFromConfig(config)
-	fmt.Printf("%s🧪 Running tests for '%s'...%s\n", Cyan, projectName, Reset)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	defer watcher.Close()
 
-	buildDir := "build"
+	dirs := watchDirsFromConfig(config)
+	for _, dir := range dirs {
+		if err := watchRecursive(watcher, dir); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%s👀 Watching %s for changes (Ctrl+C to stop)%s\n", Cyan, strings.Join(dirs, ", "), Reset)
 
-	// Configure CMake if needed
-	if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
-		fmt.Printf("%s⚙️  Configuring CMake...%s\n", Cyan, Reset)
-		cmd := exec.Command("cmake", "-B", buildDir)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("cmake configure failed: %w", err)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	var child *exec.Cmd
+	var exited chan struct{}
+
+	start := func() {
+		execPath, err := buildExecutable(buildType)
+		if err != nil {
+			fmt.Printf("%s❌ %v%s\n", Red, err, Reset)
+			fmt.Printf("%s👀 Waiting for changes...%s\n", Cyan, Reset)
+			return
+		}
+
+		child = exec.Command(execPath, execArgs...)
+		child.Env = append(os.Environ(), env...)
+		child.Stdout = os.Stdout
+		child.Stderr = os.Stderr
+		child.Stdin = os.Stdin
+		if err := child.Start(); err != nil {
+			fmt.Printf("%s❌ Failed to start %s: %v%s\n", Red, execPath, err, Reset)
+			child = nil
+			return
 		}
+
+		done := make(chan struct{})
+		exited = done
+		go func(cmd *exec.Cmd) {
+			if err := cmd.Wait(); err != nil {
+				fmt.Printf("%s⚠️  Process exited: %v%s\n", Yellow, err, Reset)
+			}
+			close(done)
+		}(child)
 	}
 
-	// Build tests
-	fmt.Printf("%s🔧 Building tests...%s\n", Cyan, Reset)
-	buildCmd := exec.Command("cmake", "--build", buildDir)
-	buildCmd.Stdout = os.Stdout
-	buildCmd.Stderr = os.Stderr
-	if err := buildCmd.Run(); err != nil {
-		return fmt.Errorf("build failed: %w", err)
+	stop := func() {
+		if child == nil {
+			return
+		}
+		select {
+		case <-exited:
+		default:
+			child.Process.Kill()
+			<-exited
+		}
+		child = nil
 	}
 
-	// Run tests with ctest
-	fmt.Printf("\n%s🧪 Running tests...%s\n", Green, Reset)
-	fmt.Println(strings.Repeat("─", 50))
+	start()
 
-	ctestArgs := []string{"--test-dir", buildDir, "--output-on-failure"}
-	if verbose {
-		ctestArgs = append(ctestArgs, "-V")
+	var debounce <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				stop()
+				return nil
+			}
+			// Pick up newly created subdirectories
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					watchRecursive(watcher, event.Name)
+				}
+			}
+			debounce = time.After(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if ok {
+				fmt.Printf("%s⚠️  Watcher error: %v%s\n", Yellow, err, Reset)
+			}
+		case <-debounce:
+			debounce = nil
+			fmt.Printf("\n%s🔄 Change detected, rebuilding...%s\n", Cyan, Reset)
+			stop()
+			start()
+		case <-interrupt:
+			fmt.Printf("\n%s👋 Stopping watch%s\n", Cyan, Reset)
+			stop()
+			return nil
+		}
 	}
-	if filter != "" {
-		ctestArgs = append(ctestArgs, "-R", filter)
+}
+
+// watchDirsFromConfig returns the directories forge run --watch watches: the
+// directories of the sources globs, the include dirs, tests/ and the
+// subdirectories. Sources at the project root are not watched, as watching the
+// root would pick up build/.
+func watchDirsFromConfig(config *ForgeConfig) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		dir = filepath.Clean(filepath.FromSlash(dir))
+		if dir == "." || seen[dir] {
+			return
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
 	}
 
-	testCmd := exec.Command("ctest", ctestArgs...)
-	testCmd.Stdout = os.Stdout
-	testCmd.Stderr = os.Stderr
-	return testCmd.Run()
+	for _, pattern := range getSourcesFromConfig(config) {
+		add(globBaseDir(pattern))
+	}
+	for _, dir := range getIncludeDirsFromConfig(config).all() {
+		add(dir)
+	}
+	add("tests")
+	for _, dir := range config.Subdirectories {
+		add(dir)
+	}
+	return dirs
 }
 
-// ============================================================================
-// CLEAN COMMAND
-// ============================================================================
-
-func cmdClean(args []string) {
-	fs := flag.NewFlagSet("clean", flag.ExitOnError)
-	all := fs.Bool("all", false, "Also remove generated files")
-	fs.Parse(args)
+// globBaseDir returns the directory part of a sources glob before its first
+// wildcard, e.g. src/core for src/core/**/*.cpp
+func globBaseDir(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		if strings.ContainsAny(part, "*?[{") {
+			return strings.Join(parts[:i], "/")
+		}
+	}
+	return path.Dir(pattern)
+}
 
-	if err := cleanProject(*all); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+// watchRecursive adds dir and all of its subdirectories to the watcher; missing dirs are skipped
+func watchRecursive(watcher *fsnotify.Watcher, dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
 	}
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
 }
 
-func cleanProject(all bool) error {
-	fmt.Printf("%s🧹 Cleaning build artifacts...%s\n", Cyan, Reset)
+// ============================================================================
+// REMOTE BUILDS - forge build/run --remote over rsync and ssh
+// ============================================================================
 
-	// Remove build directory
-	if err := os.RemoveAll("build"); err != nil {
-		return fmt.Errorf("failed to remove build directory: %w", err)
+// remoteExcludes are kept out of the remote copy; the remote build/ survives syncs
+var remoteExcludes = []string{"/build/", "/.git/"}
+
+// runRemote mirrors the project to the host in remote (user@host[:path]) with
+// rsync, then runs forge <command> args there over ssh, streaming its output.
+// Without a path the project goes to ~/forge/<name> on the host.
+func runRemote(remote, command string, args []string) error {
+	host, dir, _ := strings.Cut(remote, ":")
+	if host == "" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --remote '%s': expected user@host:/path", remote))
+	}
+	// ssh and rsync would take a leading - as one of their own options
+	if strings.HasPrefix(host, "-") {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --remote host '%s': must not start with '-'", host))
+	}
+	for _, tool := range []string{"ssh", "rsync"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return withExitCode(ExitConfig, fmt.Errorf("--remote requires %s in PATH", tool))
+		}
 	}
-	fmt.Println("   ✓ Removed build/")
 
-	// Remove CMake cache
-	cacheFiles := []string{
-		"CMakeCache.txt",
-		"CMakeFiles",
-		"cmake_install.cmake",
-		"Makefile",
-		"compile_commands.json",
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+	// Relative paths are resolved against the remote home directory by ssh and rsync
+	dir = strings.TrimPrefix(dir, "~/")
+	if dir == "" || dir == "~" {
+		dir = "forge/" + getProjectNameFromConfig(config)
 	}
 
-	for _, f := range cacheFiles {
-		if _, err := os.Stat(f); err == nil {
-			os.RemoveAll(f)
-			fmt.Printf("   ✓ Removed %s\n", f)
+	fmt.Printf("%s🌐 Syncing to %s:%s...%s\n", Cyan, host, dir, Reset)
+	mkdirCmd := exec.Command("ssh", host, remoteDirCheck(dir))
+	mkdirCmd.Stdout = os.Stdout
+	mkdirCmd.Stderr = os.Stderr
+	if err := runChild(mkdirCmd); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == remoteDirNotProject {
+			return withExitCode(ExitConfig, fmt.Errorf("refusing to sync into %s:%s: it is not empty and has no forge.yaml or forge.toml, and the sync deletes files that aren't in the project (use an empty directory or omit the path)", host, dir))
+		}
+		return withExitCode(ExitNetwork, fmt.Errorf("failed to reach %s: %w", host, err))
+	}
+
+	rsyncArgs := []string{"-az", "--delete"}
+	for _, exclude := range remoteExcludes {
+		rsyncArgs = append(rsyncArgs, "--exclude", exclude)
+	}
+	rsyncArgs = append(rsyncArgs, "./", host+":"+dir+"/")
+	rsyncCmd := exec.Command("rsync", rsyncArgs...)
+	rsyncCmd.Stdout = os.Stdout
+	rsyncCmd.Stderr = os.Stderr
+	if err := runChild(rsyncCmd); err != nil {
+		return withExitCode(ExitNetwork, fmt.Errorf("failed to sync to %s: %w", host, err))
+	}
+
+	quoted := []string{"forge", command}
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	fmt.Printf("%s🔨 Running '%s' on %s...%s\n", Cyan, strings.Join(quoted, " "), host, Reset)
+
+	// A terminal gets a remote tty, so colors work and Ctrl+C reaches the remote forge
+	sshArgs := []string{host}
+	if stdinIsTerminal() {
+		sshArgs = []string{"-t", host}
+	}
+	sshArgs = append(sshArgs, "cd "+shellQuote(dir)+" && "+strings.Join(quoted, " "))
+	sshCmd := exec.Command("ssh", sshArgs...)
+	sshCmd.Stdin = os.Stdin
+	sshCmd.Stdout = os.Stdout
+	sshCmd.Stderr = os.Stderr
+	if err := runChild(sshCmd); err != nil {
+		// ssh exits 255 for its own errors and with the remote command's code otherwise
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() != 255 {
+			return withExitCode(exitErr.ExitCode(), fmt.Errorf("forge %s failed on %s", command, host))
 		}
+		return withExitCode(ExitNetwork, fmt.Errorf("ssh to %s failed: %w", host, err))
 	}
+	return nil
+}
 
-	if all {
-		// Remove generated files
-		genFiles := []string{LockFile}
-		for _, f := range genFiles {
-			if _, err := os.Stat(f); err == nil {
-				os.Remove(f)
-				fmt.Printf("   ✓ Removed %s\n", f)
-			}
+// remoteDirNotProject is the exit status of remoteDirCheck for a directory
+// that rsync --delete must not sync into
+const remoteDirNotProject = 3
+
+// remoteDirCheck is the remote shell command that creates dir and exits with
+// remoteDirNotProject unless it is empty or already holds a forge project
+func remoteDirCheck(dir string) string {
+	return fmt.Sprintf(`mkdir -p %[1]s && cd %[1]s || exit 1; [ -e forge.yaml ] || [ -e forge.toml ] || [ -z "$(ls -A)" ] || exit %[2]d`, shellQuote(dir), remoteDirNotProject)
+}
+
+// withoutFlag removes -name/--name and its value from args, leaving anything
+// after "--" untouched
+func withoutFlag(args []string, name string) []string {
+	var result []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		trimmed := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		switch {
+		case arg != trimmed && trimmed == name:
+			i++ // skip the value
+		case arg != trimmed && strings.HasPrefix(trimmed, name+"="):
+		default:
+			result = append(result, arg)
 		}
 	}
+	return result
+}
 
-	fmt.Printf("%s✅ Clean complete!%s\n", Green, Reset)
-	return nil
+// shellSafeRegex matches words a POSIX shell takes literally
+var shellSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	if shellSafeRegex.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
 }
 
 // ============================================================================
-// NEW COMMAND
+// SIZE COMMAND
 // ============================================================================
 
-func cmdNew(args []string) {
-	fs := flag.NewFlagSet("new", flag.ExitOnError)
-	serverURL := fs.String("server", DefaultServer, "Server URL")
-	templateName := fs.String("template", "", "Use a template")
-	isLib := fs.Bool("lib", false, "Create a library project")
-	fs.StringVar(serverURL, "s", DefaultServer, "Server URL (shorthand)")
-	fs.StringVar(templateName, "t", "", "Use a template (shorthand)")
+func cmdSize(args []string) {
+	fs := flag.NewFlagSet("size", flag.ExitOnError)
+	release := fs.Bool("release", false, "Look for the release build (multi-config generators)")
+	sections := fs.Bool("sections", false, "Show a per-section breakdown (bloaty or size)")
+	compare := fs.String("compare", "", "Compare against a previous binary")
 	fs.Parse(args)
 
-	remaining := fs.Args()
+	if err := showSize(*release, *sections, *compare); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
 
-	// Default to current directory if no name given
-	projectName := "."
-	for _, arg := range remaining {
-		switch arg {
-		case "lib", "library":
-			*isLib = true
-		case "exe", "bin":
-			*isLib = false
-		default:
-			projectName = arg
-		}
+func showSize(release, sections bool, compare string) error {
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return err
 	}
 
-	if err := newProject(*serverURL, projectName, *templateName, *isLib); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+	buildType, _ := determineBuildType(release, "")
+	execPath, err := findExecutable(config, "build", buildType)
+	if err != nil {
+		return fmt.Errorf("%w (run forge build first)", err)
 	}
-}
 
-func newProject(serverURL, projectName, templateName string, isLib bool) error {
-	var targetDir string
-	var actualProjectName string
+	info, err := os.Stat(execPath)
+	if err != nil {
+		return err
+	}
 
-	// If no name given, use current folder name and create in current directory
-	if projectName == "." || projectName == "" {
-		cwd, err := os.Getwd()
+	fmt.Printf("%s📏 %s%s\n", Bold, execPath, Reset)
+	fmt.Printf("   Size: %s\n", formatSize(info.Size()))
+
+	if compare != "" {
+		oldInfo, err := os.Stat(compare)
 		if err != nil {
-			return fmt.Errorf("failed to get current directory: %w", err)
+			return fmt.Errorf("failed to read %s: %w", compare, err)
 		}
-		actualProjectName = filepath.Base(cwd)
-		targetDir = "."
-	} else {
-		// Validate project name
-		if !regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`).MatchString(projectName) {
-			return fmt.Errorf("invalid project name '%s': must start with letter and contain only letters, numbers, underscores, or hyphens", projectName)
+		delta := info.Size() - oldInfo.Size()
+		color := Green
+		sign := ""
+		if delta > 0 {
+			color = Red
+			sign = "+"
+		} else if delta < 0 {
+			sign = "-"
 		}
-		actualProjectName = projectName
-		targetDir = projectName
-
-		// Check if directory already exists
-		if _, err := os.Stat(targetDir); err == nil {
-			return fmt.Errorf("directory '%s' already exists", targetDir)
+		percent := 0.0
+		if oldInfo.Size() > 0 {
+			percent = float64(delta) / float64(oldInfo.Size()) * 100
 		}
+		fmt.Printf("   Previous: %s (%s)\n", formatSize(oldInfo.Size()), compare)
+		fmt.Printf("   Change: %s%s%s (%+.1f%%)%s\n", color, sign, formatSize(abs64(delta)), percent, Reset)
+	}
+
+	if !sections {
+		return nil
+	}
 
-		// Create the new directory
-		if err := os.MkdirAll(targetDir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory '%s': %w", targetDir, err)
+	fmt.Println()
+	var cmd *exec.Cmd
+	if _, err := exec.LookPath("bloaty"); err == nil {
+		bloatyArgs := []string{execPath}
+		if compare != "" {
+			bloatyArgs = append(bloatyArgs, "--", compare)
 		}
+		cmd = exec.Command("bloaty", bloatyArgs...)
+	} else if _, err := exec.LookPath("size"); err == nil {
+		sizeArgs := []string{"-A", execPath}
+		if runtime.GOOS == "darwin" {
+			sizeArgs = []string{"-m", execPath}
+		}
+		if compare != "" {
+			fmt.Printf("%s⚠️  Install bloaty for a per-section diff; showing the current binary only%s\n", Yellow, Reset)
+		}
+		cmd = exec.Command("size", sizeArgs...)
+	} else {
+		return fmt.Errorf("--sections requires bloaty or size in PATH")
 	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
 
-	// Check if forge.yaml already exists in target directory
-	configPath := filepath.Join(targetDir, DefaultCfgFile)
-	if _, err := os.Stat(configPath); err == nil {
-		return fmt.Errorf("forge.yaml already exists in %s", targetDir)
+// formatSize renders a byte count as B, KiB or MiB
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.2f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
 	}
+}
 
-	fmt.Printf("%s📁 Creating project '%s'...%s\n", Cyan, actualProjectName, Reset)
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
 
-	// Create forge.yaml
-	var configContent string
-	if isLib {
-		configContent = fmt.Sprintf(`# forge.yaml - C++ Library Project
-package:
-  name: %s
-  version: "0.1.0"
-  cpp_standard: 17
+// ============================================================================
+// TEST COMMAND
+// ============================================================================
 
-build:
+func cmdTest(args []string) {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	verbose := fs.Bool("verbose", false, "Show verbose output")
+	filter := fs.String("filter", "", "Filter tests by name")
+	label := fs.String("label", "", "Run only tests with a matching CTest label")
+	list := fs.Bool("list", false, "List discovered tests without running them")
+	shuffle := fs.Bool("shuffle", false, "Run tests in random order (runs the test binary directly)")
+	seed := fs.Int64("seed", 0, "Seed for --shuffle, to reproduce an order (implies --shuffle)")
+	fs.BoolVar(verbose, "v", false, "Show verbose output (shorthand)")
+	fs.StringVar(label, "L", "", "Filter tests by label (shorthand)")
+	toCTest := fs.Bool("ctest", false, "Pass the arguments after -- to ctest instead of the test binary")
+	noBuild := fs.Bool("no-build", false, "Run the existing test binaries without configuring or building")
+	fs.Parse(args)
+
+	// Everything after -- goes to the test binary (or ctest with --ctest)
+	if err := runTests(*verbose, *filter, *label, *list, *shuffle || *seed != 0, *seed, fs.Args(), *toCTest, *noBuild); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// runTests builds and runs the tests. extraArgs are appended to the ctest command
+// line with toCTest, and otherwise passed to the test binary, which is then run
+// directly since ctest has no way to forward them. noBuild skips straight to
+// running whatever is already in build/.
+func runTests(verbose bool, filter, label string, list, shuffle bool, seed int64, extraArgs []string, toCTest, noBuild bool) error {
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+
+	// Shuffling is done by the framework inside one process, so it bypasses ctest
+	direct := shuffle || (len(extraArgs) > 0 && !toCTest)
+	var directArgs []string
+	switch {
+	case shuffle && toCTest:
+		return fmt.Errorf("--shuffle can't be combined with --ctest")
+	case shuffle:
+		if label != "" || list {
+			return fmt.Errorf("--shuffle can't be combined with --label or --list")
+		}
+		if seed == 0 {
+			seed = rand.Int63n(99999) + 1
+		}
+		if directArgs, err = shuffleArgs(config.Testing.Framework, filter, seed); err != nil {
+			return err
+		}
+	case direct:
+		if label != "" || list {
+			return fmt.Errorf("arguments after -- go to the test binary and can't be combined with --label or --list (use --ctest to pass them to ctest)")
+		}
+		if filter != "" {
+			var ok bool
+			if directArgs, ok = filterArgs(config.Testing.Framework, filter); !ok {
+				return fmt.Errorf("--filter with arguments after -- requires testing.framework googletest, catch2 or doctest (got '%s')", config.Testing.Framework)
+			}
+		}
+	}
+	directArgs = append(directArgs, extraArgs...)
+
+	projectName := getProjectNameFromConfig(config)
+	fmt.Printf("%s🧪 Running tests for '%s'...%s\n", Cyan, projectName, Reset)
+
+	buildDir := "build"
+
+	if noBuild {
+		// ctest needs the configured tree and the binary it registered
+		_, cacheErr := os.Stat(filepath.Join(buildDir, "CMakeCache.txt"))
+		if _, err := findTestExecutable(projectName, buildDir); cacheErr != nil || err != nil {
+			return fmt.Errorf("no built tests in %s/ (run 'forge test' or 'forge build' first)", buildDir)
+		}
+	} else if err := buildTests(config, projectName, buildDir); err != nil {
+		return err
+	}
+
+	if direct {
+		testExe, err := findTestExecutable(projectName, buildDir)
+		if err != nil {
+			return err
+		}
+		if shuffle {
+			fmt.Printf("\n%s🔀 Running tests in random order (seed %d)...%s\n", Green, seed, Reset)
+			fmt.Printf("   Reproduce with: forge test --seed %d\n", seed)
+		} else {
+			fmt.Printf("\n%s🧪 Running %s %s...%s\n", Green, filepath.Base(testExe), strings.Join(directArgs, " "), Reset)
+		}
+		fmt.Println(strings.Repeat("─", 50))
+
+		testCmd := exec.Command(testExe, directArgs...)
+		testCmd.Stdout = os.Stdout
+		testCmd.Stderr = os.Stderr
+		if err := runChild(testCmd); err != nil {
+			if shuffle {
+				return withExitCode(ExitBuild, fmt.Errorf("tests failed with seed %d: %w", seed, err))
+			}
+			return withExitCode(ExitBuild, fmt.Errorf("tests failed: %w", err))
+		}
+		return nil
+	}
+
+	// Run tests with ctest (-N lists them without running)
+	ctestArgs := []string{"--test-dir", buildDir}
+	if list {
+		fmt.Printf("\n%s📋 Discovered tests:%s\n", Green, Reset)
+		ctestArgs = append(ctestArgs, "-N")
+	} else {
+		fmt.Printf("\n%s🧪 Running tests...%s\n", Green, Reset)
+		ctestArgs = append(ctestArgs, "--output-on-failure")
+	}
+	fmt.Println(strings.Repeat("─", 50))
+
+	if verbose {
+		ctestArgs = append(ctestArgs, "-V")
+	}
+	if filter != "" {
+		ctestArgs = append(ctestArgs, "-R", filter)
+	}
+	if label != "" {
+		ctestArgs = append(ctestArgs, "-L", label)
+	}
+	if toCTest {
+		ctestArgs = append(ctestArgs, extraArgs...)
+	}
+
+	testCmd := exec.Command("ctest", ctestArgs...)
+	testCmd.Stdout = os.Stdout
+	testCmd.Stderr = os.Stderr
+	if err := runChild(testCmd); err != nil {
+		return withExitCode(ExitBuild, fmt.Errorf("tests failed: %w", err))
+	}
+	return nil
+}
+
+// buildTests configures the build tree if needed and builds the test target
+func buildTests(config *ForgeConfig, projectName, buildDir string) error {
+	// Configure CMake if needed
+	if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
+		fmt.Printf("%s⚙️  Configuring CMake...%s\n", Cyan, Reset)
+		if err := runCMakeConfigure("-B", buildDir); err != nil {
+			return err
+		}
+	}
+
+	// Build tests
+	fmt.Printf("%s🔧 Building tests...%s\n", Cyan, Reset)
+	buildCmd := exec.Command("cmake", "--build", buildDir)
+	buildCmd.Stdout = os.Stdout
+	buildCmd.Stderr = os.Stderr
+	if err := runChild(buildCmd); err != nil {
+		return withExitCode(ExitBuild, fmt.Errorf("build failed: %w", err))
+	}
+
+	// Tests excluded from ALL (testing.build_by_default: false) need an explicit target
+	if !getTestsBuildByDefaultFromConfig(config) {
+		testsBuildCmd := exec.Command("cmake", "--build", buildDir, "--target", projectName+"_tests")
+		testsBuildCmd.Stdout = os.Stdout
+		testsBuildCmd.Stderr = os.Stderr
+		if err := runChild(testsBuildCmd); err != nil {
+			return withExitCode(ExitBuild, fmt.Errorf("build failed: %w", err))
+		}
+	}
+	return nil
+}
+
+// shuffleArgs returns the test binary flags that randomize test order with seed
+// (and apply filter in the framework's own syntax)
+func shuffleArgs(framework, filter string, seed int64) ([]string, error) {
+	var args []string
+	switch framework {
+	case "googletest":
+		args = []string{"--gtest_shuffle", fmt.Sprintf("--gtest_random_seed=%d", seed)}
+	case "catch2":
+		args = []string{"--order", "rand", "--rng-seed", fmt.Sprintf("%d", seed)}
+	case "doctest":
+		args = []string{"--order-by=rand", fmt.Sprintf("--rand-seed=%d", seed)}
+	default:
+		return nil, fmt.Errorf("--shuffle requires testing.framework googletest, catch2 or doctest (got '%s')", framework)
+	}
+	if filter != "" {
+		filterFlags, _ := filterArgs(framework, filter)
+		args = append(args, filterFlags...)
+	}
+	return args, nil
+}
+
+// filterArgs returns the test binary flags that select tests matching filter,
+// or false for frameworks without known flags
+func filterArgs(framework, filter string) ([]string, bool) {
+	switch framework {
+	case "googletest":
+		return []string{"--gtest_filter=" + filter}, true
+	case "catch2":
+		return []string{filter}, true
+	case "doctest":
+		return []string{"--test-case=" + filter}, true
+	}
+	return nil, false
+}
+
+// findTestExecutable locates <project>_tests in the build tree
+func findTestExecutable(projectName, buildDir string) (string, error) {
+	execName := projectName + "_tests"
+	if runtime.GOOS == "windows" {
+		execName += ".exe"
+	}
+
+	execPath := filepath.Join(buildDir, "tests", execName)
+	if _, err := os.Stat(execPath); os.IsNotExist(err) {
+		// Try in build type subdirectory (MSVC)
+		execPath = filepath.Join(buildDir, "tests", "Debug", execName)
+	}
+
+	if _, err := os.Stat(execPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("test executable not found: tried %s", execPath)
+	}
+	return execPath, nil
+}
+
+// ============================================================================
+// FUZZ COMMAND
+// ============================================================================
+
+func cmdFuzz(args []string) {
+	fs := flag.NewFlagSet("fuzz", flag.ExitOnError)
+	corpus := fs.String("corpus", "fuzz/corpus", "Corpus directory")
+	maxTime := fs.Int("max-time", 0, "Stop after N seconds (0 = run until a crash)")
+	fs.IntVar(maxTime, "t", 0, "Stop after N seconds (shorthand)")
+	fs.Parse(args)
+
+	if err := runFuzz(*corpus, *maxTime, fs.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func runFuzz(corpus string, maxTime int, fuzzArgs []string) error {
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+
+	projectName := getProjectNameFromConfig(config)
+
+	if !config.Testing.Fuzz {
+		return fmt.Errorf("fuzzing is not enabled: set testing.fuzz: true in forge.yaml and run 'forge generate'")
+	}
+	if _, err := os.Stat(filepath.Join("fuzz", "CMakeLists.txt")); os.IsNotExist(err) {
+		return fmt.Errorf("fuzz/CMakeLists.txt not found: run 'forge generate' first")
+	}
+
+	// libFuzzer is only available with clang
+	compiler := config.Build.Compiler
+	if !strings.Contains(compiler, "clang") {
+		compiler = "clang++"
+	}
+	if _, err := exec.LookPath(compiler); err != nil {
+		return fmt.Errorf("fuzzing requires clang (libFuzzer): %s not found in PATH", compiler)
+	}
+	cCompiler := strings.Replace(compiler, "clang++", "clang", 1)
+
+	// Separate build directory so sanitizer flags don't leak into regular builds
+	buildDir := "build-fuzz"
+	if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
+		fmt.Printf("%s⚙️  Configuring fuzz build...%s\n", Cyan, Reset)
+		if err := runCMakeConfigure("-B", buildDir,
+			"-DCMAKE_BUILD_TYPE=Debug",
+			"-DFORGE_ENABLE_FUZZING=ON",
+			"-DCMAKE_CXX_COMPILER="+compiler,
+			"-DCMAKE_C_COMPILER="+cCompiler,
+		); err != nil {
+			return err
+		}
+	}
+
+	target := projectName + "_fuzz"
+	fmt.Printf("%s🔧 Building %s...%s\n", Cyan, target, Reset)
+	buildCmd := exec.Command("cmake", "--build", buildDir, "--target", target, "--parallel", fmt.Sprintf("%d", runtime.NumCPU()))
+	buildCmd.Stdout = os.Stdout
+	buildCmd.Stderr = os.Stderr
+	if err := runChild(buildCmd); err != nil {
+		return withExitCode(ExitBuild, fmt.Errorf("build failed: %w", err))
+	}
+
+	if err := os.MkdirAll(corpus, 0755); err != nil {
+		return fmt.Errorf("failed to create corpus directory: %w", err)
+	}
+
+	runArgs := []string{corpus}
+	if maxTime > 0 {
+		runArgs = append(runArgs, fmt.Sprintf("-max_total_time=%d", maxTime))
+	}
+	runArgs = append(runArgs, fuzzArgs...)
+
+	fmt.Printf("\n%s🐛 Fuzzing '%s' (corpus: %s)...%s\n", Green, projectName, corpus, Reset)
+	fmt.Println(strings.Repeat("─", 50))
+
+	runCmd := exec.Command(filepath.Join(buildDir, "fuzz", target), runArgs...)
+	runCmd.Stdout = os.Stdout
+	runCmd.Stderr = os.Stderr
+	return runChild(runCmd)
+}
+
+// ============================================================================
+// CLEAN COMMAND
+// ============================================================================
+
+func cmdClean(args []string) {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+	all := fs.Bool("all", false, "Also remove generated files")
+	fs.Parse(args)
+
+	if err := cleanProject(*all); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func cleanProject(all bool) error {
+	fmt.Printf("%s🧹 Cleaning build artifacts...%s\n", Cyan, Reset)
+
+	// Remove build directory
+	if err := os.RemoveAll("build"); err != nil {
+		return fmt.Errorf("failed to remove build directory: %w", err)
+	}
+	fmt.Println("   ✓ Removed build/")
+
+	// Remove CMake cache
+	cacheFiles := []string{
+		"CMakeCache.txt",
+		"CMakeFiles",
+		"cmake_install.cmake",
+		"Makefile",
+		"compile_commands.json",
+	}
+
+	for _, f := range cacheFiles {
+		if _, err := os.Stat(f); err == nil {
+			os.RemoveAll(f)
+			fmt.Printf("   ✓ Removed %s\n", f)
+		}
+	}
+
+	if all {
+		// Remove generated files
+		genFiles := []string{LockFile}
+		for _, f := range genFiles {
+			if _, err := os.Stat(f); err == nil {
+				os.Remove(f)
+				fmt.Printf("   ✓ Removed %s\n", f)
+			}
+		}
+	}
+
+	fmt.Printf("%s✅ Clean complete!%s\n", Green, Reset)
+	return nil
+}
+
+// ============================================================================
+// NEW COMMAND
+// ============================================================================
+
+func cmdNew(args []string) {
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	templateName := fs.String("template", "", "Use a template")
+	isLib := fs.Bool("lib", false, "Create a library project")
+	templateURL := fs.String("template-url", "", "Scaffold from a Git repository template")
+	branch := fs.String("branch", "", "Branch or tag of the template repository")
+	fuzz := fs.Bool("fuzz", false, "Generate a libFuzzer harness in fuzz/")
+	testPerSource := fs.Bool("test-per-source", false, "Generate a stub test file for each source in src/ (testing.per_source)")
+	ide := fs.String("ide", "", "Write editor configuration ("+strings.Join(ideNames(), ", ")+")")
+	minimal := fs.Bool("minimal", false, "Create a bare project without dependencies or tests")
+	fs.BoolVar(minimal, "bare", false, "Alias for --minimal")
+	license := fs.String("license", "", "Write a LICENSE file ("+strings.Join(licenseIDs(), ", ")+")")
+	interactive := fs.Bool("interactive", false, "Prompt for name, type, C++ standard, testing framework and dependencies")
+	fs.BoolVar(interactive, "i", false, "Interactive setup (shorthand)")
+	merge := fs.Bool("merge", false, "Add forge to an existing directory, only writing files that don't exist yet")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.StringVar(templateName, "t", "", "Use a template (shorthand)")
+	fs.Parse(args)
+
+	// Allow flags after the project name (forge new myapp --template-url ...)
+	var remaining []string
+	for fs.NArg() > 0 {
+		remaining = append(remaining, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+
+	// Default to current directory if no name given
+	projectName := "."
+	for _, arg := range remaining {
+		switch arg {
+		case "lib", "library":
+			*isLib = true
+		case "exe", "bin":
+			*isLib = false
+		default:
+			projectName = arg
+		}
+	}
+
+	*serverURL = resolveServerURL(*serverURL)
+
+	if *minimal && (*templateName != "" || *templateURL != "") {
+		fmt.Fprintf(os.Stderr, "%sError:%s --minimal cannot be combined with a template\n", Red, Reset)
+		os.Exit(ExitUsage)
+	}
+
+	if err := validateLicense(*license); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(ExitUsage)
+	}
+
+	if *interactive && (*minimal || *templateName != "" || *templateURL != "") {
+		fmt.Fprintf(os.Stderr, "%sError:%s --interactive cannot be combined with --minimal or a template\n", Red, Reset)
+		os.Exit(ExitUsage)
+	}
+
+	if *merge && *templateURL != "" {
+		fmt.Fprintf(os.Stderr, "%sError:%s --merge cannot be combined with --template-url\n", Red, Reset)
+		os.Exit(ExitUsage)
+	}
+
+	// The wizard needs a terminal; piped or redirected stdin keeps the flag-driven behavior
+	var wizardConfig string
+	if *interactive {
+		if stdinIsTerminal() {
+			name, content, err := runNewWizard(*serverURL, projectName, *isLib)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+				os.Exit(exitCode(err))
+			}
+			projectName, wizardConfig = name, content
+		} else {
+			fmt.Printf("%s⚠️  stdin is not a terminal, ignoring --interactive%s\n", Yellow, Reset)
+		}
+	}
+
+	if *ide != "" {
+		if _, ok := ideGenerators[*ide]; !ok {
+			fmt.Fprintf(os.Stderr, "%sError:%s unknown IDE '%s' (available: %s)\n", Red, Reset, *ide, strings.Join(ideNames(), ", "))
+			os.Exit(ExitUsage)
+		}
+	}
+
+	if *templateURL != "" {
+		if err := newProjectFromGit(projectName, *templateURL, *branch); err != nil {
+			fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	if err := newProject(*serverURL, projectName, *templateName, *isLib, *fuzz, *testPerSource, *minimal, *ide, *license, wizardConfig, *merge); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// newProjectFromGit clones a template repository into a new project directory,
+// substitutes {{project_name}} in text files and drops the template's git history
+func newProjectFromGit(projectName, templateURL, branch string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git is required for --template-url but was not found in PATH")
+	}
+
+	if projectName == "." || projectName == "" {
+		return fmt.Errorf("a project name is required with --template-url")
+	}
+	if !regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`).MatchString(projectName) {
+		return fmt.Errorf("invalid project name '%s': must start with letter and contain only letters, numbers, underscores, or hyphens", projectName)
+	}
+	targetDir := projectName
+	if _, err := os.Stat(targetDir); err == nil {
+		return fmt.Errorf("directory '%s' already exists", targetDir)
+	}
+
+	fmt.Printf("%s📥 Cloning template %s...%s\n", Cyan, templateURL, Reset)
+	cloneArgs := []string{"clone", "--depth", "1"}
+	if branch != "" {
+		cloneArgs = append(cloneArgs, "--branch", branch)
+	}
+	cloneArgs = append(cloneArgs, templateURL, targetDir)
+	cmd := exec.Command("git", cloneArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(targetDir)
+		return fmt.Errorf("failed to clone template: %w", err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(targetDir, ".git")); err != nil {
+		return fmt.Errorf("failed to remove template git history: %w", err)
+	}
+
+	if _, err := os.Stat(resolveConfigPath(filepath.Join(targetDir, DefaultCfgFile))); os.IsNotExist(err) {
+		os.RemoveAll(targetDir)
+		return fmt.Errorf("template does not contain a %s or %s", DefaultCfgFile, TomlCfgFile)
+	}
+
+	fmt.Printf("%s📁 Creating project '%s'...%s\n", Cyan, projectName, Reset)
+	if err := substituteTemplateVars(targetDir, projectName); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s🔧 Initializing git repository...%s\n", Cyan, Reset)
+	initCmd := exec.Command("git", "init")
+	initCmd.Dir = targetDir
+	if err := initCmd.Run(); err != nil {
+		fmt.Printf("%s⚠️  Warning: Failed to initialize git repository: %v%s\n", Yellow, err, Reset)
+	} else {
+		fmt.Printf("%s✅ Initialized git repository%s\n", Green, Reset)
+	}
+
+	fmt.Printf("\n%s✅ Project '%s' ready!%s\n\n", Green, projectName, Reset)
+	fmt.Printf("Next steps:\n")
+	fmt.Printf("  cd %s\n", targetDir)
+	fmt.Printf("  %sforge build%s       # Compile the project\n", Cyan, Reset)
+	fmt.Printf("  %sforge run%s         # Build and run\n", Cyan, Reset)
+	return nil
+}
+
+// substituteTemplateVars replaces {{project_name}} in every text file under dir.
+// Files containing NUL bytes are treated as binary and left untouched.
+func substituteTemplateVars(dir, projectName string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !info.Mode().IsRegular() {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(data, 0) != -1 || !bytes.Contains(data, []byte("{{project_name}}")) {
+			return nil
+		}
+
+		replaced := bytes.ReplaceAll(data, []byte("{{project_name}}"), []byte(projectName))
+		if err := os.WriteFile(path, replaced, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// newProject writes forge.yaml and generates the project. A non-empty
+// wizardConfig (from forge new --interactive) is used as forge.yaml as-is.
+// With merge the target directory may already exist and only files missing
+// from it are written.
+func newProject(serverURL, projectName, templateName string, isLib, fuzz, testPerSource, minimal bool, ide, license, wizardConfig string, merge bool) error {
+	var targetDir string
+	var actualProjectName string
+	createdDir := false
+
+	// If no name given, use current folder name and create in current directory
+	if projectName == "." || projectName == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		actualProjectName = filepath.Base(cwd)
+		targetDir = "."
+	} else {
+		// Validate project name
+		if !regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`).MatchString(projectName) {
+			return fmt.Errorf("invalid project name '%s': must start with letter and contain only letters, numbers, underscores, or hyphens", projectName)
+		}
+		actualProjectName = projectName
+		targetDir = projectName
+
+		// Check if directory already exists
+		if _, err := os.Stat(targetDir); err == nil {
+			if !merge {
+				return fmt.Errorf("directory '%s' already exists (use --merge to add forge to it)", targetDir)
+			}
+		} else {
+			// Create the new directory
+			if err := os.MkdirAll(targetDir, 0755); err != nil {
+				return fmt.Errorf("failed to create directory '%s': %w", targetDir, err)
+			}
+			createdDir = true
+		}
+	}
+
+	// Check if forge.yaml already exists in target directory; merging keeps it
+	configPath := filepath.Join(targetDir, DefaultCfgFile)
+	keepConfig := false
+	if _, err := os.Stat(configPath); err == nil {
+		if !merge {
+			return fmt.Errorf("forge.yaml already exists in %s (use --merge to keep it and add missing files)", targetDir)
+		}
+		keepConfig = true
+	}
+
+	if merge {
+		fmt.Printf("%s📁 Adding forge to '%s'...%s\n", Cyan, actualProjectName, Reset)
+	} else {
+		fmt.Printf("%s📁 Creating project '%s'...%s\n", Cyan, actualProjectName, Reset)
+	}
+
+	// Create forge.yaml
+	var configContent string
+	if wizardConfig != "" {
+		configContent = wizardConfig
+	} else if minimal {
+		configContent = fmt.Sprintf(`# forge.yaml - Minimal C++ Project
+package:
+  name: %s
+  version: "0.1.0"
+  cpp_standard: 17
+
+build:
+  shared_libs: %t
+
+testing:
+  framework: none
+
+dependencies: {}
+`, actualProjectName, isLib)
+	} else if isLib {
+		configContent = fmt.Sprintf(`# forge.yaml - C++ Library Project
+package:
+  name: %s
+  version: "0.1.0"
+  cpp_standard: 17
+
+build:
+  shared_libs: false
+  clang_format: Google
+
+testing:
+  framework: googletest
+
+dependencies:
+  fmt: {}
+`, actualProjectName)
+	} else if templateName != "" {
+		// Fetch template from server
+		url := fmt.Sprintf("%s/api/forge/example/%s", serverURL, templateName)
+		resp, err := doServerRequest(func() (*http.Request, error) {
+			return http.NewRequest("GET", url, nil)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch template: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if err := checkServerResponse(resp, "a forge.yaml template"); err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("template '%s' not found", templateName)
+		}
+
+		data, _ := io.ReadAll(resp.Body)
+		// Replace project name in template
+		configContent = strings.ReplaceAll(string(data), "my_project", actualProjectName)
+		configContent = strings.ReplaceAll(configContent, "hello_world", actualProjectName)
+	} else {
+		configContent = fmt.Sprintf(`# forge.yaml - C++ Project Dependencies
+package:
+  name: %s
+  version: "0.1.0"
+  cpp_standard: 17
+
+build:
   shared_libs: false
   clang_format: Google
 
-testing:
-  framework: googletest
+testing:
+  framework: googletest
+
+dependencies:
+  spdlog:
+    spdlog_header_only: true
+  fmt: {}
+`, actualProjectName)
+	}
+
+	if fuzz {
+		configContent = strings.Replace(configContent, "testing:\n", "testing:\n  fuzz: true\n", 1)
+	}
+	if testPerSource {
+		configContent = strings.Replace(configContent, "testing:\n", "testing:\n  per_source: true\n", 1)
+	}
+	if license != "" {
+		// Right after package.name, or first in package when there is none
+		packageRegex := regexp.MustCompile(`(?m)^package:\n(  name: .*\n)?`)
+		configContent = packageRegex.ReplaceAllString(configContent, "${0}  license: "+license+"\n")
+	}
+
+	if keepConfig {
+		fmt.Printf("   Keeping existing %s\n", DefaultCfgFile)
+	} else if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	// Initialize git repository if a new directory was created
+	if createdDir {
+		fmt.Printf("%s🔧 Initializing git repository...%s\n", Cyan, Reset)
+		cmd := exec.Command("git", "init")
+		cmd.Dir = targetDir
+		if err := cmd.Run(); err != nil {
+			// Git init failure is not critical, just warn
+			fmt.Printf("%s⚠️  Warning: Failed to initialize git repository: %v%s\n", Yellow, err, Reset)
+		} else {
+			fmt.Printf("%s✅ Initialized git repository%s\n", Green, Reset)
+		}
+	}
+
+	if !merge {
+		fmt.Printf("%s✅ Created project '%s'%s\n", Green, actualProjectName, Reset)
+	}
+	if targetDir != "." {
+		fmt.Printf("   Directory: %s\n", targetDir)
+	}
+
+	if ide != "" {
+		if err := generateIDEConfig(ide, targetDir, false); err != nil {
+			fmt.Printf("%s⚠️  Warning: Could not write %s configuration: %v%s\n", Yellow, ide, err, Reset)
+		}
+	}
+
+	// Generate project files immediately after creating forge.yaml
+	fmt.Printf("\n%s📦 Generating project files...%s\n", Cyan, Reset)
+	if err := generateProject(serverURL, configPath, targetDir, "", false, merge); err != nil {
+		// Don't fail completely, just warn
+		fmt.Printf("%s⚠️  Warning: Could not generate project files: %v%s\n", Yellow, err, Reset)
+		fmt.Printf("   You can try running manually: %sforge build%s\n", Cyan, Reset)
+	} else {
+		fmt.Printf("\n%s✅ Project '%s' ready!%s\n\n", Green, actualProjectName, Reset)
+		fmt.Printf("Next steps:\n")
+		if targetDir != "." {
+			fmt.Printf("  cd %s\n", targetDir)
+		}
+		fmt.Printf("  %sforge build%s       # Compile the project\n", Cyan, Reset)
+		fmt.Printf("  %sforge run%s         # Build and run\n", Cyan, Reset)
+	}
+
+	return nil
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// suggestedDependencies are offered first by forge new --interactive
+var suggestedDependencies = []string{"fmt", "spdlog", "nlohmann_json", "cli11", "cxxopts", "magic_enum", "range_v3", "asio"}
+
+// runNewWizard prompts for the project settings and returns the project name
+// to create (projectName when the default is kept) and the forge.yaml content
+func runNewWizard(serverURL, projectName string, isLib bool) (string, string, error) {
+	reader := bufio.NewReader(os.Stdin)
+	ask := func(question, def string) (string, error) {
+		fmt.Printf("%s?%s %s [%s]: ", Cyan, Reset, question, def)
+		answer, err := reader.ReadString('\n')
+		if answer = strings.TrimSpace(answer); answer != "" {
+			return answer, nil
+		}
+		if err != nil {
+			fmt.Println()
+			return "", withExitCode(ExitUsage, fmt.Errorf("setup aborted, no project was created"))
+		}
+		return def, nil
+	}
+
+	fmt.Printf("%s%s🧙 New project setup%s (press Enter to accept the default)\n\n", Bold, Cyan, Reset)
+
+	defaultName := projectName
+	if projectName == "." || projectName == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		defaultName = filepath.Base(cwd)
+	}
+	var name string
+	for {
+		var err error
+		if name, err = ask("Project name", defaultName); err != nil {
+			return "", "", err
+		}
+		if regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`).MatchString(name) {
+			break
+		}
+		fmt.Printf("  %sMust start with a letter and contain only letters, numbers, underscores, or hyphens%s\n", Yellow, Reset)
+	}
+	// Keeping the folder name keeps creating the project in the current directory
+	if projectName != "." || name != defaultName {
+		projectName = name
+	}
+
+	defaultType := "exe"
+	if isLib {
+		defaultType = "lib"
+	}
+	var projectType string
+	for projectType != "exe" && projectType != "lib" {
+		var err error
+		if projectType, err = ask("Project type (exe, lib)", defaultType); err != nil {
+			return "", "", err
+		}
+	}
+
+	standards := make([]string, len(knownCppStandards))
+	for i, std := range knownCppStandards {
+		standards[i] = strconv.Itoa(std)
+	}
+	var cppStandard int
+	for {
+		answer, err := ask("C++ standard ("+strings.Join(standards, ", ")+")", "17")
+		if err != nil {
+			return "", "", err
+		}
+		std, err := strconv.Atoi(answer)
+		if err == nil && validateCppStandard(std) == nil {
+			cppStandard = std
+			break
+		}
+		fmt.Printf("  %sUnsupported C++ standard '%s'%s\n", Yellow, answer, Reset)
+	}
+
+	frameworks := []string{"googletest", "catch2", "doctest", "none"}
+	var framework string
+	for !containsString(frameworks, framework) {
+		var err error
+		if framework, err = ask("Testing framework ("+strings.Join(frameworks, ", ")+")", "googletest"); err != nil {
+			return "", "", err
+		}
+	}
+
+	// Dependencies are checked against the registry when the server is reachable
+	known := make(map[string]bool)
+	if libs, err := getAllLibraries(serverURL); err != nil {
+		fmt.Printf("  %s⚠️  Could not fetch libraries, dependencies won't be checked: %v%s\n", Yellow, err, Reset)
+	} else {
+		for _, lib := range libs {
+			known[lib.ID] = true
+		}
+		var suggestions []string
+		for _, id := range suggestedDependencies {
+			if known[id] {
+				suggestions = append(suggestions, id)
+			}
+		}
+		if len(suggestions) > 0 {
+			fmt.Printf("  Popular: %s (forge list shows all)\n", strings.Join(suggestions, ", "))
+		}
+	}
+	var deps []string
+	for {
+		deps = deps[:0]
+		var unknown []string
+		answer, err := ask("Dependencies (comma-separated)", "none")
+		if err != nil {
+			return "", "", err
+		}
+		for _, dep := range strings.Split(answer, ",") {
+			dep = strings.TrimSpace(dep)
+			if dep == "" || dep == "none" || containsString(deps, dep) {
+				continue
+			}
+			if len(known) > 0 && !known[dep] {
+				unknown = append(unknown, dep)
+			}
+			deps = append(deps, dep)
+		}
+		if len(unknown) == 0 {
+			break
+		}
+		fmt.Printf("  %sUnknown libraries: %s%s\n", Yellow, strings.Join(unknown, ", "), Reset)
+	}
+	sort.Strings(deps)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`# forge.yaml - C++ Project Dependencies
+package:
+  name: %s
+  version: "0.1.0"
+  cpp_standard: %d
+
+build:
+  shared_libs: %t
+  clang_format: Google
+
+testing:
+  framework: %s
+
+`, name, cppStandard, projectType == "lib", framework))
+	if len(deps) == 0 {
+		sb.WriteString("dependencies: {}\n")
+	} else {
+		sb.WriteString("dependencies:\n")
+		for _, dep := range deps {
+			sb.WriteString(fmt.Sprintf("  %s: {}\n", dep))
+		}
+	}
+	fmt.Println()
+	return projectName, sb.String(), nil
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================================
+// GENERATE COMMAND
+// ============================================================================
+
+func cmdGenerate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	outputDir := fs.String("output", ".", "Output directory")
+	features := fs.String("features", "", "Comma-separated list of features to enable")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.StringVar(outputDir, "o", ".", "Output directory (shorthand)")
+	fs.StringVar(features, "F", "", "Features to enable (shorthand)")
+	toStdout := fs.Bool("to-stdout", false, "Write the project as a ZIP to stdout (same as -o -)")
+	locked := fs.Bool("locked", false, "Fail instead of changing forge.lock")
+	fs.Parse(args)
+	*serverURL = resolveServerURL(*serverURL)
+	if *toStdout {
+		*outputDir = "-"
+	}
+
+	if err := generateProject(*serverURL, DefaultCfgFile, *outputDir, *features, *locked, false); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// ============================================================================
+// EXPAND COMMAND - Print the effective config (read-only)
+// ============================================================================
+
+func cmdExpand(args []string) {
+	fs := flag.NewFlagSet("expand", flag.ExitOnError)
+	features := fs.String("features", "", "Comma-separated list of features to enable")
+	profile := fs.String("profile", "", "Build profile to apply")
+	fs.StringVar(features, "F", "", "Features to enable (shorthand)")
+	fs.StringVar(profile, "p", "", "Build profile (shorthand)")
+	fs.Parse(args)
+
+	if err := expandConfig(DefaultCfgFile, *features, *profile); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// expandConfig prints forge.yaml after env substitution, features, the profile
+// and defaults have been applied, i.e. exactly what other commands act on
+func expandConfig(configFile, features, profile string) error {
+	config, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	enabled, err := resolveFeatures(config, features)
+	if err != nil {
+		return err
+	}
+	if profile, err = applyProfile(config, profile); err != nil {
+		return err
+	}
+
+	// Apply the same defaults the generator and build use
+	config.Package.Name = getProjectNameFromConfig(config)
+	config.Package.Version = getVersionFromConfig(config)
+	config.Package.Namespace = getNamespaceFromConfig(config)
+	config.Package.BinName = getBinNameFromConfig(config)
+	if config.Package.CppStandard == 0 {
+		config.Package.CppStandard = 17
+	}
+	// build_type and cxx_flags only take effect through a profile (see profileBuildType)
+	if profile == "" {
+		config.Build.BuildType = ""
+		config.Build.CxxFlags = ""
+	} else if config.Build.BuildType == "" {
+		config.Build.BuildType = "Debug"
+	}
+	if config.Testing.Framework == "" {
+		config.Testing.Framework = "none"
+	}
+
+	// Features and profiles have been applied above
+	config.Features = nil
+	config.Profiles = nil
+
+	var doc yaml.Node
+	if err := doc.Encode(config); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	// Feature defines and cxx_flags aren't forge.yaml keys, but generation applies
+	// them to the project target
+	if err := setYAMLValue(&doc, "build", "feature_defines", config.Build.FeatureDefines); err != nil {
+		return err
+	}
+	if err := setYAMLValue(&doc, "build", "feature_cxx_flags", config.Build.FeatureCxxFlags); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	fmt.Printf("# Effective configuration from %s\n", configFile)
+	if len(enabled) > 0 {
+		fmt.Printf("# Features: %s\n", strings.Join(enabled, ", "))
+	}
+	if profile != "" {
+		fmt.Printf("# Profile: %s\n", profile)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+// setYAMLValue appends key: value to the section mapping of doc, unless value is empty
+func setYAMLValue(doc *yaml.Node, section, key string, value []string) error {
+	if len(value) == 0 {
+		return nil
+	}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != section || doc.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		var valueNode yaml.Node
+		if err := valueNode.Encode(value); err != nil {
+			return fmt.Errorf("failed to marshal %s.%s: %w", section, key, err)
+		}
+		keyNode := yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+		doc.Content[i+1].Content = append(doc.Content[i+1].Content, &keyNode, &valueNode)
+		return nil
+	}
+	return fmt.Errorf("no %s section to add %s to", section, key)
+}
+
+// ============================================================================
+// ADD COMMAND
+// ============================================================================
+
+func cmdAdd(args []string) {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	dev := fs.Bool("dev", false, "Add as dev dependency")
+	optional := fs.Bool("optional", false, "Add as optional dependency behind a feature")
+	feature := fs.String("feature", "", "Feature that enables the optional dependency")
+	noSave := fs.Bool("no-save", false, "Try the dependency without writing forge.yaml")
+	listOptions := fs.Bool("list-options", false, "Print the library's options instead of adding it")
+	asYAML := fs.Bool("yaml", false, "With --list-options, print a forge.yaml block with the defaults commented out")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.Parse(args)
+	*serverURL = resolveServerURL(*serverURL)
+
+	remaining := fs.Args()
+	if len(remaining) < 1 {
+		fmt.Fprintf(os.Stderr, "%sError:%s Library name required\n", Red, Reset)
+		fmt.Fprintf(os.Stderr, "Usage: forge add <library> [--dev] [--optional --feature <name>] [--no-save]\n")
+		fmt.Fprintf(os.Stderr, "       forge add --list-options [--yaml] <library>\n")
+		os.Exit(ExitUsage)
+	}
+
+	if *listOptions {
+		if err := listLibraryOptions(*serverURL, remaining[0], *dev, *asYAML); err != nil {
+			fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	if *optional && *feature == "" {
+		fmt.Fprintf(os.Stderr, "%sError:%s --optional requires --feature <name>\n", Red, Reset)
+		os.Exit(ExitUsage)
+	}
+	if *optional && *dev {
+		fmt.Fprintf(os.Stderr, "%sError:%s --optional cannot be combined with --dev\n", Red, Reset)
+		os.Exit(ExitUsage)
+	}
+	if !*optional {
+		*feature = ""
+	}
+
+	libName := remaining[0]
+	if err := addDependency(*serverURL, libName, *dev, *feature, *noSave); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// listLibraryOptions prints libName's recipe options one per line, or with
+// asYAML as a forge.yaml dependency entry with every option commented out at
+// its default, ready to paste and uncomment
+func listLibraryOptions(serverURL, libName string, dev, asYAML bool) error {
+	lib, err := getLibraryInfo(serverURL, libName)
+	if err != nil {
+		return fmt.Errorf("library '%s' not found: %w", libName, err)
+	}
+
+	if asYAML {
+		section := "dependencies"
+		if dev {
+			section = "dev-dependencies"
+		}
+		if len(lib.Options) == 0 {
+			fmt.Printf("%s:\n  %s: {}\n", section, lib.ID)
+			return nil
+		}
+		fmt.Printf("%s:\n  %s:\n", section, lib.ID)
+		for _, opt := range lib.Options {
+			comment := opt.Description
+			if len(opt.Choices) > 0 {
+				comment += " (" + strings.Join(opt.Choices, ", ") + ")"
+			}
+			fmt.Printf("    # %s: %s  # %s\n", opt.ID, yamlScalar(opt.Default), comment)
+		}
+		return nil
+	}
+
+	if len(lib.Options) == 0 {
+		fmt.Printf("%s has no options\n", lib.ID)
+		return nil
+	}
+	fmt.Printf("%s%s options:%s\n", Bold, lib.ID, Reset)
+	for _, opt := range lib.Options {
+		fmt.Printf("  %s%-28s%s %-8s default: %-10s %s\n", Cyan, opt.ID, Reset, opt.Type, yamlScalar(opt.Default), opt.Description)
+		if len(opt.Choices) > 0 {
+			fmt.Printf("  %-28s %-8s choices: %s\n", "", "", strings.Join(opt.Choices, ", "))
+		}
+	}
+	return nil
+}
+
+// yamlScalar renders v as it would be written in forge.yaml
+func yamlScalar(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// addDependency adds libName to forge.yaml. When feature is non-empty the library
+// is added as an optional dependency under features.<feature>.dependencies.
+// With noSave the dependency only exists in memory for a one-off regeneration.
+func addDependency(serverURL, libName string, dev bool, feature string, noSave bool) error {
+	// Verify library exists
+	lib, err := getLibraryInfo(serverURL, libName)
+	if err != nil {
+		return fmt.Errorf("library '%s' not found: %w", libName, err)
+	}
+
+	// Load current config
+	config, err := loadConfigForEdit(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+
+	// Check if already added
+	if config.Dependencies == nil {
+		config.Dependencies = make(map[string]map[string]interface{})
+	}
+	if config.DevDependencies == nil {
+		config.DevDependencies = make(map[string]map[string]interface{})
+	}
+
+	targetDeps := config.Dependencies
+	depType := "dependency"
+	if dev {
+		targetDeps = config.DevDependencies
+		depType = "dev-dependency"
+	}
+	if feature != "" {
+		if config.Features == nil {
+			config.Features = make(map[string]FeatureConfig)
+		}
+		featureConfig := config.Features[feature]
+		if featureConfig.Dependencies == nil {
+			featureConfig.Dependencies = make(map[string]map[string]interface{})
+		}
+		config.Features[feature] = featureConfig
+		targetDeps = featureConfig.Dependencies
+		depType = fmt.Sprintf("optional dependency of feature '%s'", feature)
+
+		if _, exists := config.Dependencies[libName]; exists {
+			return fmt.Errorf("'%s' is already a dependency", libName)
+		}
+	}
+
+	if _, exists := targetDeps[libName]; exists {
+		return fmt.Errorf("'%s' is already a %s", libName, depType)
+	}
+
+	// Add the dependency
+	targetDeps[libName] = make(map[string]interface{})
+
+	fmt.Printf("%s📦 Adding '%s' to %s...%s\n", Cyan, lib.Name, depType, Reset)
+	if warning := deprecationWarning(lib); warning != "" {
+		fmt.Printf("%s⚠️  Warning: %s%s\n", Yellow, warning, Reset)
+	}
+
+	if noSave {
+		// The one-off regeneration builds an optional dependency with its feature enabled
+		if feature != "" {
+			features := append([]string{feature}, config.Registry.Features...)
+			if _, err := resolveFeatures(config, strings.Join(features, ",")); err != nil {
+				return err
+			}
+		}
+		return tryDependency(serverURL, config, libName, lib, depType)
+	}
+
+	// Save config
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s✅ Added %s (%s)%s\n", Green, lib.Name, lib.Description, Reset)
+
+	// Keep forge.lock in sync with forge.yaml (feature dependencies are not locked)
+	if feature == "" {
+		if err := updateLockFile(func(lock *LockConfig) {
+			lock.Dependencies[libName] = lockEntryForLibrary(lib)
+		}); err != nil {
+			fmt.Printf("%s⚠️  Warning: Could not update %s: %v%s\n", Yellow, LockFile, err, Reset)
+		}
+	}
+
+	// Regenerate dependencies.cmake only
+	if err := regenerateDependencies(serverURL); err != nil {
+		fmt.Printf("%s⚠️  Warning: Could not regenerate: %v%s\n", Yellow, err, Reset)
+		fmt.Printf("Run %sforge build%s to regenerate project files\n", Cyan, Reset)
+	}
+
+	return nil
+}
+
+// tryDependency regenerates dependencies.cmake from an in-memory config without
+// touching forge.yaml, and prints the block to copy if the dependency is kept
+func tryDependency(serverURL string, config *ForgeConfig, libName string, lib *Library, depType string) error {
+	block, err := yaml.Marshal(map[string]map[string]interface{}{libName: {}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal dependency: %w", err)
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := regenerateDependenciesFrom(serverURL, data); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s✅ Trying %s (%s) - forge.yaml was not modified%s\n", Green, lib.Name, lib.Description, Reset)
+	fmt.Printf("\nTo keep it, add this to forge.yaml as a %s:\n", depType)
+	for _, line := range strings.Split(strings.TrimRight(string(block), "\n"), "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Printf("\nRun %sforge generate%s to go back to the saved dependencies.\n", Cyan, Reset)
+	return nil
+}
+
+// ============================================================================
+// REMOVE COMMAND
+// ============================================================================
+
+func cmdRemove(args []string) {
+	fs := flag.NewFlagSet("remove", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "Show what would be removed without changing anything")
+	yes := fs.Bool("yes", false, "Don't ask for confirmation when the library is still in use")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.BoolVar(yes, "y", false, "Skip confirmation (shorthand)")
+	fs.Parse(args)
+	*serverURL = resolveServerURL(*serverURL)
+
+	remaining := fs.Args()
+	if len(remaining) < 1 {
+		fmt.Fprintf(os.Stderr, "%sError:%s Library name required\n", Red, Reset)
+		fmt.Fprintf(os.Stderr, "Usage: forge remove <library> [--dry-run] [-y]\n")
+		os.Exit(ExitUsage)
+	}
+
+	libName := remaining[0]
+	if err := removeDependency(*serverURL, libName, *dryRun, *yes); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// removeDependency removes libName from forge.yaml. Libraries still included
+// from project sources need confirmation unless yes is set.
+func removeDependency(serverURL, libName string, dryRun, yes bool) error {
+	config, err := loadConfigForEdit(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+
+	var sections []string
+	if _, exists := config.Dependencies[libName]; exists {
+		delete(config.Dependencies, libName)
+		sections = append(sections, "dependencies")
+	}
+	if _, exists := config.DevDependencies[libName]; exists {
+		delete(config.DevDependencies, libName)
+		sections = append(sections, "dev-dependencies")
+	}
+	features := make([]string, 0, len(config.Features))
+	for name := range config.Features {
+		features = append(features, name)
+	}
+	sort.Strings(features)
+	for _, name := range features {
+		if _, exists := config.Features[name].Dependencies[libName]; exists {
+			delete(config.Features[name].Dependencies, libName)
+			sections = append(sections, fmt.Sprintf("feature '%s'", name))
+		}
+	}
+
+	if len(sections) == 0 {
+		return fmt.Errorf("'%s' is not a dependency", libName)
+	}
+
+	// Without the recipe (server unreachable, nothing cached) assume <libName>/ headers
+	prefixes := []string{libName + "/"}
+	if lib, err := getLibraryInfo(serverURL, libName); err == nil {
+		prefixes = libraryIncludePrefixes(lib)
+	}
+	usages := findLibraryUsages(prefixes)
+
+	if dryRun {
+		fmt.Printf("%s🔍 Would remove '%s' from %s%s\n", Cyan, libName, strings.Join(sections, " and "), Reset)
+		if len(usages) == 0 {
+			fmt.Printf("   No includes of %s found in project sources\n", libName)
+		} else {
+			fmt.Printf("%s⚠️  Still included from:%s\n", Yellow, Reset)
+			for _, usage := range usages {
+				fmt.Printf("   %s\n", usage)
+			}
+		}
+		return nil
+	}
+
+	if len(usages) > 0 && !yes {
+		fmt.Printf("%s⚠️  '%s' is still included from:%s\n", Yellow, libName, Reset)
+		for _, usage := range usages {
+			fmt.Printf("   %s\n", usage)
+		}
+		fmt.Printf("Removing it will break the build. Continue? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return withExitCode(ExitUsage, fmt.Errorf("aborted, '%s' was not removed (use -y to remove it anyway)", libName))
+		}
+	}
+
+	fmt.Printf("%s🗑️  Removing '%s'...%s\n", Cyan, libName, Reset)
+
+	if err := saveConfig(config); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s✅ Removed %s%s\n", Green, libName, Reset)
+
+	if _, err := os.Stat(LockFile); err == nil {
+		if err := updateLockFile(func(lock *LockConfig) {
+			delete(lock.Dependencies, libName)
+		}); err != nil {
+			fmt.Printf("%s⚠️  Warning: Could not update %s: %v%s\n", Yellow, LockFile, err, Reset)
+		}
+	}
+
+	// Regenerate dependencies.cmake only
+	if err := regenerateDependencies(serverURL); err != nil {
+		fmt.Printf("%s⚠️  Warning: Could not regenerate: %v%s\n", Yellow, err, Reset)
+		fmt.Printf("Run %sforge build%s to regenerate project files\n", Cyan, Reset)
+	}
+
+	return nil
+}
+
+// libraryIncludePrefixes returns the header prefixes of lib, taken from the includes
+// of its recipe's usage example: the directory for <fmt/core.h>, the header itself
+// for <tinyxml2.h>. Recipes without includes are assumed to use <id>/ headers.
+func libraryIncludePrefixes(lib *Library) []string {
+	var prefixes []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(lib.UsageExample, "\n") {
+		m := includeDirective.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		prefix := m[2]
+		if dir, _, ok := strings.Cut(prefix, "/"); ok {
+			prefix = dir + "/"
+		}
+		if !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		prefixes = []string{lib.ID + "/"}
+	}
+	return prefixes
+}
+
+// findLibraryUsages returns file:line locations in src/, include/ and tests/
+// that include a header starting with one of prefixes
+func findLibraryUsages(prefixes []string) []string {
+	var usages []string
+	for _, dir := range []string{"src", "include", "tests"} {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			for i, line := range strings.Split(string(data), "\n") {
+				m := includeDirective.FindStringSubmatch(line)
+				if m == nil {
+					continue
+				}
+				for _, prefix := range prefixes {
+					if strings.HasPrefix(m[2], prefix) {
+						usages = append(usages, fmt.Sprintf("%s:%d", path, i+1))
+						break
+					}
+				}
+			}
+			return nil
+		})
+	}
+	return usages
+}
+
+// regenerateDependencies updates only the .cmake/forge/dependencies.cmake file
+func regenerateDependencies(serverURL string) error {
+	// Read config file
+	configFile := resolveConfigPath(DefaultCfgFile)
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	data, err = configToYAML(configFile, data)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	return regenerateDependenciesFrom(serverURL, data)
+}
+
+// regenerateDependenciesFrom fetches dependencies.cmake for the given forge.yaml content
+func regenerateDependenciesFrom(serverURL string, data []byte) error {
+	fmt.Printf("%s🔄 Updating dependencies.cmake...%s\n", Cyan, Reset)
+
+	data, err := mergeIncludes(resolveConfigPath(DefaultCfgFile), data, false)
+	if err != nil {
+		return err
+	}
+
+	// Resolve environment variables locally, as generateProject does
+	expanded, err := expandEnvVars(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	// Merge dependencies of registry.features, as forge generate does
+	var config ForgeConfig
+	if err := yaml.Unmarshal([]byte(expanded), &config); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	enabled, err := resolveFeatures(&config, "")
+	if err != nil {
+		return err
+	}
+	if len(enabled) > 0 {
+		merged, err := yaml.Marshal(&config)
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+		expanded = string(merged)
+	}
+	data = []byte(strings.ReplaceAll(expanded, "$", "$$"))
+
+	// Create multipart form
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	part, err := writer.CreateFormFile("file", DefaultCfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to create form file: %w", err)
+	}
+
+	if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("failed to write form data: %w", err)
+	}
+
+	if err := writeLocalRecipes(os.Stdout, writer, serverURL, "."); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	// Make request to server for dependencies only
+	url := fmt.Sprintf("%s/api/forge/dependencies", serverURL)
+	resp, err := doServerRequest(func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkServerResponse(resp, "dependencies.cmake"); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return withExitCode(ExitNetwork, fmt.Errorf("server error (%d): %s", resp.StatusCode, string(body)))
+	}
+
+	// Read dependencies.cmake content
+	cmakeContent, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Ensure .cmake/forge directory exists
+	cmakeDir := filepath.Join(".cmake", "forge")
+	if err := os.MkdirAll(cmakeDir, 0755); err != nil {
+		return fmt.Errorf("failed to create .cmake/forge directory: %w", err)
+	}
+
+	// Write dependencies.cmake
+	depsFile := filepath.Join(cmakeDir, "dependencies.cmake")
+	if err := os.WriteFile(depsFile, []byte(stampDependenciesCMake(string(cmakeContent))), 0644); err != nil {
+		return fmt.Errorf("failed to write dependencies.cmake: %w", err)
+	}
+
+	fmt.Printf("%s   📄 %s%s\n", Green, depsFile, Reset)
+	return nil
+}
+
+// localRecipesDir holds project recipe files that override the server's registry
+const localRecipesDir = ".forge/recipes"
+
+// loadLocalRecipes reads <projectDir>/.forge/recipes/*.yaml keyed by recipe id.
+// A missing directory means no overrides.
+func loadLocalRecipes(projectDir string) (map[string]map[string]interface{}, error) {
+	dir := filepath.Join(projectDir, localRecipesDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	recipes := make(map[string]map[string]interface{})
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var recipe map[string]interface{}
+		if err := yaml.Unmarshal(data, &recipe); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		id, _ := recipe["id"].(string)
+		if id == "" {
+			id = strings.TrimSuffix(entry.Name(), ext)
+			recipe["id"] = id
+		}
+		recipes[id] = recipe
+	}
+	return recipes, nil
+}
+
+// getRecipe fetches a library's full recipe from the server, or nil if it doesn't exist
+func getRecipe(serverURL, libID string) (map[string]interface{}, error) {
+	url := fmt.Sprintf("%s/api/libraries/%s", serverURL, libID)
+	resp, err := doServerRequest(func() (*http.Request, error) {
+		return http.NewRequest("GET", url, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := checkServerResponse(resp, "JSON"); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, withExitCode(ExitNetwork, fmt.Errorf("server error: %d", resp.StatusCode))
+	}
+
+	var recipe map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&recipe); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return recipe, nil
+}
+
+// mergeRecipe overlays override onto base: nested maps (e.g. fetch_content)
+// are merged key by key, every other value in override replaces base's
+func mergeRecipe(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		baseMap, baseOk := merged[k].(map[string]interface{})
+		overMap, overOk := v.(map[string]interface{})
+		if baseOk && overOk {
+			merged[k] = mergeRecipe(baseMap, overMap)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// writeLocalRecipes attaches the project's recipe overrides, merged over the
+// server's recipes (local wins), as "recipe" parts of a dependencies request.
+// Progress lines go to out.
+func writeLocalRecipes(out io.Writer, writer *multipart.Writer, serverURL, projectDir string) error {
+	recipes, err := loadLocalRecipes(projectDir)
+	if err != nil || len(recipes) == 0 {
+		return err
+	}
+
+	ids := make([]string, 0, len(recipes))
+	for id := range recipes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		base, err := getRecipe(serverURL, id)
+		if err != nil {
+			return fmt.Errorf("failed to fetch recipe '%s': %w", id, err)
+		}
+		merged := recipes[id]
+		if base != nil {
+			merged = mergeRecipe(base, recipes[id])
+		}
+		data, err := yaml.Marshal(merged)
+		if err != nil {
+			return fmt.Errorf("failed to marshal recipe '%s': %w", id, err)
+		}
+		part, err := writer.CreateFormFile("recipe", id+".yaml")
+		if err != nil {
+			return fmt.Errorf("failed to create form file: %w", err)
+		}
+		if _, err := part.Write(data); err != nil {
+			return fmt.Errorf("failed to write form data: %w", err)
+		}
+		fmt.Fprintf(out, "   Local recipe: %s (%s)\n", id, localRecipesDir)
+	}
+	return nil
+}
+
+// ============================================================================
+// UPDATE COMMAND
+// ============================================================================
+
+func cmdUpdate(args []string) {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.Parse(args)
+	*serverURL = resolveServerURL(*serverURL)
+
+	remaining := fs.Args()
+	var libName string
+	if len(remaining) > 0 {
+		libName = remaining[0]
+	}
+
+	if err := updateDependencies(*serverURL, libName); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func updateDependencies(serverURL, specificLib string) error {
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s🔄 Checking for updates...%s\n", Cyan, Reset)
+
+	// Get all libraries info
+	libs, err := getAllLibraries(serverURL)
+	if err != nil {
+		return err
+	}
+
+	libMap := make(map[string]Library)
+	for _, lib := range libs {
+		libMap[lib.ID] = lib
+	}
+
+	updated := 0
+	for libName := range config.Dependencies {
+		if specificLib != "" && libName != specificLib {
+			continue
+		}
+
+		if lib, ok := libMap[libName]; ok {
+			fmt.Printf("   ✓ %s (up to date)\n", lib.Name)
+			updated++
+		}
+	}
+
+	if updated == 0 {
+		fmt.Printf("%s✅ All dependencies are up to date%s\n", Green, Reset)
+	} else {
+		fmt.Printf("%s✅ Checked %d dependencies%s\n", Green, updated, Reset)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// OUTDATED COMMAND - Report locked vs recipe vs upstream versions (read-only)
+// ============================================================================
+
+func cmdOutdated(args []string) {
+	fs := flag.NewFlagSet("outdated", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	noRemote := fs.Bool("no-remote", false, "Don't query GitHub for the latest releases")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.Parse(args)
+	*serverURL = resolveServerURL(*serverURL)
+
+	if err := showOutdated(*serverURL, *noRemote); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func showOutdated(serverURL string, noRemote bool) error {
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+
+	// A missing lock file is not an error, the locked column is just empty
+	lock, err := loadLockFile(LockFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	libs, err := getAllLibraries(serverURL)
+	if err != nil {
+		return err
+	}
+	libMap := make(map[string]Library)
+	for _, lib := range libs {
+		libMap[lib.ID] = lib
+	}
+
+	var names []string
+	for name := range config.Dependencies {
+		names = append(names, name)
+	}
+	for name := range config.DevDependencies {
+		if _, exists := config.Dependencies[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		fmt.Printf("%s✅ No dependencies%s\n", Green, Reset)
+		return nil
+	}
+
+	fmt.Printf("%s🔍 Checking %d dependencies...%s\n\n", Cyan, len(names), Reset)
+	fmt.Printf("%s%-20s %-16s %-16s %-16s%s\n", Bold, "Name", "Locked", "Recipe", "Latest", Reset)
+
+	rateLimited := false
+	outdated := 0
+	for _, name := range names {
+		locked := "-"
+		if lock != nil {
+			if entry, ok := lock.Dependencies[name]; ok && entry.Tag != "" {
+				locked = entry.Tag
+			}
+		}
+
+		recipeTag := "-"
+		lib, known := libMap[name]
+		if known && lib.FetchContent["tag"] != "" {
+			recipeTag = lib.FetchContent["tag"]
+		}
+
+		latest := "-"
+		if !noRemote && !rateLimited && known && lib.GithubURL != "" {
+			tag, err := fetchLatestGitHubRelease(lib.GithubURL)
+			if err == errGitHubRateLimited {
+				rateLimited = true
+			} else if err == nil {
+				latest = tag
+			}
+		}
+
+		color := ""
+		if !known {
+			recipeTag = "(unknown)"
+			color = Red
+		} else if (locked != "-" && locked != "latest" && locked != recipeTag) || (latest != "-" && latest != recipeTag) {
+			color = Yellow
+			outdated++
+		}
+		fmt.Printf("%s%-20s %-16s %-16s %-16s%s\n", color, name, locked, recipeTag, latest, Reset)
+	}
+	fmt.Println()
+
+	if rateLimited {
+		fmt.Printf("%s⚠️  GitHub API rate limit reached; some latest versions were skipped (use --no-remote)%s\n", Yellow, Reset)
+	}
+	if outdated == 0 {
+		fmt.Printf("%s✅ All dependencies are up to date%s\n", Green, Reset)
+	} else {
+		fmt.Printf("%s%d dependencies have newer versions available%s\n", Yellow, outdated, Reset)
+	}
+	return nil
+}
+
+var errGitHubRateLimited = fmt.Errorf("GitHub API rate limit exceeded")
+
+var githubRepoRegex = regexp.MustCompile(`github\.com[/:]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// fetchLatestGitHubRelease returns the tag name of the latest GitHub release for a repository URL
+func fetchLatestGitHubRelease(githubURL string) (string, error) {
+	matches := githubRepoRegex.FindStringSubmatch(githubURL)
+	if len(matches) < 3 {
+		return "", fmt.Errorf("invalid GitHub URL: %s", githubURL)
+	}
+
+	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", matches[1], matches[2])
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "forge-cli")
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		return "", errGitHubRateLimited
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	return release.TagName, nil
+}
+
+// ============================================================================
+// AUDIT COMMAND - Check locked versions against security advisories (read-only)
+// ============================================================================
+
+// DefaultAdvisoriesFile is used when neither --source nor registry.advisories is set
+const DefaultAdvisoriesFile = ".forge/advisories.json"
+
+// Advisory marks versions of a dependency in [introduced, fixed) as vulnerable.
+// An empty bound is open; the dependency is matched by library ID or repository.
+type Advisory struct {
+	ID         string `json:"id"`
+	Library    string `json:"library,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+	Summary    string `json:"summary,omitempty"`
+}
+
+// AuditFinding is a locked dependency that falls in an advisory's range
+type AuditFinding struct {
+	Library  string `json:"library"`
+	Tag      string `json:"tag"`
+	Advisory string `json:"advisory"`
+	Summary  string `json:"summary,omitempty"`
+	Fixed    string `json:"fixed,omitempty"`
+}
+
+func cmdAudit(args []string) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	source := fs.String("source", "", "Advisories file or URL (default: registry.advisories or "+DefaultAdvisoriesFile+")")
+	format := fs.String("format", "text", "Output format: text or json")
+	fs.Parse(args)
+
+	findings, err := runAudit(*source, *format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+	if len(findings) > 0 {
+		os.Exit(1)
+	}
+}
+
+func runAudit(source, format string) ([]AuditFinding, error) {
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("invalid --format '%s': must be text or json", format)
+	}
+
+	lock, err := loadLockFile(LockFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s not found - run 'forge generate' first", LockFile)
+		}
+		return nil, err
+	}
+
+	if source == "" {
+		if config, err := loadConfig(DefaultCfgFile); err == nil && config.Registry.Advisories != "" {
+			source = config.Registry.Advisories
+		} else {
+			source = DefaultAdvisoriesFile
+		}
+	}
+	advisories, err := loadAdvisories(source)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(lock.Dependencies))
+	for name := range lock.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	findings := []AuditFinding{}
+	unchecked := []string{}
+	for _, name := range names {
+		entry := lock.Dependencies[name]
+		for _, adv := range advisories {
+			if !advisoryMatches(adv, name, entry) {
+				continue
+			}
+			vulnerable, ok := versionInRange(entry.Tag, adv.Introduced, adv.Fixed)
+			if !ok {
+				unchecked = append(unchecked, name)
+				break
+			}
+			if vulnerable {
+				findings = append(findings, AuditFinding{
+					Library:  name,
+					Tag:      entry.Tag,
+					Advisory: adv.ID,
+					Summary:  adv.Summary,
+					Fixed:    adv.Fixed,
+				})
+			}
+		}
+	}
+
+	if format == "json" {
+		out, err := json.MarshalIndent(map[string]interface{}{
+			"source":    source,
+			"findings":  findings,
+			"unchecked": unchecked,
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println(string(out))
+		return findings, nil
+	}
+
+	fmt.Printf("%s🔍 Auditing %d dependencies against %s%s\n", Cyan, len(names), source, Reset)
+	for _, f := range findings {
+		fixed := ""
+		if f.Fixed != "" {
+			fixed = fmt.Sprintf(" (fixed in %s)", f.Fixed)
+		}
+		fmt.Printf("  %s✗ %s %s%s: %s%s\n", Red, f.Library, f.Tag, Reset, f.Advisory, fixed)
+		if f.Summary != "" {
+			fmt.Printf("      %s\n", f.Summary)
+		}
+	}
+	for _, name := range unchecked {
+		fmt.Printf("  %s? %s %s%s: tag is not a version, can't compare\n", Yellow, name, lock.Dependencies[name].Tag, Reset)
+	}
+
+	if len(findings) == 0 {
+		fmt.Printf("%s✓ No known vulnerabilities found%s\n", Green, Reset)
+	} else {
+		fmt.Printf("\n%s%d vulnerable dependency version(s) found%s\n", Red, len(findings), Reset)
+	}
+	return findings, nil
+}
+
+// loadAdvisories reads {"advisories": [...]} from a local file or an http(s) URL
+func loadAdvisories(source string) ([]Advisory, error) {
+	var data []byte
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		client := &http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch advisories: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch advisories: %s returned status %d", source, resp.StatusCode)
+		}
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, fmt.Errorf("failed to read advisories: %w", err)
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(source); err != nil {
+			return nil, fmt.Errorf("failed to read advisories: %w", err)
+		}
+	}
+
+	var file struct {
+		Advisories []Advisory `json:"advisories"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse advisories %s: %w", source, err)
+	}
+	return file.Advisories, nil
+}
+
+// advisoryMatches reports whether adv applies to the locked dependency
+func advisoryMatches(adv Advisory, name string, entry LockEntry) bool {
+	if adv.Library != "" && adv.Library == name {
+		return true
+	}
+	return adv.Repository != "" && entry.Git != "" && normalizeRepoURL(adv.Repository) == normalizeRepoURL(entry.Git)
+}
+
+// normalizeRepoURL makes https://github.com/a/b.git and https://github.com/A/b/ compare equal
+func normalizeRepoURL(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+	url = strings.TrimSuffix(url, "/")
+	return strings.TrimSuffix(url, ".git")
+}
+
+// versionInRange reports whether tag is in [introduced, fixed). ok is false
+// when tag (or a bound) isn't a dotted version such as v1.2.3
+func versionInRange(tag, introduced, fixed string) (vulnerable, ok bool) {
+	if introduced != "" {
+		cmp, ok := compareVersions(tag, introduced)
+		if !ok {
+			return false, false
+		}
+		if cmp < 0 {
+			return false, true
+		}
+	}
+	if fixed != "" {
+		cmp, ok := compareVersions(tag, fixed)
+		if !ok {
+			return false, false
+		}
+		return cmp < 0, true
+	}
+	_, ok = parseVersion(tag)
+	return ok, ok
+}
+
+// compareVersions compares two dotted versions numerically (missing parts are 0)
+func compareVersions(a, b string) (int, bool) {
+	va, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+	vb, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+// versionRegex matches tags like 1.2, v1.2.3 or release-1.2.3
+var versionRegex = regexp.MustCompile(`^(?:[A-Za-z_-]*)?(\d+(?:\.\d+)*)$`)
+
+// parseVersion extracts the numeric components of a version tag
+func parseVersion(tag string) ([]int, bool) {
+	m := versionRegex.FindStringSubmatch(strings.TrimSpace(tag))
+	if m == nil {
+		return nil, false
+	}
+	parts := strings.Split(m[1], ".")
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// ============================================================================
+// LICENSES COMMAND - Aggregate dependency licenses
+// ============================================================================
+
+// DependencyLicense is one row of forge licenses
+type DependencyLicense struct {
+	Library string `json:"library"`
+	License string `json:"license"`
+	Source  string `json:"source,omitempty"`
+	Dev     bool   `json:"dev,omitempty"`
+	Status  string `json:"status"` // ok, copyleft or missing
+}
+
+// copyleftPrefixes match SPDX ids of (weak) copyleft licenses
+var copyleftPrefixes = []string{"GPL-", "LGPL-", "AGPL-", "MPL-", "EPL-", "EUPL-", "CDDL-", "OSL-", "CC-BY-SA-"}
+
+func cmdLicenses(args []string) {
+	fs := flag.NewFlagSet("licenses", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	format := fs.String("format", "text", "Output format: text, json or markdown")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.Parse(args)
+	*serverURL = resolveServerURL(*serverURL)
+
+	if err := showLicenses(*serverURL, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func showLicenses(serverURL, format string) error {
+	if format != "text" && format != "json" && format != "markdown" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --format '%s': must be text, json or markdown", format))
+	}
+
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+
+	libs, err := getAllLibraries(serverURL)
+	if err != nil {
+		return err
+	}
+	libMap := make(map[string]Library)
+	for _, lib := range libs {
+		libMap[lib.ID] = lib
+	}
+
+	// Project-local recipes may set or correct a license
+	local, err := loadLocalRecipes(".")
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range config.Dependencies {
+		names = append(names, name)
+	}
+	for name := range config.DevDependencies {
+		if _, exists := config.Dependencies[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	rows := []DependencyLicense{}
+	for _, name := range names {
+		lib := libMap[name]
+		license := lib.License
+		if override, ok := local[name]["license"].(string); ok {
+			license = override
+		}
+		_, isDep := config.Dependencies[name]
+		rows = append(rows, DependencyLicense{
+			Library: name,
+			License: license,
+			Source:  lib.GithubURL,
+			Dev:     !isDep,
+			Status:  licenseStatus(license),
+		})
+	}
+
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "markdown":
+		fmt.Printf("# Third-Party Notices\n\n")
+		fmt.Printf("%s uses the following third-party libraries.\n\n", getProjectNameFromConfig(config))
+		fmt.Printf("| Library | License | Source |\n")
+		fmt.Printf("|---------|---------|--------|\n")
+		for _, row := range rows {
+			license := row.License
+			if license == "" {
+				license = "Unknown"
+			}
+			fmt.Printf("| %s | %s | %s |\n", row.Library, license, row.Source)
+		}
+	default:
+		if len(rows) == 0 {
+			fmt.Printf("%s✅ No dependencies%s\n", Green, Reset)
+			return nil
+		}
+		fmt.Printf("%s%-20s %-32s %s%s\n", Bold, "Name", "License", "Note", Reset)
+		flagged := 0
+		for _, row := range rows {
+			license, note, color := row.License, "", ""
+			switch row.Status {
+			case "missing":
+				license, note, color = "(unknown)", "no license in recipe", Red
+			case "copyleft":
+				note, color = "copyleft", Yellow
+			}
+			if row.Dev {
+				note = strings.TrimPrefix(note+", dev", ", ")
+			}
+			if row.Status != "ok" {
+				flagged++
+			}
+			fmt.Printf("%s%-20s %-32s %s%s\n", color, row.Library, license, note, Reset)
+		}
+		if flagged > 0 {
+			fmt.Printf("\n%s%d dependencies need a license review%s\n", Yellow, flagged, Reset)
+		}
+	}
+	return nil
+}
+
+// licenseStatus classifies an SPDX expression: copyleft only when every
+// OR alternative is copyleft, since the permissive one can be chosen
+func licenseStatus(license string) string {
+	if strings.TrimSpace(license) == "" {
+		return "missing"
+	}
+	for _, alt := range strings.Split(license, " OR ") {
+		alt = strings.Trim(strings.TrimSpace(alt), "()")
+		copyleft := false
+		for _, prefix := range copyleftPrefixes {
+			if strings.HasPrefix(alt, prefix) {
+				copyleft = true
+				break
+			}
+		}
+		if !copyleft {
+			return "ok"
+		}
+	}
+	return "copyleft"
+}
+
+// ============================================================================
+// EXPORT COMMAND - Write dependencies for vcpkg or Conan
+// ============================================================================
+
+// exportFiles maps an export format to the file it writes
+var exportFiles = map[string]string{
+	"vcpkg": "vcpkg.json",
+	"conan": "conanfile.txt",
+}
+
+// exportedDependency is a forge dependency mapped to another package manager
+type exportedDependency struct {
+	Library string
+	Package string // vcpkg port name or Conan reference
+	Dev     bool
+}
+
+func cmdExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	output := fs.String("o", "", "Output file, - for stdout (default: vcpkg.json or conanfile.txt)")
+	force := fs.Bool("force", false, "Overwrite an existing output file")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+
+	// Accept flags before or after the format
+	var format string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		format, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+	if format == "" && fs.NArg() > 0 {
+		format = fs.Arg(0)
+	}
+	*serverURL = resolveServerURL(*serverURL)
+
+	if _, ok := exportFiles[format]; !ok {
+		fmt.Fprintf(os.Stderr, "%sError:%s export format required\n", Red, Reset)
+		fmt.Fprintf(os.Stderr, "Usage: forge export <vcpkg|conan> [-o file] [--force]\n")
+		os.Exit(ExitUsage)
+	}
+
+	if err := exportDependencies(*serverURL, format, *output, *force); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func exportDependencies(serverURL, format, output string, force bool) error {
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+
+	libs, err := getAllLibraries(serverURL)
+	if err != nil {
+		return err
+	}
+	libMap := make(map[string]Library)
+	for _, lib := range libs {
+		libMap[lib.ID] = lib
+	}
+
+	// Project-local recipes may add or correct the package names
+	local, err := loadLocalRecipes(".")
+	if err != nil {
+		return err
+	}
+	lock, _ := loadLockFile(LockFile)
+
+	var names []string
+	for name := range config.Dependencies {
+		names = append(names, name)
+	}
+	for name := range config.DevDependencies {
+		if _, exists := config.Dependencies[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var deps []exportedDependency
+	var unmapped []string
+	for _, name := range names {
+		lib, known := libMap[name]
+		if override, ok := local[name]; ok {
+			known = true
+			if v, ok := override["vcpkg_name"].(string); ok {
+				lib.VcpkgName = v
+			}
+			if v, ok := override["conan_ref"].(string); ok {
+				lib.ConanRef = v
+			}
+		}
+		if !known {
+			unmapped = append(unmapped, fmt.Sprintf("%s (no recipe)", name))
+			continue
+		}
+
+		var pkg, reason string
+		if format == "vcpkg" {
+			pkg = vcpkgName(name, lib)
+		} else {
+			tag := ""
+			if lock != nil && lock.Dependencies[name].Tag != "latest" {
+				tag = lock.Dependencies[name].Tag
+			}
+			if tag == "" {
+				tag = lib.FetchContent["tag"]
+			}
+			pkg, reason = conanReference(name, lib, tag)
+		}
+		if pkg == "" {
+			unmapped = append(unmapped, fmt.Sprintf("%s (%s)", name, reason))
+			continue
+		}
+		_, isDep := config.Dependencies[name]
+		deps = append(deps, exportedDependency{Library: name, Package: pkg, Dev: !isDep})
+	}
+
+	var content string
+	if format == "vcpkg" {
+		content, err = generateVcpkgManifest(config, deps)
+		if err != nil {
+			return err
+		}
+	} else {
+		content = generateConanfile(deps)
+	}
+
+	if output == "" {
+		output = exportFiles[format]
+	}
+	if output == "-" {
+		fmt.Print(content)
+	} else {
+		if _, err := os.Stat(output); err == nil && !force {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", output)
+		}
+		if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+		fmt.Fprintf(os.Stderr, "%s✅ Wrote %s (%d dependencies)%s\n", Green, output, len(deps), Reset)
+	}
+
+	if len(unmapped) > 0 {
+		fmt.Fprintf(os.Stderr, "%s⚠️  Not exported (set vcpkg_name or conan_ref in a local recipe to map them):%s\n", Yellow, Reset)
+		for _, name := range unmapped {
+			fmt.Fprintf(os.Stderr, "   %s\n", name)
+		}
+	}
+	return nil
+}
+
+// vcpkgName returns the vcpkg port for a library: the recipe's vcpkg_name, or the
+// id with underscores turned into hyphens, since port names can't contain them
+func vcpkgName(id string, lib Library) string {
+	if lib.VcpkgName != "" {
+		return lib.VcpkgName
+	}
+	return strings.ReplaceAll(strings.ToLower(id), "_", "-")
+}
+
+// conanReference returns name/version for a library. The recipe's conan_ref is
+// used as is when it has a version, otherwise as the name; the version comes
+// from the locked or recipe tag. An empty reference comes with the reason.
+func conanReference(id string, lib Library, tag string) (string, string) {
+	name := id
+	if lib.ConanRef != "" {
+		if strings.Contains(lib.ConanRef, "/") {
+			return lib.ConanRef, ""
+		}
+		name = lib.ConanRef
+	}
+	if _, ok := parseVersion(tag); !ok {
+		if tag == "" {
+			return "", "no version tag"
+		}
+		return "", fmt.Sprintf("tag %s is not a version", tag)
+	}
+	return name + "/" + versionRegex.FindStringSubmatch(strings.TrimSpace(tag))[1], ""
+}
+
+// generateVcpkgManifest renders a vcpkg.json manifest; vcpkg has no dev
+// dependencies, so those are listed too
+func generateVcpkgManifest(config *ForgeConfig, deps []exportedDependency) (string, error) {
+	manifest := struct {
+		Name         string   `json:"name"`
+		Version      string   `json:"version-string,omitempty"`
+		Description  string   `json:"description,omitempty"`
+		Dependencies []string `json:"dependencies"`
+	}{
+		Name:         strings.ReplaceAll(strings.ToLower(getProjectNameFromConfig(config)), "_", "-"),
+		Version:      config.Package.Version,
+		Description:  config.Package.Description,
+		Dependencies: []string{},
+	}
+	seen := make(map[string]bool)
+	for _, dep := range deps {
+		if !seen[dep.Package] {
+			seen[dep.Package] = true
+			manifest.Dependencies = append(manifest.Dependencies, dep.Package)
+		}
+	}
+	out, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
+
+// generateConanfile renders a conanfile.txt using the CMake generators
+func generateConanfile(deps []exportedDependency) string {
+	var requires, testRequires []string
+	for _, dep := range deps {
+		if dep.Dev {
+			testRequires = append(testRequires, dep.Package)
+		} else {
+			requires = append(requires, dep.Package)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Exported by forge export conan; forge.yaml is the source of truth\n")
+	b.WriteString("[requires]\n")
+	for _, ref := range requires {
+		b.WriteString(ref + "\n")
+	}
+	if len(testRequires) > 0 {
+		b.WriteString("\n[test_requires]\n")
+		for _, ref := range testRequires {
+			b.WriteString(ref + "\n")
+		}
+	}
+	b.WriteString("\n[generators]\nCMakeDeps\nCMakeToolchain\n\n[layout]\ncmake_layout\n")
+	return b.String()
+}
+
+// ============================================================================
+// DEPS COMMAND - Resolved dependencies as one document (read-only)
+// ============================================================================
+
+// ResolvedDependency is one entry of forge deps: the manifest entry joined with
+// its recipe and the source forge.lock or dependencies.cmake pins it to
+type ResolvedDependency struct {
+	ID       string                 `json:"id"`
+	Name     string                 `json:"name,omitempty"`
+	Category string                 `json:"category,omitempty"`
+	License  string                 `json:"license,omitempty"`
+	Git      string                 `json:"git,omitempty"`
+	Tag      string                 `json:"tag,omitempty"`
+	Commit   string                 `json:"commit,omitempty"`
+	Source   string                 `json:"source"` // where git/tag came from: lock, dependencies.cmake, recipe or none
+	Dev      bool                   `json:"dev"`
+	Options  map[string]interface{} `json:"options"`
+}
+
+// DependencyGraph is the output of forge deps --json
+type DependencyGraph struct {
+	Project      string               `json:"project"`
+	Version      string               `json:"version,omitempty"`
+	Dependencies []ResolvedDependency `json:"dependencies"`
+}
+
+func cmdDeps(args []string) {
+	fs := flag.NewFlagSet("deps", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	format := fs.String("format", "text", "Output format: text or json")
+	jsonOut := fs.Bool("json", false, "Same as --format json")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.Parse(args)
+	if *jsonOut {
+		*format = "json"
+	}
+	*serverURL = resolveServerURL(*serverURL)
+
+	if err := showDeps(*serverURL, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func showDeps(serverURL, format string) error {
+	if format != "text" && format != "json" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --format '%s': must be text or json", format))
+	}
+
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+	graph, err := resolveDependencyGraph(serverURL, config)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		out, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	if len(graph.Dependencies) == 0 {
+		fmt.Printf("%s✅ No dependencies%s\n", Green, Reset)
+		return nil
+	}
+	fmt.Printf("%s%-20s %-16s %-18s %s%s\n", Bold, "Name", "Tag", "Source", "Repository", Reset)
+	for _, dep := range graph.Dependencies {
+		tag, color := dep.Tag, ""
+		if tag == "" {
+			tag = "-"
+		}
+		if dep.Source == "none" {
+			color = Red
+		}
+		name := dep.ID
+		if dep.Dev {
+			name += " (dev)"
+		}
+		fmt.Printf("%s%-20s %-16s %-18s %s%s\n", color, name, tag, dep.Source, dep.Git, Reset)
+	}
+	return nil
+}
+
+// resolveDependencyGraph joins forge.yaml, forge.lock, dependencies.cmake and the
+// recipe index (with project-local recipe overrides). The recipe index comes from
+// the server or, offline, from ~/.forge/recipes.json; nothing is written.
+func resolveDependencyGraph(serverURL string, config *ForgeConfig) (*DependencyGraph, error) {
+	libs, err := getAllLibraries(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	libMap := make(map[string]Library)
+	for _, lib := range libs {
+		libMap[lib.ID] = lib
+	}
+	local, err := loadLocalRecipes(".")
+	if err != nil {
+		return nil, err
+	}
+
+	lock, _ := loadLockFile(LockFile)
+	resolved := map[string]LockEntry{}
+	if data, err := os.ReadFile(filepath.Join(".cmake", "forge", "dependencies.cmake")); err == nil {
+		resolved = resolvedDependencies(string(data))
+	}
+
+	var names []string
+	for name := range config.Dependencies {
+		names = append(names, name)
+	}
+	for name := range config.DevDependencies {
+		if _, exists := config.Dependencies[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	graph := &DependencyGraph{
+		Project:      getProjectNameFromConfig(config),
+		Version:      config.Package.Version,
+		Dependencies: []ResolvedDependency{},
+	}
+	for _, name := range names {
+		options, isDep := config.Dependencies[name]
+		if !isDep {
+			options = config.DevDependencies[name]
+		}
+		if options == nil {
+			options = map[string]interface{}{}
+		}
+		dep := ResolvedDependency{ID: name, Dev: !isDep, Options: options, Source: "none"}
+
+		lib, known := libMap[name]
+		if override, ok := local[name]; ok {
+			if v, ok := override["name"].(string); ok {
+				lib.Name = v
+			}
+			if v, ok := override["category"].(string); ok {
+				lib.Category = v
+			}
+			if v, ok := override["license"].(string); ok {
+				lib.License = v
+			}
+			if fc, ok := override["fetch_content"].(map[string]interface{}); ok {
+				merged := make(map[string]string, len(lib.FetchContent)+len(fc))
+				for k, v := range lib.FetchContent {
+					merged[k] = v
+				}
+				for k, v := range fc {
+					merged[k] = fmt.Sprint(v)
+				}
+				lib.FetchContent = merged
+			}
+			known = true
+		}
+		if known {
+			dep.Name, dep.Category, dep.License = lib.Name, lib.Category, lib.License
+		}
+
+		// The lock pins what builds fetch; "latest" only records that nothing was pinned
+		var locked LockEntry
+		if lock != nil {
+			locked = lock.Dependencies[name]
+		}
+		if locked.Tag != "" && locked.Tag != "latest" {
+			dep.Git, dep.Tag, dep.Commit, dep.Source = locked.Git, locked.Tag, locked.Commit, "lock"
+		} else if entry, ok := resolved[name]; ok {
+			dep.Git, dep.Tag, dep.Source = entry.Git, entry.Tag, "dependencies.cmake"
+		} else if known && lib.FetchContent["repository"] != "" {
+			dep.Git, dep.Tag, dep.Commit, dep.Source = lib.FetchContent["repository"], lib.FetchContent["tag"], lib.FetchContent["commit"], "recipe"
+		}
+		graph.Dependencies = append(graph.Dependencies, dep)
+	}
+	return graph, nil
+}
+
+// ============================================================================
+// SBOM COMMAND - CycloneDX bill of materials from the resolved dependencies
+// ============================================================================
+
+// cycloneDXBOM is the subset of a CycloneDX 1.5 JSON document forge sbom writes
+type cycloneDXBOM struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     cycloneDXMetadata     `json:"metadata"`
+	Components   []cycloneDXComponent  `json:"components"`
+	Dependencies []cycloneDXDependency `json:"dependencies"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cycloneDXComponent `json:"components"`
+	} `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXComponent struct {
+	Type               string                 `json:"type"`
+	BOMRef             string                 `json:"bom-ref,omitempty"`
+	Name               string                 `json:"name"`
+	Version            string                 `json:"version,omitempty"`
+	Description        string                 `json:"description,omitempty"`
+	Scope              string                 `json:"scope,omitempty"`
+	Licenses           []cycloneDXLicense     `json:"licenses,omitempty"`
+	PURL               string                 `json:"purl,omitempty"`
+	ExternalReferences []cycloneDXExternalRef `json:"externalReferences,omitempty"`
+}
+
+// cycloneDXLicense holds either a single SPDX id or an SPDX expression
+type cycloneDXLicense struct {
+	License    *cycloneDXLicenseID `json:"license,omitempty"`
+	Expression string              `json:"expression,omitempty"`
+}
+
+type cycloneDXLicenseID struct {
+	ID string `json:"id"`
+}
+
+type cycloneDXExternalRef struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+func cmdSbom(args []string) {
+	fs := flag.NewFlagSet("sbom", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	format := fs.String("format", "cyclonedx", "SBOM format: cyclonedx")
+	output := fs.String("output", "", "Write the SBOM to a file instead of stdout")
+	fs.StringVar(output, "o", "", "Output file (shorthand)")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.Parse(args)
+	*serverURL = resolveServerURL(*serverURL)
+
+	if err := writeSBOM(*serverURL, *format, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func writeSBOM(serverURL, format, output string) error {
+	if format != "cyclonedx" {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid --format '%s': must be cyclonedx", format))
+	}
+
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		return err
+	}
+	graph, err := resolveDependencyGraph(serverURL, config)
+	if err != nil {
+		return err
+	}
+
+	bom, err := cycloneDXForGraph(graph, config)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if output == "" || output == "-" {
+		os.Stdout.Write(data)
+		return nil
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	fmt.Fprintf(os.Stderr, "%s✅ Wrote %s (%d components)%s\n", Green, output, len(bom.Components), Reset)
+	return nil
+}
+
+// cycloneDXForGraph describes the project as the BOM's subject and each dependency
+// as a library component; dev-dependencies aren't shipped, so their scope is "excluded"
+func cycloneDXForGraph(graph *DependencyGraph, config *ForgeConfig) (cycloneDXBOM, error) {
+	serial, err := randomUUID()
+	if err != nil {
+		return cycloneDXBOM{}, err
+	}
+
+	projectType := "application"
+	if getProjectTypeFromConfig(config) == "lib" {
+		projectType = "library"
+	}
+	project := cycloneDXComponent{
+		Type:        projectType,
+		BOMRef:      graph.Project,
+		Name:        graph.Project,
+		Version:     graph.Version,
+		Description: config.Package.Description,
+		Licenses:    cycloneDXLicenses(config.Package.License),
+	}
+	if config.Package.Repository != "" {
+		project.ExternalReferences = []cycloneDXExternalRef{{Type: "vcs", URL: config.Package.Repository}}
+	}
+
+	bom := cycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + serial,
+		Version:      1,
+		Components:   []cycloneDXComponent{},
+	}
+	bom.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	bom.Metadata.Tools.Components = []cycloneDXComponent{{Type: "application", Name: "forge", Version: Version}}
+	bom.Metadata.Component = project
+
+	dependsOn := []string{}
+	for _, dep := range graph.Dependencies {
+		purl := dependencyPURL(dep)
+		component := cycloneDXComponent{
+			Type:     "library",
+			BOMRef:   purl,
+			Name:     dep.ID,
+			Version:  dep.Tag,
+			Scope:    "required",
+			Licenses: cycloneDXLicenses(dep.License),
+			PURL:     purl,
+		}
+		if dep.Dev {
+			component.Scope = "excluded"
+		}
+		if dep.Git != "" {
+			component.ExternalReferences = []cycloneDXExternalRef{{Type: "vcs", URL: dep.Git}}
+		}
+		bom.Components = append(bom.Components, component)
+		dependsOn = append(dependsOn, component.BOMRef)
+	}
+	bom.Dependencies = []cycloneDXDependency{{Ref: project.BOMRef, DependsOn: dependsOn}}
+	return bom, nil
+}
+
+// cycloneDXLicenses maps a recipe's SPDX expression to CycloneDX: a plain id goes
+// in license.id, anything with an operator in expression
+func cycloneDXLicenses(license string) []cycloneDXLicense {
+	license = strings.TrimSpace(license)
+	if license == "" {
+		return nil
+	}
+	if strings.ContainsAny(license, " ()") {
+		return []cycloneDXLicense{{Expression: license}}
+	}
+	return []cycloneDXLicense{{License: &cycloneDXLicenseID{ID: license}}}
+}
+
+// dependencyPURL returns pkg:github/<owner>/<repo>@<tag> for GitHub sources and
+// pkg:generic/<id>@<tag> with a vcs_url qualifier for any other repository
+func dependencyPURL(dep ResolvedDependency) string {
+	version := ""
+	if dep.Tag != "" {
+		version = "@" + url.PathEscape(dep.Tag)
+	}
+	if m := githubRepoRegex.FindStringSubmatch(dep.Git); m != nil {
+		return fmt.Sprintf("pkg:github/%s/%s%s", strings.ToLower(m[1]), strings.ToLower(m[2]), version)
+	}
+	purl := fmt.Sprintf("pkg:generic/%s%s", url.PathEscape(dep.ID), version)
+	if dep.Git != "" {
+		purl += "?vcs_url=" + url.QueryEscape("git+"+dep.Git)
+	}
+	return purl
+}
+
+// randomUUID returns a version 4 UUID for the BOM's serial number
+func randomUUID() (string, error) {
+	var b [16]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate a serial number: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// ============================================================================
+// LIST COMMAND
+// ============================================================================
+
+func cmdList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	category := fs.String("category", "", "Filter by category")
+	all := fs.Bool("all", false, "Include deprecated libraries")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.Parse(args)
+	*serverURL = resolveServerURL(*serverURL)
+
+	if err := listLibraries(*serverURL, *category, *all); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func listLibraries(serverURL, category string, all bool) error {
+	libs, err := getAllLibraries(serverURL)
+	if err != nil {
+		return err
+	}
+
+	// Group by category, hiding deprecated libraries unless --all
+	categories := make(map[string][]Library)
+	hidden := 0
+	for _, lib := range libs {
+		if category != "" && lib.Category != category {
+			continue
+		}
+		if lib.Deprecated && !all {
+			hidden++
+			continue
+		}
+		categories[lib.Category] = append(categories[lib.Category], lib)
+	}
+
+	fmt.Printf("%s📚 Available Libraries (%d total)%s\n\n", Bold, len(libs), Reset)
+
+	// Print by category
+	categoryOrder := []string{
+		"serialization", "logging", "testing", "networking", "cli",
+		"configuration", "gui", "formatting", "concurrency", "utility",
+		"database", "compression", "math", "cryptography",
+	}
+
+	for _, cat := range categoryOrder {
+		catLibs, ok := categories[cat]
+		if !ok || len(catLibs) == 0 {
+			continue
+		}
+
+		fmt.Printf("  %s%s:%s\n", Yellow, strings.Title(cat), Reset)
+		for _, lib := range catLibs {
+			headerOnly := ""
+			if lib.HeaderOnly {
+				headerOnly = fmt.Sprintf(" %s[header-only]%s", Cyan, Reset)
+			}
+			stars := ""
+			if lib.Stars > 0 {
+				stars = fmt.Sprintf(" %s⭐ %d%s", Yellow, lib.Stars, Reset)
+			}
+			deprecated := ""
+			if lib.Deprecated {
+				deprecated = fmt.Sprintf(" %s[deprecated]%s", Red, Reset)
+			}
+			fmt.Printf("    • %-20s C++%d%s%s%s\n", lib.ID, lib.CppStandard, headerOnly, stars, deprecated)
+		}
+		fmt.Println()
+	}
+
+	if hidden > 0 {
+		fmt.Printf("%d deprecated libraries hidden, use %sforge list --all%s to show them\n", hidden, Cyan, Reset)
+	}
 
-dependencies:
-  fmt: {}
-`, actualProjectName)
-	} else if templateName != "" {
-		// Fetch template from server
-		url := fmt.Sprintf("%s/api/forge/example/%s", serverURL, templateName)
-		resp, err := http.Get(url)
+	return nil
+}
+
+// ============================================================================
+// SEARCH COMMAND
+// ============================================================================
+
+func cmdSearch(args []string) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.Parse(args)
+	*serverURL = resolveServerURL(*serverURL)
+
+	remaining := fs.Args()
+	if len(remaining) < 1 {
+		fmt.Fprintf(os.Stderr, "%sError:%s Search query required\n", Red, Reset)
+		fmt.Fprintf(os.Stderr, "Usage: forge search <query>\n")
+		os.Exit(ExitUsage)
+	}
+
+	query := strings.Join(remaining, " ")
+	if err := searchLibraries(*serverURL, query); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func searchLibraries(serverURL, query string) error {
+	libs, err := getAllLibraries(serverURL)
+	if err != nil {
+		return err
+	}
+
+	query = strings.ToLower(query)
+	var results []Library
+
+	for _, lib := range libs {
+		// Search in id, name, description, tags
+		if strings.Contains(strings.ToLower(lib.ID), query) ||
+			strings.Contains(strings.ToLower(lib.Name), query) ||
+			strings.Contains(strings.ToLower(lib.Description), query) {
+			results = append(results, lib)
+			continue
+		}
+		for _, tag := range lib.Tags {
+			if strings.Contains(strings.ToLower(tag), query) {
+				results = append(results, lib)
+				break
+			}
+		}
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("%s🔍 No libraries found matching '%s'%s\n", Yellow, query, Reset)
+		return nil
+	}
+
+	fmt.Printf("%s🔍 Found %d libraries matching '%s':%s\n\n", Green, len(results), query, Reset)
+
+	for _, lib := range results {
+		fmt.Printf("  %s%s%s (%s)\n", Bold, lib.Name, Reset, lib.ID)
+		fmt.Printf("    %s\n", lib.Description)
+		if lib.Stars > 0 {
+			fmt.Printf("    %s⭐ %s%d stars%s\n", Yellow, Cyan, lib.Stars, Reset)
+		}
+		if len(lib.Tags) > 0 {
+			fmt.Printf("    Tags: %s%s%s\n", Cyan, strings.Join(lib.Tags, ", "), Reset)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// ============================================================================
+// INFO COMMAND
+// ============================================================================
+
+func cmdInfo(args []string) {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	addRetryFlags(fs)
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.Parse(args)
+	*serverURL = resolveServerURL(*serverURL)
+
+	remaining := fs.Args()
+	if len(remaining) < 1 {
+		fmt.Fprintf(os.Stderr, "%sError:%s Library name required\n", Red, Reset)
+		fmt.Fprintf(os.Stderr, "Usage: forge info <library>\n")
+		os.Exit(ExitUsage)
+	}
+
+	libName := remaining[0]
+	if err := showLibraryInfo(*serverURL, libName); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func showLibraryInfo(serverURL, libName string) error {
+	lib, err := getLibraryInfo(serverURL, libName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s%s%s\n", Bold, lib.Name, Reset)
+	fmt.Println(strings.Repeat("─", 50))
+	if warning := deprecationWarning(lib); warning != "" {
+		fmt.Printf("%s⚠️  %s%s\n", Yellow, warning, Reset)
+	}
+	fmt.Printf("ID:          %s\n", lib.ID)
+	fmt.Printf("Description: %s\n", lib.Description)
+	fmt.Printf("Category:    %s\n", lib.Category)
+	fmt.Printf("C++ Standard: C++%d\n", lib.CppStandard)
+	fmt.Printf("Header Only: %v\n", lib.HeaderOnly)
+	if lib.GithubURL != "" {
+		fmt.Printf("GitHub:      %s%s%s\n", Cyan, lib.GithubURL, Reset)
+	}
+	if lib.Stars > 0 {
+		fmt.Printf("Stars:       %s⭐ %d%s\n", Yellow, lib.Stars, Reset)
+	}
+	if len(lib.Tags) > 0 {
+		fmt.Printf("Tags:        %s\n", strings.Join(lib.Tags, ", "))
+	}
+
+	if len(lib.Options) > 0 {
+		fmt.Printf("\n%sOptions:%s\n", Yellow, Reset)
+		for _, opt := range lib.Options {
+			fmt.Printf("  %s%s%s: %s (default: %v)\n", Cyan, opt.ID, Reset, opt.Description, opt.Default)
+		}
+	}
+
+	fmt.Printf("\n%sUsage in forge.yaml:%s\n", Yellow, Reset)
+	fmt.Printf("  dependencies:\n")
+	fmt.Printf("    %s: {}\n", lib.ID)
+
+	if lib.UsageExample != "" {
+		fmt.Printf("\n%sUsage in code:%s\n", Yellow, Reset)
+		for _, line := range strings.Split(strings.TrimRight(lib.UsageExample, "\n"), "\n") {
+			if line == "" {
+				fmt.Println()
+				continue
+			}
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	return nil
+}
+
+// ============================================================================
+// FMT COMMAND
+// ============================================================================
+
+func cmdFmt(args []string) {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	check := fs.Bool("check", false, "Check formatting without modifying files")
+	bin := fs.String("clang-format-bin", "", "clang-format binary to use (default: $CLANG_FORMAT, clang-format, or the newest clang-format-N)")
+	verbose := fs.Bool("verbose", false, "Show which clang-format is used")
+	fs.BoolVar(verbose, "v", false, "Show which clang-format is used (shorthand)")
+	fs.Parse(args)
+
+	// Allow flags after the paths (forge fmt src/net --check)
+	var paths []string
+	for fs.NArg() > 0 {
+		paths = append(paths, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+
+	if err := formatCode(*check, paths, *bin, *verbose); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// formatCode runs clang-format over paths (files, directories or globs), or
+// over src/, include/ and tests/ when none are given
+func formatCode(checkOnly bool, paths []string, bin string, verbose bool) error {
+	// Check if clang-format is available
+	clangFormat, err := resolveClangTool("clang-format", bin)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s🎨 Formatting code...%s\n", Cyan, Reset)
+	if verbose {
+		fmt.Printf("   Using %s\n", clangFormat)
+	}
+
+	// Find all source files
+	extensions := []string{".cpp", ".hpp", ".c", ".h", ".cc", ".cxx", ".hxx"}
+	files, err := collectSourceFiles(paths, []string{"src", "include", "tests"}, extensions)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		fmt.Printf("%s✅ No source files found%s\n", Green, Reset)
+		return nil
+	}
+
+	// Format each file
+	formatArgs := []string{"-style=file"}
+	if !checkOnly {
+		formatArgs = append(formatArgs, "-i")
+	} else {
+		formatArgs = append(formatArgs, "--dry-run", "--Werror")
+	}
+
+	needsFormat := false
+	reformatted := 0
+	for _, file := range files {
+		// Compare contents to tell reformatted files from already formatted ones
+		before, _ := os.ReadFile(file)
+
+		args := append(formatArgs, file)
+		cmd := exec.Command(clangFormat, args...)
+		output, err := cmd.CombinedOutput()
+
+		if checkOnly && err != nil {
+			needsFormat = true
+			fmt.Printf("   %s✗ %s needs formatting%s\n", Yellow, file, Reset)
+		} else if !checkOnly && err != nil {
+			fmt.Printf("   %s✗ %s: clang-format failed%s\n", Red, file, Reset)
+		} else if !checkOnly {
+			if after, _ := os.ReadFile(file); !bytes.Equal(before, after) {
+				reformatted++
+				fmt.Printf("   ✓ %s\n", file)
+			}
+		}
+
+		if len(output) > 0 && (checkOnly || err != nil) {
+			fmt.Print(string(output))
+		}
+	}
+
+	if checkOnly && needsFormat {
+		return fmt.Errorf("some files need formatting. Run 'forge fmt' to fix")
+	}
+
+	if checkOnly {
+		fmt.Printf("%s✅ All %d files are formatted%s\n", Green, len(files), Reset)
+	} else {
+		fmt.Printf("%s✅ Reformatted %d of %d files%s\n", Green, reformatted, len(files), Reset)
+	}
+	return nil
+}
+
+// collectSourceFiles returns the files with one of extensions under paths, which
+// may be files, directories (walked recursively) or glob patterns. Without
+// paths the existing defaultDirs are walked.
+func collectSourceFiles(paths, defaultDirs, extensions []string) ([]string, error) {
+	hasExtension := func(path string) bool {
+		for _, ext := range extensions {
+			if strings.HasSuffix(path, ext) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if hasExtension(path) && !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	walk := func(root string) {
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			add(path)
+			return nil
+		})
+	}
+
+	if len(paths) == 0 {
+		for _, dir := range defaultDirs {
+			if _, err := os.Stat(dir); os.IsNotExist(err) {
+				continue
+			}
+			walk(dir)
+		}
+		return files, nil
+	}
+
+	for _, pattern := range paths {
+		matches, err := filepath.Glob(pattern)
 		if err != nil {
-			return fmt.Errorf("failed to fetch template: %w", err)
+			return nil, withExitCode(ExitUsage, fmt.Errorf("invalid pattern '%s': %w", pattern, err))
 		}
-		defer resp.Body.Close()
+		if len(matches) == 0 {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("no files match '%s'", pattern))
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				walk(match)
+			} else {
+				add(match)
+			}
+		}
+	}
+	return files, nil
+}
+
+// ============================================================================
+// LINT COMMAND
+// ============================================================================
+
+func cmdLint(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	fix := fs.Bool("fix", false, "Automatically fix issues")
+	bin := fs.String("clang-tidy-bin", "", "clang-tidy binary to use (default: $CLANG_TIDY, clang-tidy, or the newest clang-tidy-N)")
+	verbose := fs.Bool("verbose", false, "Show which clang-tidy is used")
+	fs.BoolVar(verbose, "v", false, "Show which clang-tidy is used (shorthand)")
+	fs.Parse(args)
+
+	// Allow flags after the paths (forge lint src/net --fix)
+	var paths []string
+	for fs.NArg() > 0 {
+		paths = append(paths, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+
+	if err := lintCode(*fix, paths, *bin, *verbose); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// lintCode runs clang-tidy over the sources in paths (files, directories or
+// globs), or over src/ when none are given
+func lintCode(fix bool, paths []string, bin string, verbose bool) error {
+	// Check if clang-tidy is available
+	clangTidy, err := resolveClangTool("clang-tidy", bin)
+	if err != nil {
+		return err
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("template '%s' not found", templateName)
+	fmt.Printf("%s🔍 Running static analysis...%s\n", Cyan, Reset)
+	if verbose {
+		fmt.Printf("   Using %s\n", clangTidy)
+	}
+
+	// Check for compile_commands.json
+	compileDb := "build/compile_commands.json"
+	if _, err := os.Stat(compileDb); os.IsNotExist(err) {
+		fmt.Printf("%s⚙️  Generating compile_commands.json...%s\n", Cyan, Reset)
+		cmd := exec.Command("cmake", "-B", "build", "-DCMAKE_EXPORT_COMPILE_COMMANDS=ON")
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to generate compile_commands.json: %w", err)
 		}
+	}
 
-		data, _ := io.ReadAll(resp.Body)
-		// Replace project name in template
-		configContent = strings.ReplaceAll(string(data), "my_project", actualProjectName)
-		configContent = strings.ReplaceAll(configContent, "hello_world", actualProjectName)
-	} else {
-		configContent = fmt.Sprintf(`# forge.yaml - C++ Project Dependencies
-package:
-  name: %s
-  version: "0.1.0"
-  cpp_standard: 17
+	// Find source files
+	files, err := collectSourceFiles(paths, []string{"src"}, []string{".cpp", ".cc"})
+	if err != nil {
+		return err
+	}
 
-build:
-  shared_libs: false
-  clang_format: Google
+	if len(files) == 0 {
+		fmt.Printf("%s✅ No source files found%s\n", Green, Reset)
+		return nil
+	}
 
-testing:
-  framework: googletest
+	// Run clang-tidy
+	tidyArgs := []string{"-p", "build"}
+	if fix {
+		tidyArgs = append(tidyArgs, "-fix")
+	}
+	tidyArgs = append(tidyArgs, files...)
 
-dependencies:
-  spdlog:
-    spdlog_header_only: true
-  fmt: {}
-`, actualProjectName)
+	cmd := exec.Command(clangTidy, tidyArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		// clang-tidy returns non-zero on warnings
+		fmt.Printf("%s⚠️  Analysis complete with warnings%s\n", Yellow, Reset)
+		return nil
 	}
 
-	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+	fmt.Printf("%s✅ No issues found!%s\n", Green, Reset)
+	return nil
+}
+
+// clangToolEnv maps the clang tools forge runs to their override variables
+var clangToolEnv = map[string]string{
+	"clang-format":  "CLANG_FORMAT",
+	"clang-tidy":    "CLANG_TIDY",
+	"llvm-profdata": "LLVM_PROFDATA",
+}
+
+// resolveClangTool finds the binary for tool: bin (from --<tool>-bin), then
+// $CLANG_FORMAT/$CLANG_TIDY/$LLVM_PROFDATA, then tool itself, then the newest tool-N in PATH
+// (distributions like Ubuntu often only install versioned names)
+func resolveClangTool(tool, bin string) (string, error) {
+	if bin == "" {
+		bin = os.Getenv(clangToolEnv[tool])
+	}
+	if bin != "" {
+		path, err := exec.LookPath(bin)
+		if err != nil {
+			return "", fmt.Errorf("%s not found: %s", tool, bin)
+		}
+		return path, nil
 	}
 
-	// Initialize git repository if a new directory was created
-	if targetDir != "." {
-		fmt.Printf("%s🔧 Initializing git repository...%s\n", Cyan, Reset)
-		cmd := exec.Command("git", "init")
-		cmd.Dir = targetDir
-		if err := cmd.Run(); err != nil {
-			// Git init failure is not critical, just warn
-			fmt.Printf("%s⚠️  Warning: Failed to initialize git repository: %v%s\n", Yellow, err, Reset)
-		} else {
-			fmt.Printf("%s✅ Initialized git repository%s\n", Green, Reset)
+	if path, err := exec.LookPath(tool); err == nil {
+		return path, nil
+	}
+	for version := 25; version >= 10; version-- {
+		if path, err := exec.LookPath(fmt.Sprintf("%s-%d", tool, version)); err == nil {
+			return path, nil
 		}
 	}
+	if tool == "llvm-profdata" {
+		// Only used by forge build --pgo-use, which has no --llvm-profdata-bin
+		return "", fmt.Errorf("%s not found. Please install it first (or set $%s)", tool, clangToolEnv[tool])
+	}
+	return "", fmt.Errorf("%s not found. Please install it first (or set $%s or --%s-bin)", tool, clangToolEnv[tool], tool)
+}
 
-	fmt.Printf("%s✅ Created project '%s'%s\n", Green, actualProjectName, Reset)
-	if targetDir != "." {
-		fmt.Printf("   Directory: %s\n", targetDir)
+// ============================================================================
+// CHECK COMMAND
+// ============================================================================
+
+func cmdCheck(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	fs.Parse(args)
+
+	if err := checkCode(); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
 	}
+}
 
-	// Generate project files immediately after creating forge.yaml
-	fmt.Printf("\n%s📦 Generating project files...%s\n", Cyan, Reset)
-	if err := generateProject(serverURL, configPath, targetDir, ""); err != nil {
-		// Don't fail completely, just warn
-		fmt.Printf("%s⚠️  Warning: Could not generate project files: %v%s\n", Yellow, err, Reset)
-		fmt.Printf("   You can try running manually: %sforge build%s\n", Cyan, Reset)
-	} else {
-		fmt.Printf("\n%s✅ Project '%s' ready!%s\n\n", Green, actualProjectName, Reset)
-		fmt.Printf("Next steps:\n")
-		if targetDir != "." {
-			fmt.Printf("  cd %s\n", targetDir)
+func checkCode() error {
+	fmt.Printf("%s🔎 Checking code...%s\n", Cyan, Reset)
+
+	buildDir := "build"
+
+	// Configure CMake
+	if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
+		fmt.Printf("%s⚙️  Configuring CMake...%s\n", Cyan, Reset)
+		if err := runCMakeConfigure("-B", buildDir); err != nil {
+			return err
 		}
-		fmt.Printf("  %sforge build%s       # Compile the project\n", Cyan, Reset)
-		fmt.Printf("  %sforge run%s         # Build and run\n", Cyan, Reset)
 	}
 
+	// Build with syntax check only (using -fsyntax-only would be ideal but cmake doesn't support it directly)
+	// Instead we do a quick compile
+	fmt.Printf("%s🔧 Compiling...%s\n", Cyan, Reset)
+	cmd := exec.Command("cmake", "--build", buildDir, "--", "-j", fmt.Sprintf("%d", runtime.NumCPU()))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return withExitCode(ExitBuild, fmt.Errorf("compilation failed: %w", err))
+	}
+
+	fmt.Printf("%s✅ Check passed!%s\n", Green, Reset)
 	return nil
 }
 
 // ============================================================================
-// ADD COMMAND
+// DOCTOR COMMAND - Check the toolchain
 // ============================================================================
 
-func cmdAdd(args []string) {
-	fs := flag.NewFlagSet("add", flag.ExitOnError)
-	serverURL := fs.String("server", DefaultServer, "Server URL")
-	dev := fs.Bool("dev", false, "Add as dev dependency")
-	fs.StringVar(serverURL, "s", DefaultServer, "Server URL (shorthand)")
-	fs.Parse(args)
+// ToolCheck is one row of forge doctor's report
+type ToolCheck struct {
+	Tool     string `json:"tool"`
+	Found    bool   `json:"found"`
+	Version  string `json:"version,omitempty"`
+	Required bool   `json:"required"`
+	OK       bool   `json:"ok"`
+}
 
-	remaining := fs.Args()
-	if len(remaining) < 1 {
-		fmt.Fprintf(os.Stderr, "%sError:%s Library name required\n", Red, Reset)
-		fmt.Fprintf(os.Stderr, "Usage: forge add <library> [--dev]\n")
-		os.Exit(1)
+// toolVersionRegex picks the version number out of `<tool> --version` output
+var toolVersionRegex = regexp.MustCompile(`\d+\.\d+(?:\.\d+)*`)
+
+func cmdDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	format := fs.String("format", "text", "Output format: text or json")
+	jsonOut := fs.Bool("json", false, "Same as --format json")
+	fs.Parse(args)
+	if *jsonOut {
+		*format = "json"
 	}
 
-	libName := remaining[0]
-	if err := addDependency(*serverURL, libName, *dev); err != nil {
+	ok, err := runDoctor(*format)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+	if !ok {
+		os.Exit(ExitError)
 	}
 }
 
-func addDependency(serverURL, libName string, dev bool) error {
-	// Verify library exists
-	lib, err := getLibraryInfo(serverURL, libName)
-	if err != nil {
-		return fmt.Errorf("library '%s' not found: %w", libName, err)
+// runDoctor checks the tools forge relies on and reports whether every
+// required one was found
+func runDoctor(format string) (bool, error) {
+	if format != "text" && format != "json" {
+		return false, withExitCode(ExitUsage, fmt.Errorf("invalid --format '%s': must be text or json", format))
 	}
 
-	// Load current config
-	config, err := loadConfig(DefaultCfgFile)
+	checks := []ToolCheck{
+		checkTool("cmake", true),
+		checkTool(doctorCompiler(), true),
+		checkTool("git", true),
+		checkTool("ninja", false),
+		checkTool(clangToolName("clang-format"), false),
+		checkTool(clangToolName("clang-tidy"), false),
+		checkTool("doxygen", false),
+	}
+	ok := true
+	for _, c := range checks {
+		ok = ok && c.OK
+	}
+
+	if format == "json" {
+		out, err := json.MarshalIndent(map[string]interface{}{
+			"ok":    ok,
+			"tools": checks,
+		}, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		fmt.Println(string(out))
+		return ok, nil
+	}
+
+	fmt.Printf("%s🩺 Checking toolchain...%s\n", Cyan, Reset)
+	for _, c := range checks {
+		kind := "optional"
+		if c.Required {
+			kind = "required"
+		}
+		switch {
+		case c.Found:
+			fmt.Printf("  %s✓%s %-14s %-12s (%s)\n", Green, Reset, c.Tool, c.Version, kind)
+		case c.Required:
+			fmt.Printf("  %s✗%s %-14s %-12s (%s)\n", Red, Reset, c.Tool, "not found", kind)
+		default:
+			fmt.Printf("  %s-%s %-14s %-12s (%s)\n", Yellow, Reset, c.Tool, "not found", kind)
+		}
+	}
+
+	if ok {
+		fmt.Printf("%s✅ All required tools found%s\n", Green, Reset)
+	} else {
+		fmt.Printf("%s❌ Required tools are missing%s\n", Red, Reset)
+	}
+	return ok, nil
+}
+
+// doctorCompiler returns the C++ compiler a build would use: build.compiler,
+// then $CXX, then the first of c++, g++ and clang++ in PATH
+func doctorCompiler() string {
+	if config, err := loadConfig(DefaultCfgFile); err == nil && config.Build.Compiler != "" {
+		return config.Build.Compiler
+	}
+	if cxx := os.Getenv("CXX"); cxx != "" {
+		return cxx
+	}
+	for _, name := range []string{"c++", "g++", "clang++"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return name
+		}
+	}
+	return "c++"
+}
+
+// clangToolName is the clang tool forge fmt/lint would run, as doctor reports it
+func clangToolName(tool string) string {
+	path, err := resolveClangTool(tool, "")
 	if err != nil {
-		return err
+		return tool
+	}
+	if _, err := exec.LookPath(filepath.Base(path)); err == nil {
+		return filepath.Base(path)
 	}
+	return path
+}
 
-	// Check if already added
-	if config.Dependencies == nil {
-		config.Dependencies = make(map[string]map[string]interface{})
+// checkTool looks tool up in PATH and reads its version from --version
+func checkTool(tool string, required bool) ToolCheck {
+	check := ToolCheck{Tool: tool, Required: required}
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		check.OK = !required
+		return check
 	}
-	if config.DevDependencies == nil {
-		config.DevDependencies = make(map[string]map[string]interface{})
+	check.Found = true
+	check.OK = true
+
+	out, err := exec.Command(path, "--version").CombinedOutput()
+	if err == nil {
+		check.Version = toolVersionRegex.FindString(string(out))
 	}
+	return check
+}
 
-	targetDeps := config.Dependencies
-	depType := "dependency"
-	if dev {
-		targetDeps = config.DevDependencies
-		depType = "dev-dependency"
+// ============================================================================
+// SELF-TEST COMMAND - Generate, build and run a throwaway project
+// ============================================================================
+
+// selfTestProject is the name of the project forge self-test scaffolds
+const selfTestProject = "forge_selftest"
+
+func cmdSelfTest(args []string) {
+	fs := flag.NewFlagSet("self-test", flag.ExitOnError)
+	serverURL := fs.String("server", "", "Server URL or name (default: $FORGE_SERVER, registry.server, the forge server use choice, or "+DefaultServer+")")
+	keep := fs.Bool("keep", false, "Keep the temporary project for inspection")
+	fs.StringVar(serverURL, "s", "", "Server URL (shorthand)")
+	fs.Parse(args)
+
+	ok, err := runSelfTest(resolveServerURL(*serverURL), *keep)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+		os.Exit(exitCode(err))
 	}
+	if !ok {
+		os.Exit(ExitError)
+	}
+}
 
-	if _, exists := targetDeps[libName]; exists {
-		return fmt.Errorf("'%s' is already a %s", libName, depType)
+// runSelfTest scaffolds a minimal project in a temporary directory and drives
+// this forge binary through generate, build and run. It is skipped (and
+// reports success) when the toolchain or the server isn't available.
+func runSelfTest(serverURL string, keep bool) (bool, error) {
+	fmt.Printf("%s🧪 Running forge self-test...%s\n", Cyan, Reset)
+
+	for _, tool := range []ToolCheck{checkTool("cmake", true), checkTool(doctorCompiler(), true)} {
+		if !tool.Found {
+			fmt.Printf("%s⏭️  Skipped: %s not found (run 'forge doctor')%s\n", Yellow, tool.Tool, Reset)
+			return true, nil
+		}
 	}
 
-	// Add the dependency
-	targetDeps[libName] = make(map[string]interface{})
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(serverURL + "/api/version")
+	if err != nil {
+		fmt.Printf("%s⏭️  Skipped: server %s is unreachable: %v%s\n", Yellow, serverURL, err, Reset)
+		return true, nil
+	}
+	resp.Body.Close()
 
-	fmt.Printf("%s📦 Adding '%s' to %s...%s\n", Cyan, lib.Name, depType, Reset)
+	forgeBin, err := os.Executable()
+	if err != nil {
+		return false, fmt.Errorf("failed to locate the forge binary: %w", err)
+	}
 
-	// Save config
-	if err := saveConfig(config); err != nil {
-		return err
+	tmpDir, err := os.MkdirTemp("", "forge-self-test-")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temporary directory: %w", err)
 	}
+	if keep {
+		fmt.Printf("   Project: %s\n", tmpDir)
+	} else {
+		defer os.RemoveAll(tmpDir)
+	}
+
+	// Each step runs forge as a child so the installed binary is what's tested
+	forge := func(args ...string) (string, error) {
+		cmd := exec.Command(forgeBin, args...)
+		cmd.Dir = tmpDir
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+	expected := fmt.Sprintf("Hello from %s!", selfTestProject)
+	steps := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{"scaffold", func() (string, error) {
+			config := fmt.Sprintf(`package:
+  name: %s
+  version: "0.1.0"
+  cpp_standard: 17
 
-	fmt.Printf("%s✅ Added %s (%s)%s\n", Green, lib.Name, lib.Description, Reset)
+testing:
+  framework: none
+
+dependencies: {}
+`, selfTestProject)
+			return "", os.WriteFile(filepath.Join(tmpDir, DefaultCfgFile), []byte(config), 0644)
+		}},
+		{"generate", func() (string, error) { return forge("generate", "-s", serverURL) }},
+		{"build", func() (string, error) { return forge("build") }},
+		{"run", func() (string, error) {
+			out, err := forge("run")
+			if err == nil && !strings.Contains(out, expected) {
+				err = fmt.Errorf("output doesn't contain %q", expected)
+			}
+			return out, err
+		}},
+	}
 
-	// Regenerate dependencies.cmake only
-	if err := regenerateDependencies(serverURL); err != nil {
-		fmt.Printf("%s⚠️  Warning: Could not regenerate: %v%s\n", Yellow, err, Reset)
-		fmt.Printf("Run %sforge build%s to regenerate project files\n", Cyan, Reset)
+	for _, step := range steps {
+		start := time.Now()
+		out, err := step.run()
+		if err != nil {
+			fmt.Printf("  %s✗%s %-10s %v\n", Red, Reset, step.name, err)
+			if out = strings.TrimSpace(out); out != "" {
+				fmt.Println(strings.Repeat("─", 50))
+				fmt.Println(out)
+				fmt.Println(strings.Repeat("─", 50))
+			}
+			fmt.Printf("%s❌ Self-test failed at '%s'%s\n", Red, step.name, Reset)
+			return false, nil
+		}
+		fmt.Printf("  %s✓%s %-10s (%.1fs)\n", Green, Reset, step.name, time.Since(start).Seconds())
 	}
 
-	return nil
+	fmt.Printf("%s✅ Self-test passed%s\n", Green, Reset)
+	return true, nil
 }
 
 // ============================================================================
-// REMOVE COMMAND
+// DOC COMMAND
 // ============================================================================
 
-func cmdRemove(args []string) {
-	fs := flag.NewFlagSet("remove", flag.ExitOnError)
-	serverURL := fs.String("server", DefaultServer, "Server URL")
-	fs.StringVar(serverURL, "s", DefaultServer, "Server URL (shorthand)")
+func cmdDoc(args []string) {
+	fs := flag.NewFlagSet("doc", flag.ExitOnError)
+	open := fs.Bool("open", false, "Open documentation in browser")
+	force := fs.Bool("force", false, "Regenerate Doxyfile from forge.yaml even if it exists")
+	output := fs.String("output", "", "Documentation output directory (default: docs.output or docs)")
+	fs.StringVar(output, "o", "", "Output directory (shorthand)")
+	serve := &optionalPortFlag{port: "8080"}
+	fs.Var(serve, "serve", "Serve HTML docs over HTTP (optional port, default 8080)")
+	host := fs.String("host", "127.0.0.1", "Address --serve listens on (0.0.0.0 for every interface)")
 	fs.Parse(args)
 
-	remaining := fs.Args()
-	if len(remaining) < 1 {
-		fmt.Fprintf(os.Stderr, "%sError:%s Library name required\n", Red, Reset)
-		fmt.Fprintf(os.Stderr, "Usage: forge remove <library>\n")
-		os.Exit(1)
+	// Allow "--serve 9000" in addition to "--serve=9000"
+	if serve.set && fs.NArg() > 0 {
+		if _, err := fmt.Sscanf(fs.Arg(0), "%d", new(int)); err == nil {
+			serve.port = fs.Arg(0)
+		}
 	}
 
-	libName := remaining[0]
-	if err := removeDependency(*serverURL, libName); err != nil {
+	outputDir, err := generateDocs(*force, *output)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
-}
 
-func removeDependency(serverURL, libName string) error {
-	config, err := loadConfig(DefaultCfgFile)
-	if err != nil {
-		return err
+	htmlDir := filepath.Join(outputDir, "html")
+	if serve.set {
+		if err := serveDocs(htmlDir, *host, serve.port, *open); err != nil {
+			fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
+			os.Exit(exitCode(err))
+		}
+		return
 	}
 
-	found := false
-	if _, exists := config.Dependencies[libName]; exists {
-		delete(config.Dependencies, libName)
-		found = true
-	}
-	if _, exists := config.DevDependencies[libName]; exists {
-		delete(config.DevDependencies, libName)
-		found = true
+	if *open {
+		openInBrowser(filepath.Join(htmlDir, "index.html"))
 	}
+}
 
-	if !found {
-		return fmt.Errorf("'%s' is not a dependency", libName)
+// optionalPortFlag is a boolean-style flag that also accepts a port ("--serve" or "--serve=9000")
+type optionalPortFlag struct {
+	set  bool
+	port string
+}
+
+func (f *optionalPortFlag) String() string   { return f.port }
+func (f *optionalPortFlag) IsBoolFlag() bool { return true }
+
+func (f *optionalPortFlag) Set(value string) error {
+	f.set = true
+	switch value {
+	case "true":
+	case "false":
+		f.set = false
+	default:
+		f.port = value
 	}
+	return nil
+}
 
-	fmt.Printf("%s🗑️  Removing '%s'...%s\n", Cyan, libName, Reset)
+// generateDoxyfile renders a Doxyfile from the docs section of forge.yaml
+func generateDoxyfile(config *ForgeConfig, outputDir string) string {
+	docs := config.Docs
 
-	if err := saveConfig(config); err != nil {
-		return err
+	input := docs.Input
+	if len(input) == 0 {
+		input = []string{"src", "include"}
 	}
 
-	fmt.Printf("%s✅ Removed %s%s\n", Green, libName, Reset)
+	formats := make(map[string]bool)
+	for _, f := range docs.Formats {
+		formats[strings.ToLower(f)] = true
+	}
+	if len(formats) == 0 {
+		formats["html"] = true
+	}
 
-	// Regenerate dependencies.cmake only
-	if err := regenerateDependencies(serverURL); err != nil {
-		fmt.Printf("%s⚠️  Warning: Could not regenerate: %v%s\n", Yellow, err, Reset)
-		fmt.Printf("Run %sforge build%s to regenerate project files\n", Cyan, Reset)
+	yesNo := func(b bool) string {
+		if b {
+			return "YES"
+		}
+		return "NO"
 	}
 
-	return nil
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`PROJECT_NAME           = "%s"
+PROJECT_NUMBER         = "%s"
+OUTPUT_DIRECTORY       = %s
+INPUT                  = %s
+RECURSIVE              = YES
+EXTRACT_ALL            = YES
+`, config.Package.Name, config.Package.Version, outputDir, strings.Join(input, " ")))
+	if config.Package.Description != "" {
+		sb.WriteString(fmt.Sprintf("PROJECT_BRIEF          = \"%s\"\n", strings.ReplaceAll(config.Package.Description, `"`, `\"`)))
+	}
+	// Doxygen has no repository setting; \\repository in comments expands to a link
+	if config.Package.Repository != "" {
+		sb.WriteString(fmt.Sprintf("ALIASES               += \"repository=<a href=\\\"%s\\\">%s</a>\"\n", config.Package.Repository, config.Package.Repository))
+	}
+	if len(docs.Exclude) > 0 {
+		sb.WriteString(fmt.Sprintf("EXCLUDE_PATTERNS       = %s\n", strings.Join(docs.Exclude, " ")))
+	}
+	sb.WriteString(fmt.Sprintf(`GENERATE_HTML          = %s
+GENERATE_LATEX         = %s
+GENERATE_XML           = %s
+GENERATE_MAN           = %s
+HTML_OUTPUT            = html
+HAVE_DOT               = %s
+USE_MDFILE_AS_MAINPAGE = README.md
+`, yesNo(formats["html"]), yesNo(formats["latex"]), yesNo(formats["xml"]), yesNo(formats["man"]), yesNo(docs.Graphs)))
+	return sb.String()
 }
 
-// regenerateDependencies updates only the .cmake/forge/dependencies.cmake file
-func regenerateDependencies(serverURL string) error {
-	fmt.Printf("%s🔄 Updating dependencies.cmake...%s\n", Cyan, Reset)
+func generateDocs(force bool, outputDir string) (string, error) {
+	// Check if Doxygen is available
+	if _, err := exec.LookPath("doxygen"); err != nil {
+		return "", fmt.Errorf("doxygen not found. Please install it first:\n  macOS: brew install doxygen\n  Ubuntu: sudo apt install doxygen")
+	}
 
-	// Read config file
-	data, err := os.ReadFile(DefaultCfgFile)
+	config, err := loadConfig(DefaultCfgFile)
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return "", err
 	}
 
-	// Create multipart form
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
+	// --output wins over docs.output
+	outputOverride := outputDir != ""
+	if outputDir == "" {
+		outputDir = config.Docs.Output
+	}
+	if outputDir == "" {
+		outputDir = "docs"
+	}
 
-	part, err := writer.CreateFormFile("file", DefaultCfgFile)
-	if err != nil {
-		return fmt.Errorf("failed to create form file: %w", err)
+	fmt.Printf("%s📚 Generating documentation...%s\n", Cyan, Reset)
+
+	// Create Doxyfile if it doesn't exist; a hand-maintained one is only replaced with --force
+	if _, err := os.Stat("Doxyfile"); os.IsNotExist(err) || force {
+		if err := os.WriteFile("Doxyfile", []byte(generateDoxyfile(config, outputDir)), 0644); err != nil {
+			return "", fmt.Errorf("failed to create Doxyfile: %w", err)
+		}
+		fmt.Printf("   ✓ Created Doxyfile\n")
 	}
 
-	if _, err := part.Write(data); err != nil {
-		return fmt.Errorf("failed to write form data: %w", err)
+	doxyfile, err := os.ReadFile("Doxyfile")
+	if err != nil {
+		return "", fmt.Errorf("failed to read Doxyfile: %w", err)
+	}
+	if outputOverride {
+		// Later assignments win, so the existing Doxyfile is left untouched
+		doxyfile = append(doxyfile, []byte(fmt.Sprintf("\nOUTPUT_DIRECTORY = %s\n", outputDir))...)
 	}
 
-	if err := writer.Close(); err != nil {
-		return fmt.Errorf("failed to close writer: %w", err)
+	// Run Doxygen, reading the configuration from stdin
+	cmd := exec.Command("doxygen", "-")
+	cmd.Stdin = bytes.NewReader(doxyfile)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("doxygen failed: %w", err)
 	}
 
-	// Make request to server for dependencies only
-	url := fmt.Sprintf("%s/api/forge/dependencies", serverURL)
-	req, err := http.NewRequest("POST", url, &buf)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	fmt.Printf("%s✅ Documentation generated in %s%s\n", Green, outputDir, Reset)
+	return outputDir, nil
+}
+
+// openInBrowser opens a path or URL with the platform's default handler
+func openInBrowser(target string) {
+	var openCmd string
+	switch runtime.GOOS {
+	case "darwin":
+		openCmd = "open"
+	case "linux":
+		openCmd = "xdg-open"
+	case "windows":
+		openCmd = "start"
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to connect to server: %w", err)
+	if openCmd != "" {
+		exec.Command(openCmd, target).Start()
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("server error (%d): %s", resp.StatusCode, string(body))
+// serveDocs serves the generated HTML docs until interrupted
+func serveDocs(htmlDir, host, port string, openBrowser bool) error {
+	if _, err := os.Stat(filepath.Join(htmlDir, "index.html")); err != nil {
+		return fmt.Errorf("no HTML documentation in %s (enable the html format in docs.formats)", htmlDir)
 	}
 
-	// Read dependencies.cmake content
-	cmakeContent, err := io.ReadAll(resp.Body)
+	listener, url, err := listenDocs(host, port)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return err
 	}
+	fmt.Printf("%s🌐 Serving %s at %s%s\n", Cyan, htmlDir, url, Reset)
+	fmt.Printf("   Press Ctrl+C to stop\n")
 
-	// Ensure .cmake/forge directory exists
-	cmakeDir := filepath.Join(".cmake", "forge")
-	if err := os.MkdirAll(cmakeDir, 0755); err != nil {
-		return fmt.Errorf("failed to create .cmake/forge directory: %w", err)
+	if openBrowser {
+		openInBrowser(url)
 	}
 
-	// Write dependencies.cmake
-	depsFile := filepath.Join(cmakeDir, "dependencies.cmake")
-	if err := os.WriteFile(depsFile, cmakeContent, 0644); err != nil {
-		return fmt.Errorf("failed to write dependencies.cmake: %w", err)
+	return http.Serve(listener, http.FileServer(http.Dir(htmlDir)))
+}
+
+// listenDocs listens on host:port and returns the URL to browse the docs at.
+// Only loopback is reachable by default; other hosts are an explicit --host.
+func listenDocs(host, port string) (net.Listener, string, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen on %s: %w", net.JoinHostPort(host, port), err)
 	}
 
-	fmt.Printf("%s   📄 %s%s\n", Green, depsFile, Reset)
-	return nil
+	addr := listener.Addr().(*net.TCPAddr)
+	browseHost := host
+	if addr.IP.IsUnspecified() || addr.IP.IsLoopback() {
+		browseHost = "localhost"
+	}
+	return listener, fmt.Sprintf("http://%s/", net.JoinHostPort(browseHost, strconv.Itoa(addr.Port))), nil
 }
 
 // ============================================================================
-// UPDATE COMMAND
+// AMALGAMATE COMMAND - Bundle a header-only library into one header
 // ============================================================================
 
-func cmdUpdate(args []string) {
-	fs := flag.NewFlagSet("update", flag.ExitOnError)
-	serverURL := fs.String("server", DefaultServer, "Server URL")
-	fs.StringVar(serverURL, "s", DefaultServer, "Server URL (shorthand)")
-	fs.Parse(args)
+// includeDirective matches #include "path" and #include <path>
+var includeDirective = regexp.MustCompile(`^\s*#\s*include\s*([<"])([^>"]+)[>"]`)
 
-	remaining := fs.Args()
-	var libName string
-	if len(remaining) > 0 {
-		libName = remaining[0]
-	}
+// pragmaOnce matches #pragma once, which is dropped from inlined headers
+var pragmaOnce = regexp.MustCompile(`^\s*#\s*pragma\s+once\b`)
 
-	if err := updateDependencies(*serverURL, libName); err != nil {
+func cmdAmalgamate(args []string) {
+	fs := flag.NewFlagSet("amalgamate", flag.ExitOnError)
+	out := fs.String("out", "", "Output header, - for stdout (default: single_include/<name>.hpp)")
+	fs.StringVar(out, "o", "", "Output header (shorthand)")
+	entry := fs.String("entry", "", "Header to start from (default: <include>/<name>/<name>.hpp)")
+	fs.Parse(args)
+
+	if err := amalgamate(*entry, *out); err != nil {
 		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func updateDependencies(serverURL, specificLib string) error {
+// amalgamate inlines the project-local includes of entry recursively and writes
+// the result as one header. Each header is inlined once; includes that don't
+// resolve into the project's include directories are left as they are.
+func amalgamate(entry, out string) error {
 	config, err := loadConfig(DefaultCfgFile)
 	if err != nil {
 		return err
 	}
+	projectName := getProjectNameFromConfig(config)
+	roots := getIncludeDirsFromConfig(config).all()
 
-	fmt.Printf("%s🔄 Checking for updates...%s\n", Cyan, Reset)
+	if entry == "" {
+		for _, root := range roots {
+			candidate := filepath.Join(root, projectName, projectName+".hpp")
+			if _, err := os.Stat(candidate); err == nil {
+				entry = candidate
+				break
+			}
+		}
+		if entry == "" {
+			return fmt.Errorf("no %s/%s.hpp in %s, pass --entry", projectName, projectName, strings.Join(roots, ", "))
+		}
+	}
+	if out == "" {
+		out = filepath.Join("single_include", projectName+".hpp")
+	}
 
-	// Get all libraries info
-	libs, err := getAllLibraries(serverURL)
-	if err != nil {
+	// Sources are compiled, not bundled, so the single header would be incomplete
+	if sources, _ := filepath.Glob(filepath.Join("src", "*.cpp")); len(sources) > 0 {
+		fmt.Fprintf(os.Stderr, "%s⚠️  src/ has %d source files; only headers are bundled%s\n", Yellow, len(sources), Reset)
+	}
+
+	a := &amalgamator{roots: roots, seen: make(map[string]bool)}
+	if err := a.inline(entry); err != nil {
 		return err
 	}
 
-	libMap := make(map[string]Library)
-	for _, lib := range libs {
-		libMap[lib.ID] = lib
+	guard := namespaceMacroPrefix(getNamespaceFromConfig(config)) + "_SINGLE_HPP"
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("// %s single-header distribution, generated by forge amalgamate.\n", projectName))
+	b.WriteString("// Do not edit; regenerate from the headers instead.\n\n")
+	b.WriteString(fmt.Sprintf("#ifndef %s\n#define %s\n\n", guard, guard))
+	b.WriteString(a.out.String())
+	b.WriteString(fmt.Sprintf("\n#endif  // %s\n", guard))
+
+	if out == "-" {
+		fmt.Print(b.String())
+		return nil
 	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(out), err)
+	}
+	if err := os.WriteFile(out, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	fmt.Printf("%s✅ Wrote %s (%d headers)%s\n", Green, out, len(a.seen), Reset)
+	return nil
+}
 
-	updated := 0
-	for libName := range config.Dependencies {
-		if specificLib != "" && libName != specificLib {
-			continue
-		}
+// amalgamator accumulates the inlined headers
+type amalgamator struct {
+	roots []string
+	seen  map[string]bool
+	out   strings.Builder
+}
 
-		if lib, ok := libMap[libName]; ok {
-			fmt.Printf("   ✓ %s (up to date)\n", lib.Name)
-			updated++
+// resolve finds a project header for an include: quoted includes are tried next to
+// the including file first, then both forms in the include directories
+func (a *amalgamator) resolve(from, path string, quoted bool) (string, bool) {
+	var candidates []string
+	if quoted {
+		candidates = append(candidates, filepath.Join(filepath.Dir(from), path))
+	}
+	for _, root := range a.roots {
+		candidates = append(candidates, filepath.Join(root, path))
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
 		}
 	}
+	return "", false
+}
 
-	if updated == 0 {
-		fmt.Printf("%s✅ All dependencies are up to date%s\n", Green, Reset)
-	} else {
-		fmt.Printf("%s✅ Checked %d dependencies%s\n", Green, updated, Reset)
+func (a *amalgamator) inline(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if a.seen[abs] {
+		return nil
+	}
+	a.seen[abs] = true
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
+	a.out.WriteString(fmt.Sprintf("// ---- begin %s ----\n", filepath.ToSlash(path)))
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if pragmaOnce.MatchString(line) {
+			continue
+		}
+		if m := includeDirective.FindStringSubmatch(line); m != nil {
+			if header, ok := a.resolve(path, m[2], m[1] == `"`); ok {
+				if err := a.inline(header); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		a.out.WriteString(line + "\n")
+	}
+	a.out.WriteString(fmt.Sprintf("// ---- end %s ----\n", filepath.ToSlash(path)))
 	return nil
 }
 
 // ============================================================================
-// LIST COMMAND
+// IDE COMMAND
 // ============================================================================
 
-func cmdList(args []string) {
-	fs := flag.NewFlagSet("list", flag.ExitOnError)
-	serverURL := fs.String("server", DefaultServer, "Server URL")
-	category := fs.String("category", "", "Filter by category")
-	fs.StringVar(serverURL, "s", DefaultServer, "Server URL (shorthand)")
+// ideGenerators maps an IDE name to the files it needs, relative to the project root.
+// Add an entry (e.g. "clion" writing .idea/) to support another editor.
+var ideGenerators = map[string]func(config *ForgeConfig) (map[string]string, error){
+	"vscode": generateVSCodeFiles,
+}
+
+func ideNames() []string {
+	names := make([]string, 0, len(ideGenerators))
+	for name := range ideGenerators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func cmdIDE(args []string) {
+	fs := flag.NewFlagSet("ide", flag.ExitOnError)
+	force := fs.Bool("force", false, "Overwrite existing editor configuration files")
 	fs.Parse(args)
 
-	if err := listLibraries(*serverURL, *category); err != nil {
+	if fs.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "%sError:%s IDE name required\n", Red, Reset)
+		fmt.Fprintf(os.Stderr, "Usage: forge ide <%s> [--force]\n", strings.Join(ideNames(), "|"))
+		os.Exit(ExitUsage)
+	}
+
+	if err := generateIDEConfig(fs.Arg(0), ".", *force); err != nil {
 		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func listLibraries(serverURL, category string) error {
-	libs, err := getAllLibraries(serverURL)
+// generateIDEConfig writes the editor configuration for ide into projectDir,
+// keeping existing files unless force is set
+func generateIDEConfig(ide, projectDir string, force bool) error {
+	generate, ok := ideGenerators[ide]
+	if !ok {
+		return fmt.Errorf("unknown IDE '%s' (available: %s)", ide, strings.Join(ideNames(), ", "))
+	}
+
+	config, err := loadConfig(filepath.Join(projectDir, DefaultCfgFile))
 	if err != nil {
 		return err
 	}
 
-	// Group by category
-	categories := make(map[string][]Library)
-	for _, lib := range libs {
-		if category != "" && lib.Category != category {
-			continue
-		}
-		categories[lib.Category] = append(categories[lib.Category], lib)
+	files, err := generate(config)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("%s📚 Available Libraries (%d total)%s\n\n", Bold, len(libs), Reset)
-
-	// Print by category
-	categoryOrder := []string{
-		"serialization", "logging", "testing", "networking", "cli",
-		"configuration", "gui", "formatting", "concurrency", "utility",
-		"database", "compression", "math", "cryptography",
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
 
-	for _, cat := range categoryOrder {
-		catLibs, ok := categories[cat]
-		if !ok || len(catLibs) == 0 {
+	fmt.Printf("%s🧩 Writing %s configuration...%s\n", Cyan, ide, Reset)
+	for _, path := range paths {
+		fullPath := filepath.Join(projectDir, path)
+		if _, err := os.Stat(fullPath); err == nil && !force {
+			fmt.Printf("   %s⚠️  Skipping %s (exists, use --force to overwrite)%s\n", Yellow, path, Reset)
 			continue
 		}
-
-		fmt.Printf("  %s%s:%s\n", Yellow, strings.Title(cat), Reset)
-		for _, lib := range catLibs {
-			headerOnly := ""
-			if lib.HeaderOnly {
-				headerOnly = fmt.Sprintf(" %s[header-only]%s", Cyan, Reset)
-			}
-			stars := ""
-			if lib.Stars > 0 {
-				stars = fmt.Sprintf(" %s⭐ %d%s", Yellow, lib.Stars, Reset)
-			}
-			fmt.Printf("    • %-20s C++%d%s%s\n", lib.ID, lib.CppStandard, headerOnly, stars)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
 		}
-		fmt.Println()
+		if err := os.WriteFile(fullPath, []byte(files[path]), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("   ✓ %s\n", path)
 	}
-
 	return nil
 }
 
 // ============================================================================
-// SEARCH COMMAND
+// RELEASE COMMAND
 // ============================================================================
 
-func cmdSearch(args []string) {
-	fs := flag.NewFlagSet("search", flag.ExitOnError)
-	serverURL := fs.String("server", DefaultServer, "Server URL")
-	fs.StringVar(serverURL, "s", DefaultServer, "Server URL (shorthand)")
+func cmdRelease(args []string) {
+	fs := flag.NewFlagSet("release", flag.ExitOnError)
 	fs.Parse(args)
 
 	remaining := fs.Args()
-	if len(remaining) < 1 {
-		fmt.Fprintf(os.Stderr, "%sError:%s Search query required\n", Red, Reset)
-		fmt.Fprintf(os.Stderr, "Usage: forge search <query>\n")
-		os.Exit(1)
+	bumpType := "patch"
+	if len(remaining) > 0 {
+		bumpType = remaining[0]
 	}
 
-	query := strings.Join(remaining, " ")
-	if err := searchLibraries(*serverURL, query); err != nil {
+	if err := bumpVersion(bumpType); err != nil {
 		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func searchLibraries(serverURL, query string) error {
-	libs, err := getAllLibraries(serverURL)
+func bumpVersion(bumpType string) error {
+	config, err := loadConfigForEdit(DefaultCfgFile)
 	if err != nil {
 		return err
 	}
 
-	query = strings.ToLower(query)
-	var results []Library
+	version := config.Package.Version
+	if version == "" {
+		version = "0.1.0"
+	}
+	if strings.Contains(version, "$") {
+		return fmt.Errorf("package.version is set from an environment variable (%s), update it there", version)
+	}
 
-	for _, lib := range libs {
-		// Search in id, name, description, tags
-		if strings.Contains(strings.ToLower(lib.ID), query) ||
-			strings.Contains(strings.ToLower(lib.Name), query) ||
-			strings.Contains(strings.ToLower(lib.Description), query) {
-			results = append(results, lib)
-			continue
-		}
-		for _, tag := range lib.Tags {
-			if strings.Contains(strings.ToLower(tag), query) {
-				results = append(results, lib)
-				break
-			}
-		}
+	// Parse version
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) < 3 {
+		parts = append(parts, make([]string, 3-len(parts))...)
 	}
 
-	if len(results) == 0 {
-		fmt.Printf("%s🔍 No libraries found matching '%s'%s\n", Yellow, query, Reset)
-		return nil
+	major, minor, patch := 0, 0, 0
+	fmt.Sscanf(parts[0], "%d", &major)
+	fmt.Sscanf(parts[1], "%d", &minor)
+	fmt.Sscanf(parts[2], "%d", &patch)
+
+	switch bumpType {
+	case "major":
+		major++
+		minor = 0
+		patch = 0
+	case "minor":
+		minor++
+		patch = 0
+	case "patch":
+		patch++
+	default:
+		return fmt.Errorf("invalid bump type: %s (use major, minor, or patch)", bumpType)
 	}
 
-	fmt.Printf("%s🔍 Found %d libraries matching '%s':%s\n\n", Green, len(results), query, Reset)
+	newVersion := fmt.Sprintf("%d.%d.%d", major, minor, patch)
+	config.Package.Version = newVersion
 
-	for _, lib := range results {
-		fmt.Printf("  %s%s%s (%s)\n", Bold, lib.Name, Reset, lib.ID)
-		fmt.Printf("    %s\n", lib.Description)
-		if lib.Stars > 0 {
-			fmt.Printf("    %s⭐ %s%d stars%s\n", Yellow, Cyan, lib.Stars, Reset)
-		}
-		if len(lib.Tags) > 0 {
-			fmt.Printf("    Tags: %s%s%s\n", Cyan, strings.Join(lib.Tags, ", "), Reset)
-		}
-		fmt.Println()
+	fmt.Printf("%s📦 Bumping version: %s → %s%s\n", Cyan, version, newVersion, Reset)
+
+	if err := saveConfig(config); err != nil {
+		return err
 	}
 
+	fmt.Printf("%s✅ Version updated to %s%s\n", Green, newVersion, Reset)
 	return nil
 }
 
 // ============================================================================
-// INFO COMMAND
+// SERVER COMMAND - Named servers in ~/.forge/config.yaml
 // ============================================================================
 
-func cmdInfo(args []string) {
-	fs := flag.NewFlagSet("info", flag.ExitOnError)
-	serverURL := fs.String("server", DefaultServer, "Server URL")
-	fs.StringVar(serverURL, "s", DefaultServer, "Server URL (shorthand)")
-	fs.Parse(args)
+// UserConfig is ~/.forge/config.yaml, the user's settings across projects
+type UserConfig struct {
+	// Named server URLs; the active one is used when -s, $FORGE_SERVER and
+	// registry.server are all unset
+	Servers      map[string]string `yaml:"servers,omitempty"`
+	ActiveServer string            `yaml:"active_server,omitempty"`
+}
 
-	remaining := fs.Args()
-	if len(remaining) < 1 {
-		fmt.Fprintf(os.Stderr, "%sError:%s Library name required\n", Red, Reset)
-		fmt.Fprintf(os.Stderr, "Usage: forge info <library>\n")
-		os.Exit(1)
-	}
+// publicServerName refers to DefaultServer and can't be redefined
+const publicServerName = "public"
 
-	libName := remaining[0]
-	if err := showLibraryInfo(*serverURL, libName); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+// serverNameRegex matches names accepted by forge server add
+var serverNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+func userConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
 	}
+	return filepath.Join(home, ".forge", "config.yaml"), nil
 }
 
-func showLibraryInfo(serverURL, libName string) error {
-	lib, err := getLibraryInfo(serverURL, libName)
+// loadUserConfig reads ~/.forge/config.yaml; a missing file is an empty config
+func loadUserConfig() (*UserConfig, error) {
+	config := &UserConfig{}
+	path, err := userConfigPath()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, withExitCode(ExitConfig, fmt.Errorf("invalid %s: %w", path, err))
 	}
+	return config, nil
+}
 
-	fmt.Printf("\n%s%s%s\n", Bold, lib.Name, Reset)
-	fmt.Println(strings.Repeat("─", 50))
-	fmt.Printf("ID:          %s\n", lib.ID)
-	fmt.Printf("Description: %s\n", lib.Description)
-	fmt.Printf("Category:    %s\n", lib.Category)
-	fmt.Printf("C++ Standard: C++%d\n", lib.CppStandard)
-	fmt.Printf("Header Only: %v\n", lib.HeaderOnly)
-	if lib.GithubURL != "" {
-		fmt.Printf("GitHub:      %s%s%s\n", Cyan, lib.GithubURL, Reset)
+func saveUserConfig(config *UserConfig) error {
+	path, err := userConfigPath()
+	if err != nil {
+		return err
 	}
-	if lib.Stars > 0 {
-		fmt.Printf("Stars:       %s⭐ %d%s\n", Yellow, lib.Stars, Reset)
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
 	}
-	if len(lib.Tags) > 0 {
-		fmt.Printf("Tags:        %s\n", strings.Join(lib.Tags, ", "))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
 	}
-
-	if len(lib.Options) > 0 {
-		fmt.Printf("\n%sOptions:%s\n", Yellow, Reset)
-		for _, opt := range lib.Options {
-			fmt.Printf("  %s%s%s: %s (default: %v)\n", Cyan, opt.ID, Reset, opt.Description, opt.Default)
-		}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
 	}
-
-	fmt.Printf("\n%sUsage in forge.yaml:%s\n", Yellow, Reset)
-	fmt.Printf("  dependencies:\n")
-	fmt.Printf("    %s: {}\n", lib.ID)
-
 	return nil
 }
 
-// ============================================================================
-// FMT COMMAND
-// ============================================================================
+// serverURL returns the URL for a named server
+func (c *UserConfig) serverURL(name string) (string, bool) {
+	if name == publicServerName {
+		return DefaultServer, true
+	}
+	url, ok := c.Servers[name]
+	return url, ok
+}
 
-func cmdFmt(args []string) {
-	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
-	check := fs.Bool("check", false, "Check formatting without modifying files")
-	fs.Parse(args)
+func cmdServer(args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: forge server <list|add <name> <url>|use <name>|remove <name>>\n")
+		os.Exit(ExitUsage)
+	}
+	if len(args) < 1 {
+		usage()
+	}
 
-	if err := formatCode(*check); err != nil {
+	var err error
+	switch args[0] {
+	case "list", "ls":
+		err = listServers()
+	case "add":
+		if len(args) != 3 {
+			usage()
+		}
+		err = addServer(args[1], args[2])
+	case "use":
+		if len(args) != 2 {
+			usage()
+		}
+		err = useServer(args[1])
+	case "remove", "rm":
+		if len(args) != 2 {
+			usage()
+		}
+		err = removeServer(args[1])
+	default:
+		usage()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func formatCode(checkOnly bool) error {
-	// Check if clang-format is available
-	if _, err := exec.LookPath("clang-format"); err != nil {
-		return fmt.Errorf("clang-format not found. Please install it first")
+func listServers() error {
+	config, err := loadUserConfig()
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("%s🎨 Formatting code...%s\n", Cyan, Reset)
-
-	// Find all source files
-	var files []string
-	extensions := []string{".cpp", ".hpp", ".c", ".h", ".cc", ".cxx", ".hxx"}
+	active := config.ActiveServer
+	if active == "" {
+		active = publicServerName
+	}
+	names := []string{publicServerName}
+	for name := range config.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
 
-	for _, dir := range []string{"src", "include", "tests"} {
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			continue
+	for _, name := range names {
+		url, _ := config.serverURL(name)
+		marker := " "
+		if name == active {
+			marker = Green + "*"
 		}
-		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-			if err != nil || info.IsDir() {
-				return nil
-			}
-			for _, ext := range extensions {
-				if strings.HasSuffix(path, ext) {
-					files = append(files, path)
-					break
-				}
-			}
-			return nil
-		})
+		fmt.Printf("%s %-16s %s%s\n", marker, name, url, Reset)
 	}
 
-	if len(files) == 0 {
-		fmt.Printf("%s✅ No source files found%s\n", Green, Reset)
-		return nil
+	// Point out settings that take precedence over the active server
+	if env := os.Getenv("FORGE_SERVER"); env != "" {
+		fmt.Printf("\n%sNote: $FORGE_SERVER (%s) overrides the active server%s\n", Yellow, env, Reset)
+	} else if project, err := loadConfig(DefaultCfgFile); err == nil && project.Registry.Server != "" {
+		fmt.Printf("\n%sNote: registry.server in forge.yaml (%s) overrides the active server here%s\n", Yellow, project.Registry.Server, Reset)
 	}
+	return nil
+}
 
-	// Format each file
-	formatArgs := []string{"-style=file"}
-	if !checkOnly {
-		formatArgs = append(formatArgs, "-i")
-	} else {
-		formatArgs = append(formatArgs, "--dry-run", "--Werror")
+func addServer(name, url string) error {
+	if !serverNameRegex.MatchString(name) {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid server name '%s'", name))
+	}
+	if name == publicServerName {
+		return withExitCode(ExitUsage, fmt.Errorf("'%s' is the built-in server %s", publicServerName, DefaultServer))
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return withExitCode(ExitUsage, fmt.Errorf("server URL must start with http:// or https://"))
 	}
 
-	needsFormat := false
-	for _, file := range files {
-		args := append(formatArgs, file)
-		cmd := exec.Command("clang-format", args...)
-		output, err := cmd.CombinedOutput()
-
-		if checkOnly && err != nil {
-			needsFormat = true
-			fmt.Printf("   %s✗ %s needs formatting%s\n", Yellow, file, Reset)
-		} else if !checkOnly {
-			fmt.Printf("   ✓ %s\n", file)
-		}
-
-		if len(output) > 0 && checkOnly {
-			fmt.Print(string(output))
-		}
+	config, err := loadUserConfig()
+	if err != nil {
+		return err
 	}
+	if config.Servers == nil {
+		config.Servers = make(map[string]string)
+	}
+	config.Servers[name] = strings.TrimSuffix(url, "/")
+	if err := saveUserConfig(config); err != nil {
+		return err
+	}
+	fmt.Printf("%s✅ Added server '%s' (%s)%s\n", Green, name, config.Servers[name], Reset)
+	fmt.Printf("   Switch to it with: forge server use %s\n", name)
+	return nil
+}
 
-	if checkOnly && needsFormat {
-		return fmt.Errorf("some files need formatting. Run 'forge fmt' to fix")
+func useServer(name string) error {
+	config, err := loadUserConfig()
+	if err != nil {
+		return err
+	}
+	url, ok := config.serverURL(name)
+	if !ok {
+		return withExitCode(ExitUsage, fmt.Errorf("unknown server '%s' (add it with: forge server add %s <url>)", name, name))
+	}
+	config.ActiveServer = name
+	if name == publicServerName {
+		config.ActiveServer = ""
+	}
+	if err := saveUserConfig(config); err != nil {
+		return err
 	}
+	fmt.Printf("%s✅ Using server '%s' (%s)%s\n", Green, name, url, Reset)
+	return nil
+}
 
-	fmt.Printf("%s✅ Formatted %d files%s\n", Green, len(files), Reset)
+func removeServer(name string) error {
+	config, err := loadUserConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := config.Servers[name]; !ok {
+		return withExitCode(ExitUsage, fmt.Errorf("unknown server '%s'", name))
+	}
+	delete(config.Servers, name)
+	if config.ActiveServer == name {
+		config.ActiveServer = ""
+		fmt.Printf("%s⚠️  '%s' was active, switched back to '%s'%s\n", Yellow, name, publicServerName, Reset)
+	}
+	if err := saveUserConfig(config); err != nil {
+		return err
+	}
+	fmt.Printf("%s✅ Removed server '%s'%s\n", Green, name, Reset)
 	return nil
 }
 
 // ============================================================================
-// LINT COMMAND
+// HELPER FUNCTIONS
 // ============================================================================
 
-func cmdLint(args []string) {
-	fs := flag.NewFlagSet("lint", flag.ExitOnError)
-	fix := fs.Bool("fix", false, "Automatically fix issues")
-	fs.Parse(args)
-
-	if err := lintCode(*fix); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+func loadConfig(path string) (*ForgeConfig, error) {
+	path = resolveConfigPath(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, withExitCode(ExitConfig, fmt.Errorf("failed to read %s: %w", path, err))
 	}
-}
 
-func lintCode(fix bool) error {
-	// Check if clang-tidy is available
-	if _, err := exec.LookPath("clang-tidy"); err != nil {
-		return fmt.Errorf("clang-tidy not found. Please install it first")
+	expanded, err := expandEnvVars(string(data))
+	if err != nil {
+		return nil, withExitCode(ExitConfig, fmt.Errorf("failed to parse %s: %w", path, err))
 	}
 
-	fmt.Printf("%s🔍 Running static analysis...%s\n", Cyan, Reset)
-
-	// Check for compile_commands.json
-	compileDb := "build/compile_commands.json"
-	if _, err := os.Stat(compileDb); os.IsNotExist(err) {
-		fmt.Printf("%s⚙️  Generating compile_commands.json...%s\n", Cyan, Reset)
-		cmd := exec.Command("cmake", "-B", "build", "-DCMAKE_EXPORT_COMPILE_COMMANDS=ON")
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to generate compile_commands.json: %w", err)
-		}
+	yamlData, err := configToYAML(path, []byte(expanded))
+	if err != nil {
+		return nil, withExitCode(ExitConfig, fmt.Errorf("failed to parse %s: %w", path, err))
 	}
 
-	// Find source files
-	var files []string
-	for _, dir := range []string{"src"} {
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			continue
-		}
-		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-			if err != nil || info.IsDir() {
-				return nil
-			}
-			if strings.HasSuffix(path, ".cpp") || strings.HasSuffix(path, ".cc") {
-				files = append(files, path)
-			}
-			return nil
-		})
+	yamlData, err = mergeIncludes(path, yamlData, true)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(files) == 0 {
-		fmt.Printf("%s✅ No source files found%s\n", Green, Reset)
-		return nil
+	var config ForgeConfig
+	if err := yaml.Unmarshal(yamlData, &config); err != nil {
+		return nil, withExitCode(ExitConfig, fmt.Errorf("failed to parse %s: %w", path, err))
 	}
 
-	// Run clang-tidy
-	tidyArgs := []string{"-p", "build"}
-	if fix {
-		tidyArgs = append(tidyArgs, "-fix")
+	return &config, nil
+}
+
+// loadConfigForEdit reads forge.yaml without expanding environment variables,
+// so commands that rewrite the file keep ${VAR} references intact
+func loadConfigForEdit(path string) (*ForgeConfig, error) {
+	path = resolveConfigPath(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, withExitCode(ExitConfig, fmt.Errorf("failed to read %s: %w", path, err))
 	}
-	tidyArgs = append(tidyArgs, files...)
 
-	cmd := exec.Command("clang-tidy", tidyArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	yamlData, err := configToYAML(path, data)
+	if err != nil {
+		return nil, withExitCode(ExitConfig, fmt.Errorf("failed to parse %s: %w", path, err))
+	}
 
-	if err := cmd.Run(); err != nil {
-		// clang-tidy returns non-zero on warnings
-		fmt.Printf("%s⚠️  Analysis complete with warnings%s\n", Yellow, Reset)
-		return nil
+	var config ForgeConfig
+	if err := yaml.Unmarshal(yamlData, &config); err != nil {
+		return nil, withExitCode(ExitConfig, fmt.Errorf("failed to parse %s: %w", path, err))
 	}
 
-	fmt.Printf("%s✅ No issues found!%s\n", Green, Reset)
-	return nil
+	return &config, nil
 }
 
-// ============================================================================
-// CHECK COMMAND
-// ============================================================================
-
-func cmdCheck(args []string) {
-	fs := flag.NewFlagSet("check", flag.ExitOnError)
-	fs.Parse(args)
-
-	if err := checkCode(); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+// mergeIncludes merges the manifests listed under include: into data, the
+// YAML of the manifest at path. Includes are merged in order and then
+// overlaid with the local values: maps merge key by key, scalars and lists
+// are replaced. Data without includes is returned unchanged.
+func mergeIncludes(path string, data []byte, expand bool) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, withExitCode(ExitConfig, fmt.Errorf("failed to parse %s: %w", path, err))
+	}
+	if len(includeManifests(doc)) == 0 {
+		return data, nil
+	}
+
+	merged, err := resolveIncludes(path, doc, expand, []string{absPath(path)})
+	if err != nil {
+		return nil, withExitCode(ExitConfig, err)
 	}
+	return yaml.Marshal(merged)
 }
 
-func checkCode() error {
-	fmt.Printf("%s🔎 Checking code...%s\n", Cyan, Reset)
+// resolveIncludes returns doc merged over its includes; stack holds the
+// absolute paths of the manifests being loaded, to report include cycles
+func resolveIncludes(path string, doc map[string]interface{}, expand bool, stack []string) (map[string]interface{}, error) {
+	list := includeManifests(doc)
+	if len(list) == 0 {
+		return doc, nil
+	}
+	switch include := doc["include"].(type) {
+	case []interface{}:
+		delete(doc, "include")
+	case map[string]interface{}:
+		delete(include, "manifests")
+	}
+
+	base := make(map[string]interface{})
+	for _, item := range list {
+		name, ok := item.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s: include must list manifest files", path)
+		}
+		includePath := name
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(path), name)
+		}
 
-	buildDir := "build"
+		abs := absPath(includePath)
+		for i, p := range stack {
+			if p == abs {
+				cycle := append(append([]string{}, stack[i:]...), abs)
+				return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
 
-	// Configure CMake
-	if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
-		fmt.Printf("%s⚙️  Configuring CMake...%s\n", Cyan, Reset)
-		cmd := exec.Command("cmake", "-B", buildDir)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("cmake configure failed: %w", err)
+		included, err := readIncludedConfig(includePath, path, expand)
+		if err != nil {
+			return nil, err
+		}
+		included, err = resolveIncludes(includePath, included, expand, append(stack, abs))
+		if err != nil {
+			return nil, err
 		}
+		base = mergeRecipe(base, included)
 	}
+	return mergeRecipe(base, doc), nil
+}
 
-	// Build with syntax check only (using -fsyntax-only would be ideal but cmake doesn't support it directly)
-	// Instead we do a quick compile
-	fmt.Printf("%s🔧 Compiling...%s\n", Cyan, Reset)
-	cmd := exec.Command("cmake", "--build", buildDir, "--", "-j", fmt.Sprintf("%d", runtime.NumCPU()))
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("compilation failed: %w", err)
+// includeManifests returns the manifests named by include:, either the list
+// form or include.manifests next to the header directories
+func includeManifests(doc map[string]interface{}) []interface{} {
+	switch include := doc["include"].(type) {
+	case []interface{}:
+		return include
+	case map[string]interface{}:
+		list, _ := include["manifests"].([]interface{})
+		return list
 	}
-
-	fmt.Printf("%s✅ Check passed!%s\n", Green, Reset)
 	return nil
 }
 
-// ============================================================================
-// DOC COMMAND
-// ============================================================================
-
-func cmdDoc(args []string) {
-	fs := flag.NewFlagSet("doc", flag.ExitOnError)
-	open := fs.Bool("open", false, "Open documentation in browser")
-	fs.Parse(args)
-
-	if err := generateDocs(*open); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+// readIncludedConfig parses an included manifest (YAML or TOML)
+func readIncludedConfig(path, from string, expand bool) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s (included from %s): %w", path, from, err)
 	}
-}
-
-func generateDocs(openBrowser bool) error {
-	// Check if Doxygen is available
-	if _, err := exec.LookPath("doxygen"); err != nil {
-		return fmt.Errorf("doxygen not found. Please install it first:\n  macOS: brew install doxygen\n  Ubuntu: sudo apt install doxygen")
+	if expand {
+		expanded, err := expandEnvVars(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		data = []byte(expanded)
 	}
-
-	config, err := loadConfig(DefaultCfgFile)
+	yamlData, err := configToYAML(path, data)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
 	}
 
-	fmt.Printf("%s📚 Generating documentation...%s\n", Cyan, Reset)
+	doc := make(map[string]interface{})
+	if err := yaml.Unmarshal(yamlData, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	return doc, nil
+}
 
-	// Create Doxyfile if it doesn't exist
-	if _, err := os.Stat("Doxyfile"); os.IsNotExist(err) {
-		doxyContent := fmt.Sprintf(`PROJECT_NAME           = "%s"
-PROJECT_NUMBER         = "%s"
-OUTPUT_DIRECTORY       = docs
-INPUT                  = src include
-RECURSIVE              = YES
-EXTRACT_ALL            = YES
-GENERATE_HTML          = YES
-GENERATE_LATEX         = NO
-HTML_OUTPUT            = html
-USE_MDFILE_AS_MAINPAGE = README.md
-`, config.Package.Name, config.Package.Version)
+// absPath returns path made absolute, or path itself if that fails
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
 
-		if err := os.WriteFile("Doxyfile", []byte(doxyContent), 0644); err != nil {
-			return fmt.Errorf("failed to create Doxyfile: %w", err)
+// resolveConfigPath returns the manifest to use for path: a forge.yaml that
+// doesn't exist falls back to a forge.toml next to it. YAML stays the default.
+func resolveConfigPath(path string) string {
+	if filepath.Base(path) != DefaultCfgFile {
+		return path
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		tomlPath := filepath.Join(filepath.Dir(path), TomlCfgFile)
+		if _, err := os.Stat(tomlPath); err == nil {
+			return tomlPath
 		}
-		fmt.Printf("   ✓ Created Doxyfile\n")
 	}
+	return path
+}
 
-	// Run Doxygen
-	cmd := exec.Command("doxygen")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("doxygen failed: %w", err)
+func isTOMLConfig(path string) bool {
+	return strings.HasSuffix(path, ".toml")
+}
+
+// configToYAML converts a TOML manifest to YAML so both formats share the
+// yaml-tagged ForgeConfig and the server upload; YAML is returned unchanged
+func configToYAML(path string, data []byte) ([]byte, error) {
+	if !isTOMLConfig(path) {
+		return data, nil
+	}
+	var doc map[string]interface{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
 	}
+	return yaml.Marshal(doc)
+}
 
-	indexPath := "docs/html/index.html"
-	fmt.Printf("%s✅ Documentation generated at %s%s\n", Green, indexPath, Reset)
+// configFromYAML converts marshalled YAML back to the format of path
+func configFromYAML(path string, data []byte) ([]byte, error) {
+	if !isTOMLConfig(path) {
+		return data, nil
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return toml.Marshal(dropNullValues(doc))
+}
 
-	if openBrowser {
-		var openCmd string
-		switch runtime.GOOS {
-		case "darwin":
-			openCmd = "open"
-		case "linux":
-			openCmd = "xdg-open"
-		case "windows":
-			openCmd = "start"
+// dropNullValues removes nil entries, which TOML cannot represent
+func dropNullValues(doc map[string]interface{}) map[string]interface{} {
+	for key, value := range doc {
+		switch v := value.(type) {
+		case nil:
+			delete(doc, key)
+		case map[string]interface{}:
+			doc[key] = dropNullValues(v)
 		}
+	}
+	return doc
+}
 
-		if openCmd != "" {
-			exec.Command(openCmd, indexPath).Start()
+// expandEnvVars expands ${VAR}, ${VAR:-default} and $VAR from the environment.
+// A literal dollar sign is written as $$. Unset variables without a default are an error.
+func expandEnvVars(s string) (string, error) {
+	var missing []string
+	seen := make(map[string]bool)
+	out := envVarRegex.ReplaceAllStringFunc(s, func(m string) string {
+		if m == "$$" {
+			return "$"
+		}
+		sub := envVarRegex.FindStringSubmatch(m)
+		name := sub[1]
+		if name == "" {
+			name = sub[4]
 		}
+		value, ok := os.LookupEnv(name)
+		if sub[2] != "" {
+			if !ok || value == "" {
+				return sub[3]
+			}
+			return value
+		}
+		if !ok {
+			if !seen[name] {
+				seen[name] = true
+				missing = append(missing, name)
+			}
+			return m
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable not set: %s", strings.Join(missing, ", "))
 	}
-
-	return nil
+	return out, nil
 }
 
-// ============================================================================
-// RELEASE COMMAND
-// ============================================================================
-
-func cmdRelease(args []string) {
-	fs := flag.NewFlagSet("release", flag.ExitOnError)
-	fs.Parse(args)
+// getVersionFromConfig extracts version from config with default fallback
+func getVersionFromConfig(config *ForgeConfig) string {
+	version := config.Package.Version
+	if version == "" {
+		return "1.0.0"
+	}
+	return version
+}
 
-	remaining := fs.Args()
-	bumpType := "patch"
-	if len(remaining) > 0 {
-		bumpType = remaining[0]
+// getProjectNameFromConfig extracts project name from config with default fallback
+func getProjectNameFromConfig(config *ForgeConfig) string {
+	name := config.Package.Name
+	if name == "" {
+		return "my_project"
 	}
+	return name
+}
 
-	if err := bumpVersion(bumpType); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s %v\n", Red, Reset, err)
-		os.Exit(1)
+// getCppStandardFromConfig extracts the C++ standard from config, defaulting to 17
+func getCppStandardFromConfig(config *ForgeConfig) int {
+	if config.Package.CppStandard == 0 {
+		return 17
 	}
+	return config.Package.CppStandard
 }
 
-func bumpVersion(bumpType string) error {
-	config, err := loadConfig(DefaultCfgFile)
-	if err != nil {
-		return err
+// getBinNameFromConfig extracts the executable name from config, defaulting to the project name
+func getBinNameFromConfig(config *ForgeConfig) string {
+	if config.Package.BinName != "" {
+		return config.Package.BinName
 	}
+	return getProjectNameFromConfig(config)
+}
 
-	version := config.Package.Version
-	if version == "" {
-		version = "0.1.0"
+// getInstallFromConfig reports whether install rules are generated (default: libraries only)
+func getInstallFromConfig(config *ForgeConfig) bool {
+	if config.Build.Install != nil {
+		return *config.Build.Install
 	}
+	return getProjectTypeFromConfig(config) == "lib"
+}
 
-	// Parse version
-	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
-	if len(parts) < 3 {
-		parts = append(parts, make([]string, 3-len(parts))...)
+// getProjectTypeFromConfig returns "lib" or "exe" from package.project_type,
+// defaulting to a library when build.shared_libs is set
+func getProjectTypeFromConfig(config *ForgeConfig) string {
+	switch config.Package.ProjectType {
+	case "lib", "library":
+		return "lib"
+	case "exe", "executable":
+		return "exe"
 	}
+	if config.Build.SharedLibs {
+		return "lib"
+	}
+	return "exe"
+}
 
-	major, minor, patch := 0, 0, 0
-	fmt.Sscanf(parts[0], "%d", &major)
-	fmt.Sscanf(parts[1], "%d", &minor)
-	fmt.Sscanf(parts[2], "%d", &patch)
+// defaultSources compiles every .cpp under src/
+var defaultSources = []string{"src/**/*.cpp"}
 
-	switch bumpType {
-	case "major":
-		major++
-		minor = 0
-		patch = 0
-	case "minor":
-		minor++
-		patch = 0
-	case "patch":
-		patch++
-	default:
-		return fmt.Errorf("invalid bump type: %s (use major, minor, or patch)", bumpType)
+// getSourcesFromConfig returns the main target's sources, defaulting to all of src/
+func getSourcesFromConfig(config *ForgeConfig) []string {
+	if len(config.Sources) == 0 {
+		return defaultSources
 	}
+	sources := make([]string, 0, len(config.Sources))
+	for _, src := range config.Sources {
+		sources = append(sources, filepath.ToSlash(strings.TrimPrefix(src, "./")))
+	}
+	return sources
+}
 
-	newVersion := fmt.Sprintf("%d.%d.%d", major, minor, patch)
-	config.Package.Version = newVersion
+// getTestsBuildByDefaultFromConfig reports whether tests are built by a bare cmake --build (default true)
+func getTestsBuildByDefaultFromConfig(config *ForgeConfig) bool {
+	return config.Testing.BuildByDefault == nil || *config.Testing.BuildByDefault
+}
 
-	fmt.Printf("%s📦 Bumping version: %s → %s%s\n", Cyan, version, newVersion, Reset)
+// warningFlags maps build.warnings levels to GCC/Clang and MSVC flags
+var warningFlags = map[string][2]string{
+	"strict":   {"-Wall;-Wextra;-Wpedantic;-Werror", "/W4;/WX"},
+	"standard": {"-Wall;-Wextra", "/W4"},
+	"off":      {"", ""},
+}
 
-	if err := saveConfig(config); err != nil {
-		return err
+// getWarningsFromConfig returns the build.warnings level, defaulting to standard
+func getWarningsFromConfig(config *ForgeConfig) (string, error) {
+	level := strings.ToLower(config.Build.Warnings)
+	if level == "" {
+		return "standard", nil
+	}
+	if _, ok := warningFlags[level]; !ok {
+		return "", fmt.Errorf("invalid build.warnings '%s': must be strict, standard or off", config.Build.Warnings)
 	}
+	return level, nil
+}
 
-	fmt.Printf("%s✅ Version updated to %s%s\n", Green, newVersion, Reset)
-	return nil
+// all returns public then private include directories
+func (c IncludeConfig) all() []string {
+	dirs := make([]string, 0, len(c.Public)+len(c.Private))
+	dirs = append(dirs, c.Public...)
+	return append(dirs, c.Private...)
 }
 
-// ============================================================================
-// HELPER FUNCTIONS
-// ============================================================================
+// getIncludeDirsFromConfig returns the header directories, defaulting to a single public include/
+func getIncludeDirsFromConfig(config *ForgeConfig) IncludeConfig {
+	var includes IncludeConfig
+	for _, dir := range config.Include.Public {
+		includes.Public = append(includes.Public, strings.TrimSuffix(dir, "/"))
+	}
+	if len(includes.Public) == 0 {
+		includes.Public = []string{"include"}
+	}
+	for _, dir := range config.Include.Private {
+		includes.Private = append(includes.Private, strings.TrimSuffix(dir, "/"))
+	}
+	return includes
+}
 
-func loadConfig(path string) (*ForgeConfig, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+// applyProfile merges the named profile from forge.yaml over the build section.
+// An empty name falls back to registry.profile. Returns the applied profile name.
+func applyProfile(config *ForgeConfig, name string) (string, error) {
+	if name == "" {
+		name = config.Registry.Profile
+	}
+	if name == "" {
+		return "", nil
 	}
 
-	var config ForgeConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	profile, ok := config.Profiles[name]
+	if !ok {
+		var available []string
+		for p := range config.Profiles {
+			available = append(available, p)
+		}
+		sort.Strings(available)
+		if len(available) == 0 {
+			return "", fmt.Errorf("unknown profile '%s': forge.yaml defines no profiles", name)
+		}
+		return "", fmt.Errorf("unknown profile '%s' (available: %s)", name, strings.Join(available, ", "))
 	}
 
-	return &config, nil
+	if profile.BuildType != "" {
+		config.Build.BuildType = profile.BuildType
+	}
+	if profile.CxxFlags != "" {
+		config.Build.CxxFlags = profile.CxxFlags
+	}
+	if profile.Compiler != "" {
+		config.Build.Compiler = profile.Compiler
+	}
+	if profile.CCompiler != "" {
+		config.Build.CCompiler = profile.CCompiler
+	}
+	if profile.LTO != nil {
+		config.Build.LTO = *profile.LTO
+	}
+	return name, nil
 }
 
-// getVersionFromConfig extracts version from config with default fallback
-func getVersionFromConfig(config *ForgeConfig) string {
-	version := config.Package.Version
-	if version == "" {
-		return "1.0.0"
+// resolveServerURL picks the server URL: --server flag (a URL or a named server),
+// then $FORGE_SERVER, then registry.server in forge.yaml, then the server chosen
+// with forge server use, then the built-in default
+func resolveServerURL(flagValue string) string {
+	userConfig, err := loadUserConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s⚠️  Ignoring ~/.forge/config.yaml: %v%s\n", Yellow, err, Reset)
+		userConfig = &UserConfig{}
 	}
-	return version
+
+	// -s also accepts the name of a server from forge server add
+	if flagValue != "" {
+		if url, ok := userConfig.serverURL(flagValue); ok {
+			return url
+		}
+		return flagValue
+	}
+	if env := os.Getenv("FORGE_SERVER"); env != "" {
+		return env
+	}
+	if config, err := loadConfig(DefaultCfgFile); err == nil && config.Registry.Server != "" {
+		return config.Registry.Server
+	}
+	if url, ok := userConfig.serverURL(userConfig.ActiveServer); ok {
+		return url
+	}
+	return DefaultServer
 }
 
-// getProjectNameFromConfig extracts project name from config with default fallback
-func getProjectNameFromConfig(config *ForgeConfig) string {
-	name := config.Package.Name
-	if name == "" {
-		return "my_project"
+// getNamespaceFromConfig extracts the C++ namespace from config, defaulting to the project name
+func getNamespaceFromConfig(config *ForgeConfig) string {
+	if config.Package.Namespace != "" {
+		return config.Package.Namespace
 	}
-	return name
+	return getProjectNameFromConfig(config)
+}
+
+// testLabel is a test directory and the CTest label its tests get
+type testLabel struct {
+	Dir   string
+	Label string
+}
+
+// getTestLabelsFromConfig returns the CTest label of every testing.labels
+// directory. tests/ always comes first, defaulting to "unit"; the other
+// directories follow in name order.
+func getTestLabelsFromConfig(config *ForgeConfig) []testLabel {
+	labels := []testLabel{{Dir: "tests", Label: "unit"}}
+	var dirs []string
+	byDir := make(map[string]string)
+	for dir, label := range config.Testing.Labels {
+		dir = strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(dir), "./"), "/")
+		if label == "" || dir == "" {
+			continue
+		}
+		if dir == "tests" {
+			labels[0].Label = label
+			continue
+		}
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = label
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		labels = append(labels, testLabel{Dir: dir, Label: byDir[dir]})
+	}
+	return labels
 }
 
 // printVersionChangeMessage prints a formatted message when version changes
@@ -1693,6 +6778,71 @@ func touchCMakeCache(buildDir string) {
 	}
 }
 
+// readCMakeCacheVar reads a variable from <buildDir>/CMakeCache.txt.
+// Returns the value and whether it was found.
+func readCMakeCacheVar(buildDir, name string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(buildDir, "CMakeCache.txt"))
+	if err != nil {
+		return "", false
+	}
+
+	// Entries look like: CMAKE_CXX_COMPILER:FILEPATH=/usr/bin/c++
+	prefix := name + ":"
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		if idx := strings.Index(line, "="); idx >= 0 {
+			return strings.TrimSpace(line[idx+1:]), true
+		}
+	}
+	return "", false
+}
+
+// compilerChanged reports whether the requested compiler differs from the one
+// recorded in the CMake cache. Returns false when nothing was requested or no cache exists.
+func compilerChanged(buildDir, cacheVar, requested string) (bool, string) {
+	if requested == "" {
+		return false, ""
+	}
+	cached, ok := readCMakeCacheVar(buildDir, cacheVar)
+	if !ok || cached == "" {
+		return false, ""
+	}
+
+	resolve := func(path string) string {
+		if resolved, err := exec.LookPath(path); err == nil {
+			path = resolved
+		}
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		return path
+	}
+
+	if cached == requested || resolve(cached) == resolve(requested) {
+		return false, cached
+	}
+	return true, cached
+}
+
+// profileBuildType determines the build type and CXX flags for forge build.
+// build.build_type and build.cxx_flags, with the profile merged over them, only
+// apply when a profile is selected; --release, --debug and -O win over them.
+func profileBuildType(config *ForgeConfig, profile string, release, debug bool, optLevel string) (string, string) {
+	buildType, cxxFlags := determineBuildType(release, optLevel)
+	if profile == "" {
+		return buildType, cxxFlags
+	}
+	if !release && !debug && optLevel == "" && config.Build.BuildType != "" {
+		buildType = config.Build.BuildType
+	}
+	if config.Build.CxxFlags != "" {
+		cxxFlags = strings.TrimSpace(config.Build.CxxFlags + " " + cxxFlags)
+	}
+	return buildType, cxxFlags
+}
+
 // determineBuildType determines the CMake build type and CXX flags based on release flag and optimization level.
 // Returns (buildType, cxxFlags)
 func determineBuildType(release bool, optLevel string) (string, string) {
@@ -1733,14 +6883,15 @@ func determineBuildType(release bool, optLevel string) (string, string) {
 func updateVersionHppIfNeeded(config *ForgeConfig) (bool, error) {
 	yamlVersion := getVersionFromConfig(config)
 	projectName := getProjectNameFromConfig(config)
+	namespace := getNamespaceFromConfig(config)
 
-	versionHppPath := filepath.Join("include", projectName, "version.hpp")
+	versionHppPath := filepath.Join(getIncludeDirsFromConfig(config).Public[0], projectName, "version.hpp")
 
 	// Read current version from version.hpp if it exists
 	currentVersion := ""
 	if data, err := os.ReadFile(versionHppPath); err == nil {
-		// Extract version from: #define PROJECT_NAME_VERSION "1.0.0"
-		projectNameUpper := strings.ToUpper(projectName)
+		// Extract version from: #define NAMESPACE_VERSION "1.0.0"
+		projectNameUpper := namespaceMacroPrefix(namespace)
 		re := regexp.MustCompile(fmt.Sprintf(`#define\s+%s_VERSION\s+"([^"]+)"`, regexp.QuoteMeta(projectNameUpper)))
 		matches := re.FindStringSubmatch(string(data))
 		if len(matches) > 1 {
@@ -1754,7 +6905,7 @@ func updateVersionHppIfNeeded(config *ForgeConfig) (bool, error) {
 	}
 
 	// Generate version.hpp directly
-	versionHpp := generateVersionHpp(projectName, yamlVersion)
+	versionHpp := generateVersionHpp(namespace, yamlVersion)
 
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(versionHppPath), 0755); err != nil {
@@ -1842,7 +6993,7 @@ func updateCMakeSettingsIfNeeded(config *ForgeConfig) bool {
 	}
 
 	// Update clang-format
-	if clangUpdated, err := updateClangFormatIfNeeded(config); err != nil {
+	if clangUpdated, err := updateClangFormatIfNeeded(config, ".", false); err != nil {
 		fmt.Printf("%s⚠️  Warning: Could not update clang-format: %v%s\n", Yellow, err, Reset)
 	} else if clangUpdated {
 		updated = true
@@ -1858,6 +7009,9 @@ func updateCppStandardIfNeeded(config *ForgeConfig) (bool, error) {
 	if yamlCppStandard == 0 {
 		yamlCppStandard = 17 // default
 	}
+	if err := validateCppStandard(yamlCppStandard); err != nil {
+		return false, err
+	}
 
 	cmakeListsPath := "CMakeLists.txt"
 	data, err := os.ReadFile(cmakeListsPath)
@@ -1897,6 +7051,46 @@ func updateCppStandardIfNeeded(config *ForgeConfig) (bool, error) {
 	return false, nil
 }
 
+// validateCppStandard rejects cpp_standard values not in knownCppStandards
+func validateCppStandard(std int) error {
+	for _, known := range knownCppStandards {
+		if known == std {
+			return nil
+		}
+	}
+	var names []string
+	for _, known := range knownCppStandards {
+		names = append(names, fmt.Sprintf("%d", known))
+	}
+	return fmt.Errorf("unsupported cpp_standard %d (supported: %s)", std, strings.Join(names, ", "))
+}
+
+// warnIfCppStandardUnsupported warns when the installed CMake predates the
+// requested standard, instead of letting CMake silently fall back to an older one
+func warnIfCppStandardUnsupported(std int) {
+	minVersion, ok := minCMakeForCppStandard[std]
+	if !ok {
+		return
+	}
+
+	out, err := exec.Command("cmake", "--version").Output()
+	if err != nil {
+		return
+	}
+	matches := regexp.MustCompile(`cmake version (\d+)\.(\d+)`).FindStringSubmatch(string(out))
+	if len(matches) < 3 {
+		return
+	}
+
+	var haveMajor, haveMinor, needMajor, needMinor int
+	fmt.Sscanf(matches[1]+"."+matches[2], "%d.%d", &haveMajor, &haveMinor)
+	fmt.Sscanf(minVersion, "%d.%d", &needMajor, &needMinor)
+	if haveMajor < needMajor || (haveMajor == needMajor && haveMinor < needMinor) {
+		fmt.Printf("%s⚠️  C++%d needs CMake %s or newer (found %s.%s); your compiler may not support it either%s\n",
+			Yellow, std, minVersion, matches[1], matches[2], Reset)
+	}
+}
+
 // updateSharedLibsIfNeeded updates BUILD_SHARED_LIBS option in CMakeLists.txt if it changed.
 // Returns true if updated.
 func updateSharedLibsIfNeeded(config *ForgeConfig) (bool, error) {
@@ -1944,21 +7138,55 @@ func updateSharedLibsIfNeeded(config *ForgeConfig) (bool, error) {
 	return false, nil
 }
 
-// updateClangFormatIfNeeded updates .clang-format file if clang_format style changed.
+// updateClangFormatIfNeeded brings .clang-format in line with build.clang_format and
+// build.clang_format_overrides. A file forge generated is only regenerated when those
+// settings changed, so manual edits survive; a hand-written one only gets its
+// BasedOnStyle updated. A missing file is written when create is set.
 // Returns true if updated.
-func updateClangFormatIfNeeded(config *ForgeConfig) (bool, error) {
+func updateClangFormatIfNeeded(config *ForgeConfig, dir string, create bool) (bool, error) {
 	yamlClangFormat := config.Build.ClangFormat
 	if yamlClangFormat == "" {
 		yamlClangFormat = "Google" // default
 	}
+	overrides := config.Build.ClangFormatOverrides
 
-	clangFormatPath := ".clang-format"
+	clangFormatPath := filepath.Join(dir, ".clang-format")
 	data, err := os.ReadFile(clangFormatPath)
-	if err != nil {
+	if os.IsNotExist(err) && !create {
 		return false, nil // File doesn't exist, nothing to update
+	} else if err != nil && !os.IsNotExist(err) {
+		return false, err
 	}
 
 	clangFormatContent := string(data)
+	digest := clangFormatDigest(yamlClangFormat, overrides)
+
+	if err == nil {
+		if m := clangFormatHeaderRegex.FindStringSubmatch(clangFormatContent); m == nil {
+			return updateClangFormatStyle(clangFormatPath, clangFormatContent, yamlClangFormat, len(overrides) > 0)
+		} else if m[1] == digest {
+			return false, nil
+		}
+	}
+
+	content, err := generateClangFormat(yamlClangFormat, overrides)
+	if err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(clangFormatPath, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("failed to write .clang-format: %w", err)
+	}
+	if clangFormatContent != "" {
+		fmt.Printf("%s🔄 Clang-format settings changed, regenerated .clang-format%s\n", Cyan, Reset)
+	}
+	return true, nil
+}
+
+// updateClangFormatStyle updates the BasedOnStyle line of a hand-written .clang-format
+func updateClangFormatStyle(clangFormatPath, clangFormatContent, yamlClangFormat string, hasOverrides bool) (bool, error) {
+	if hasOverrides {
+		fmt.Printf("%s⚠️  .clang-format isn't managed by forge, so clang_format_overrides are not applied (remove it to regenerate)%s\n", Yellow, Reset)
+	}
 
 	// Extract current style from: BasedOnStyle: Google
 	re := regexp.MustCompile(`BasedOnStyle:\s*(\w+)`)
@@ -2058,7 +7286,7 @@ func updateTestCMakeIfNeeded(config *ForgeConfig) (bool, error) {
 	// Regenerate tests/CMakeLists.txt
 	projectName := getProjectNameFromConfig(config)
 	libraryIDs := getLibraryIDsFromConfig(config)
-	newTestCMake := generateTestCMake(projectName, libraryIDs, yamlFramework)
+	newTestCMake := generateTestCMake(projectName, libraryIDs, yamlFramework, getTestLabelsFromConfig(config), getIncludeDirsFromConfig(config), config.Testing.PerSource)
 
 	if err := os.WriteFile(testCMakePath, []byte(newTestCMake), 0644); err != nil {
 		return false, fmt.Errorf("failed to write tests/CMakeLists.txt: %w", err)
@@ -2112,7 +7340,7 @@ func updateTestMainIfNeeded(config *ForgeConfig) (bool, error) {
 	// Regenerate tests/test_main.cpp
 	projectName := getProjectNameFromConfig(config)
 	libraryIDs := getLibraryIDsFromConfig(config)
-	newTestMain := generateTestMain(projectName, libraryIDs, yamlFramework)
+	newTestMain := generateTestMain(projectName, getNamespaceFromConfig(config), libraryIDs, yamlFramework)
 
 	if err := os.WriteFile(testMainPath, []byte(newTestMain), 0644); err != nil {
 		return false, fmt.Errorf("failed to write tests/test_main.cpp: %w", err)
@@ -2286,33 +7514,144 @@ func getLibraryIDsFromConfig(config *ForgeConfig) []string {
 	return libraryIDs
 }
 
+// resolveFeatures merges the dependencies of each enabled feature (comma-separated)
+// into config.Dependencies. Returns the enabled feature names in order.
+func resolveFeatures(config *ForgeConfig, features string) ([]string, error) {
+	// registry.features applies when no features are requested explicitly
+	if features == "" {
+		features = strings.Join(config.Registry.Features, ",")
+	}
+
+	var enabled []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(features, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		featureConfig, ok := config.Features[name]
+		if !ok {
+			available := make([]string, 0, len(config.Features))
+			for f := range config.Features {
+				available = append(available, f)
+			}
+			sort.Strings(available)
+			if len(available) == 0 {
+				return nil, fmt.Errorf("unknown feature '%s': no features defined in forge.yaml", name)
+			}
+			return nil, fmt.Errorf("unknown feature '%s' (available: %s)", name, strings.Join(available, ", "))
+		}
+
+		if config.Dependencies == nil {
+			config.Dependencies = make(map[string]map[string]interface{})
+		}
+		for libID, options := range featureConfig.Dependencies {
+			// Options declared in the main dependencies take precedence
+			if _, exists := config.Dependencies[libID]; exists {
+				continue
+			}
+			if options == nil {
+				options = make(map[string]interface{})
+			}
+			config.Dependencies[libID] = options
+		}
+		for _, define := range featureConfig.Defines {
+			config.Build.FeatureDefines = append(config.Build.FeatureDefines, strings.TrimPrefix(define, "-D"))
+		}
+		config.Build.FeatureCxxFlags = append(config.Build.FeatureCxxFlags, strings.Fields(featureConfig.CxxFlags)...)
+		enabled = append(enabled, name)
+	}
+	return enabled, nil
+}
+
 func saveConfig(config *ForgeConfig) error {
 	data, err := yaml.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	// Write back in the format the manifest was read in
+	configFile := resolveConfigPath(DefaultCfgFile)
+	data, err = configFromYAML(configFile, data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
 	// Add header comment
-	header := "# forge.yaml - C++ Project Dependencies\n# Like Cargo.toml for Rust, but for C++!\n\n"
+	header := fmt.Sprintf("# %s - C++ Project Dependencies\n# Like Cargo.toml for Rust, but for C++!\n\n", filepath.Base(configFile))
 	data = append([]byte(header), data...)
 
-	if err := os.WriteFile(DefaultCfgFile, data, 0644); err != nil {
+	if err := os.WriteFile(configFile, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 
 	return nil
 }
 
+// serverRetries is how many times transient server errors are retried (--retries, --no-retry)
+var serverRetries = 3
+
+// serverRetryBackoff is the wait before the first retry; it doubles on each further one
+var serverRetryBackoff = 500 * time.Millisecond
+
+// addRetryFlags registers --retries and --no-retry on a command that talks to the server
+func addRetryFlags(fs *flag.FlagSet) {
+	fs.IntVar(&serverRetries, "retries", 3, "Retries for connection errors and 5xx responses")
+	fs.BoolFunc("no-retry", "Don't retry failed server requests", func(string) error {
+		serverRetries = 0
+		return nil
+	})
+}
+
+// doServerRequest sends a request built by newRequest, retrying with exponential
+// backoff on connection errors and 5xx responses. 4xx responses are never retried.
+func doServerRequest(newRequest func() (*http.Request, error)) (*http.Response, error) {
+	client := &http.Client{}
+	backoff := serverRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if (err == nil && resp.StatusCode < 500) || attempt > serverRetries {
+			return resp, withExitCode(ExitNetwork, err)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		fmt.Fprintf(os.Stderr, "%s⚠️  Server request failed, retrying (%d/%d)...%s\n", Yellow, attempt, serverRetries, Reset)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// getAllLibraries fetches every recipe from the server and caches them in
+// ~/.forge/recipes.json; when the server can't be reached the cache is used
 func getAllLibraries(serverURL string) ([]Library, error) {
 	url := fmt.Sprintf("%s/api/libraries", serverURL)
-	resp, err := http.Get(url)
+	resp, err := doServerRequest(func() (*http.Request, error) {
+		return http.NewRequest("GET", url, nil)
+	})
 	if err != nil {
+		if cached, fetchedAt, ok := loadCachedLibraries(serverURL); ok {
+			fmt.Fprintf(os.Stderr, "%s📦 Server unreachable, using recipes cached %s (cached)%s\n", Yellow, fetchedAt.Local().Format("2006-01-02 15:04"), Reset)
+			return cached, nil
+		}
 		return nil, fmt.Errorf("failed to connect to server: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if err := checkServerResponse(resp, "JSON"); err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server error: %d", resp.StatusCode)
+		return nil, withExitCode(ExitNetwork, fmt.Errorf("server error: %d", resp.StatusCode))
 	}
 
 	var result struct {
@@ -2323,9 +7662,121 @@ func getAllLibraries(serverURL string) ([]Library, error) {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	saveCachedLibraries(serverURL, result.Libraries)
 	return result.Libraries, nil
 }
 
+// recipeCache is ~/.forge/recipes.json: the last recipe list fetched from each server
+type recipeCache struct {
+	Servers map[string]cachedRecipes `json:"servers"`
+}
+
+type cachedRecipes struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Libraries []Library `json:"libraries"`
+}
+
+func recipeCachePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".forge", "recipes.json"), nil
+}
+
+func readRecipeCache() recipeCache {
+	cache := recipeCache{Servers: make(map[string]cachedRecipes)}
+	path, err := recipeCachePath()
+	if err != nil {
+		return cache
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	if cache.Servers == nil {
+		cache.Servers = make(map[string]cachedRecipes)
+	}
+	return cache
+}
+
+// loadCachedLibraries returns the recipes last fetched from serverURL
+func loadCachedLibraries(serverURL string) ([]Library, time.Time, bool) {
+	cached, ok := readRecipeCache().Servers[strings.TrimSuffix(serverURL, "/")]
+	if !ok || len(cached.Libraries) == 0 {
+		return nil, time.Time{}, false
+	}
+	return cached.Libraries, cached.FetchedAt, true
+}
+
+// saveCachedLibraries records libs for offline use; failures only cost the cache
+func saveCachedLibraries(serverURL string, libs []Library) {
+	path, err := recipeCachePath()
+	if err != nil {
+		return
+	}
+	cache := readRecipeCache()
+	cache.Servers[strings.TrimSuffix(serverURL, "/")] = cachedRecipes{FetchedAt: time.Now().UTC(), Libraries: libs}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err == nil {
+		os.Rename(tmp, path)
+	}
+}
+
+// checkServerResponse rejects HTML responses, which almost always mean --server
+// points at the wrong host (a proxy login page, a static site, ...)
+func checkServerResponse(resp *http.Response, expected string) error {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+	return withExitCode(ExitNetwork, fmt.Errorf("expected %s from %s, got text/html — is the server URL correct?\n\n%s",
+		expected, resp.Request.URL.String(), strings.TrimSpace(string(snippet))))
+}
+
+// validateTestingFramework checks testing.framework names a testing recipe on
+// the server or in .forge/recipes, so a typo fails here instead of at build time
+func validateTestingFramework(serverURL, framework string) error {
+	if framework == "" || framework == "none" {
+		return nil
+	}
+	libs, err := getAllLibraries(serverURL)
+	if err != nil {
+		return err
+	}
+	local, err := loadLocalRecipes(".")
+	if err != nil {
+		return err
+	}
+
+	valid := []string{}
+	for _, lib := range libs {
+		if _, overridden := local[lib.ID]; lib.Category == "testing" && !overridden {
+			valid = append(valid, lib.ID)
+		}
+	}
+	for id, recipe := range local {
+		if category, _ := recipe["category"].(string); category == "testing" {
+			valid = append(valid, id)
+		}
+	}
+	for _, id := range valid {
+		if id == framework {
+			return nil
+		}
+	}
+	sort.Strings(valid)
+	valid = append(valid, "none")
+	return withExitCode(ExitConfig, fmt.Errorf("unknown testing.framework '%s' (valid: %s)", framework, strings.Join(valid, ", ")))
+}
+
 func getLibraryInfo(serverURL, libID string) (*Library, error) {
 	libs, err := getAllLibraries(serverURL)
 	if err != nil {
@@ -2341,19 +7792,103 @@ func getLibraryInfo(serverURL, libID string) (*Library, error) {
 	return nil, fmt.Errorf("library not found")
 }
 
-func generateLockFile(config ForgeConfig, outputDir string) error {
+// loadLockFile reads and parses forge.lock
+func loadLockFile(path string) (*LockConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock LockConfig
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &lock, nil
+}
+
+func generateLockFile(config ForgeConfig, outputDir string, resolved map[string]LockEntry) error {
+	existing, _ := loadLockFile(filepath.Join(outputDir, LockFile))
+	lock := lockForConfig(config, existing, resolved)
+	return saveLockFile(&lock, outputDir)
+}
+
+// lockForConfig returns the forge.lock generate writes for config. Entries recorded
+// by forge add are kept while they still match what dependencies.cmake fetches;
+// otherwise the resolved source is recorded, without a specific commit.
+func lockForConfig(config ForgeConfig, existing *LockConfig, resolved map[string]LockEntry) LockConfig {
 	lock := LockConfig{
 		Version:      1,
 		Dependencies: make(map[string]LockEntry),
 	}
 
-	// For now, just record the dependencies without specific commits
-	for libID := range config.Dependencies {
-		lock.Dependencies[libID] = LockEntry{
-			Tag: "latest",
+	for _, deps := range []map[string]map[string]interface{}{config.Dependencies, config.DevDependencies} {
+		for libID := range deps {
+			entry, isResolved := resolved[libID]
+			if existing != nil {
+				if old, ok := existing.Dependencies[libID]; ok && (!isResolved || (old.Git == entry.Git && old.Tag == entry.Tag)) {
+					lock.Dependencies[libID] = old
+					continue
+				}
+			}
+			if !isResolved {
+				entry = LockEntry{Tag: "latest"}
+			}
+			lock.Dependencies[libID] = entry
+		}
+	}
+
+	return lock
+}
+
+// lockDifferences describes how want differs from the lock on disk, sorted by library
+func lockDifferences(have *LockConfig, want LockConfig) []string {
+	var diffs []string
+	for name, entry := range want.Dependencies {
+		old, ok := have.Dependencies[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("%s: not locked", name))
+		case old != entry:
+			diffs = append(diffs, fmt.Sprintf("%s: locked %s, would be %s", name, old.Tag, entry.Tag))
+		}
+	}
+	for name := range have.Dependencies {
+		if _, ok := want.Dependencies[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: locked but no longer a dependency", name))
 		}
 	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// checkLocked enforces --locked: forge.lock must exist, be at least as new as the
+// manifest, and match both the manifest's dependencies and dependencies.cmake
+func checkLocked(config *ForgeConfig) error {
+	lockInfo, err := os.Stat(LockFile)
+	if err != nil {
+		return withExitCode(ExitConfig, fmt.Errorf("--locked: %s not found, run 'forge generate' first", LockFile))
+	}
+	configFile := resolveConfigPath(DefaultCfgFile)
+	if configInfo, err := os.Stat(configFile); err == nil && configInfo.ModTime().After(lockInfo.ModTime()) {
+		return withExitCode(ExitConfig, fmt.Errorf("--locked: %s is newer than %s, run 'forge generate' to update it", configFile, LockFile))
+	}
+
+	lock, err := loadLockFile(LockFile)
+	if err != nil {
+		return withExitCode(ExitConfig, err)
+	}
+	resolved := map[string]LockEntry{}
+	if data, err := os.ReadFile(filepath.Join(".cmake", "forge", "dependencies.cmake")); err == nil {
+		resolved = resolvedDependencies(string(data))
+	}
+	if diffs := lockDifferences(lock, lockForConfig(*config, lock, resolved)); len(diffs) > 0 {
+		return withExitCode(ExitConfig, fmt.Errorf("--locked: %s is out of date:\n  %s", LockFile, strings.Join(diffs, "\n  ")))
+	}
+	return nil
+}
 
+// saveLockFile writes forge.lock with its header
+func saveLockFile(lock *LockConfig, outputDir string) error {
 	data, err := yaml.Marshal(lock)
 	if err != nil {
 		return err
@@ -2365,53 +7900,96 @@ func generateLockFile(config ForgeConfig, outputDir string) error {
 	return os.WriteFile(filepath.Join(outputDir, LockFile), data, 0644)
 }
 
-func extractZip(data []byte, outputDir string) error {
-	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+// updateLockFile applies fn to forge.lock in the current directory, creating it if missing
+func updateLockFile(fn func(lock *LockConfig)) error {
+	lock, err := loadLockFile(LockFile)
 	if err != nil {
-		return err
+		if !os.IsNotExist(err) {
+			return err
+		}
+		lock = &LockConfig{Version: 1}
+	}
+	if lock.Dependencies == nil {
+		lock.Dependencies = make(map[string]LockEntry)
 	}
 
-	absOutputDir, err := filepath.Abs(outputDir)
-	if err != nil {
-		return err
+	fn(lock)
+	return saveLockFile(lock, ".")
+}
+
+// lockEntryForLibrary records the recipe's current FetchContent source
+func lockEntryForLibrary(lib *Library) LockEntry {
+	entry := LockEntry{
+		Git:    lib.FetchContent["repository"],
+		Tag:    lib.FetchContent["tag"],
+		Commit: lib.FetchContent["commit"],
 	}
+	if entry.Tag == "" {
+		entry.Tag = "latest"
+	}
+	return entry
+}
 
-	for _, file := range reader.File {
-		path := filepath.Join(outputDir, file.Name)
-		absPath, err := filepath.Abs(path)
-		if err != nil {
+// fetchContentRegex matches the FetchContent_Declare blocks the server emits in dependencies.cmake
+var fetchContentRegex = regexp.MustCompile(`FetchContent_Declare\(\s*(\S+)\s+GIT_REPOSITORY\s+(\S+)\s+GIT_TAG\s+(\S+)`)
+
+// resolvedDependencies returns the repository and tag dependencies.cmake fetches
+// for each library ID. The README and forge.lock both use it so they agree.
+func resolvedDependencies(dependenciesCMake string) map[string]LockEntry {
+	resolved := make(map[string]LockEntry)
+	for _, m := range fetchContentRegex.FindAllStringSubmatch(dependenciesCMake, -1) {
+		resolved[m[1]] = LockEntry{Git: m[2], Tag: m[3]}
+	}
+	return resolved
+}
+
+// stampDependenciesCMake records the forge version and generation time in the header
+func stampDependenciesCMake(content string) string {
+	stamp := fmt.Sprintf("# Forge version: %s\n# Generated at: %s\n", Version, time.Now().UTC().Format(time.RFC3339))
+	const title = "# dependencies.cmake - Generated by Forge\n"
+	if i := strings.Index(content, title); i >= 0 {
+		i += len(title)
+		return content[:i] + stamp + content[i:]
+	}
+	return stamp + content
+}
+
+// writeZip writes the files under dir to w as a ZIP archive
+func writeZip(w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
 			return err
 		}
-
-		if !strings.HasPrefix(absPath, absOutputDir) {
-			return fmt.Errorf("invalid file path: %s", file.Name)
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
 		}
-
-		if file.FileInfo().IsDir() {
-			os.MkdirAll(path, 0755)
-			continue
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
 		}
+		header.Name = filepath.ToSlash(rel)
+		header.Method = zip.Deflate
 
-		os.MkdirAll(filepath.Dir(path), 0755)
-
-		outFile, err := os.Create(path)
+		fw, err := zw.CreateHeader(header)
 		if err != nil {
 			return err
 		}
-
-		rc, err := file.Open()
+		f, err := os.Open(path)
 		if err != nil {
-			outFile.Close()
 			return err
 		}
-
-		io.Copy(outFile, rc)
-		rc.Close()
-		outFile.Close()
-
-		fmt.Printf("   📄 %s\n", file.Name)
+		defer f.Close()
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write ZIP: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write ZIP: %w", err)
 	}
-
 	return nil
 }
 
@@ -2426,7 +8004,7 @@ func cmdUpgrade(args []string) {
 	resp, err := http.Get("https://api.github.com/repos/ozacod/forge/releases/latest")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%sError:%s Failed to check for updates: %v\n", Red, Reset, err)
-		os.Exit(1)
+		os.Exit(ExitNetwork)
 	}
 	defer resp.Body.Close()
 
@@ -2449,6 +8027,20 @@ func cmdUpgrade(args []string) {
 
 	fmt.Printf("%s📦 New version available: %s → %s%s\n", Yellow, currentVersion, latestVersion, Reset)
 
+	// Get current executable path
+	execPath, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError:%s Failed to get executable path: %v\n", Red, Reset, err)
+		os.Exit(1)
+	}
+	execPath, _ = filepath.EvalSymlinks(execPath)
+
+	// Package-manager installs and read-only locations must not be replaced in place
+	if manager := packageManagerFor(execPath); manager != "" || !dirWritable(filepath.Dir(execPath)) {
+		printUpgradeInstructions(execPath, manager, release.HTMLURL)
+		return
+	}
+
 	// Determine platform and architecture
 	goos := runtime.GOOS
 	goarch := runtime.GOARCH
@@ -2473,13 +8065,13 @@ func cmdUpgrade(args []string) {
 	resp, err = http.Get(downloadURL)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%sError:%s Failed to download: %v\n", Red, Reset, err)
-		os.Exit(1)
+		os.Exit(ExitNetwork)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		fmt.Fprintf(os.Stderr, "%sError:%s Download failed with status %d\n", Red, Reset, resp.StatusCode)
-		os.Exit(1)
+		os.Exit(ExitNetwork)
 	}
 
 	binaryData, err := io.ReadAll(resp.Body)
@@ -2488,42 +8080,163 @@ func cmdUpgrade(args []string) {
 		os.Exit(1)
 	}
 
-	// Get current executable path
-	execPath, err := os.Executable()
+	// Stage next to the target so the final rename stays on one filesystem
+	backupPath, err := replaceExecutable(execPath, binaryData)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to get executable path: %v\n", Red, Reset, err)
-		os.Exit(1)
-	}
-	execPath, _ = filepath.EvalSymlinks(execPath)
-
-	// Write to temp file first
-	tempPath := execPath + ".new"
-	if err := os.WriteFile(tempPath, binaryData, 0755); err != nil {
-		// Try writing to temp directory instead
-		tempPath = filepath.Join(os.TempDir(), "forge-new")
-		if err := os.WriteFile(tempPath, binaryData, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "%sError:%s Failed to write binary: %v\n", Red, Reset, err)
+		// Fall back to the temp directory and let the user move it into place
+		tempPath := filepath.Join(os.TempDir(), "forge-new")
+		if werr := os.WriteFile(tempPath, binaryData, 0755); werr != nil {
+			fmt.Fprintf(os.Stderr, "%sError:%s Failed to replace binary: %v\n", Red, Reset, err)
 			os.Exit(1)
 		}
+		fmt.Fprintf(os.Stderr, "%sError:%s Failed to replace binary: %v\n", Red, Reset, err)
 		fmt.Printf("%s✓ Downloaded to %s%s\n", Green, tempPath, Reset)
 		fmt.Printf("\nTo complete the upgrade, run:\n")
 		fmt.Printf("  sudo mv %s %s\n", tempPath, execPath)
-		return
-	}
-
-	// Remove old binary and rename new one
-	os.Remove(execPath)
-	if err := os.Rename(tempPath, execPath); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to replace binary: %v\n", Red, Reset, err)
-		fmt.Printf("\nTo complete manually, run:\n")
-		fmt.Printf("  sudo mv %s %s\n", tempPath, execPath)
 		os.Exit(1)
 	}
 
 	fmt.Printf("%s✓ Successfully upgraded to %s!%s\n", Green, latestVersion, Reset)
 	fmt.Printf("  Run %sforge version%s to verify.\n", Cyan, Reset)
+	fmt.Printf("  Previous version kept at %s (move it back to roll back)\n", backupPath)
+}
+
+// replaceExecutable atomically swaps execPath for data and returns the path of
+// the previous binary kept for rollback (<exe>.old). The new binary is written
+// and fsynced in the same directory so the final rename never crosses devices.
+func replaceExecutable(execPath string, data []byte) (string, error) {
+	dir := filepath.Dir(execPath)
+	tmp, err := os.CreateTemp(dir, ".forge-new-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed into place
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return "", err
+	}
+
+	backupPath := execPath + ".old"
+	os.Remove(backupPath)
+
+	if runtime.GOOS == "windows" {
+		// A running exe can't be overwritten, but it can be renamed out of the way.
+		// The .old file stays until the next upgrade since it's still in use.
+		if err := os.Rename(execPath, backupPath); err != nil {
+			return "", err
+		}
+		if err := os.Rename(tmpPath, execPath); err != nil {
+			os.Rename(backupPath, execPath)
+			return "", err
+		}
+		return backupPath, nil
+	}
+
+	// Keep a hard link (or copy) to the current binary, then rename over it
+	if err := os.Link(execPath, backupPath); err != nil {
+		if err := copyFile(execPath, backupPath); err != nil {
+			return "", fmt.Errorf("failed to back up current binary: %w", err)
+		}
+	}
+	if err := os.Rename(tmpPath, execPath); err != nil {
+		os.Remove(backupPath)
+		return "", err
+	}
+	syncDir(dir)
+	return backupPath, nil
+}
+
+// copyFile copies src to dst, preserving the file mode
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// syncDir flushes a directory entry change (best effort)
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// homebrewPrefixes are the Cellar locations of Homebrew (macOS Intel/ARM, Linuxbrew)
+var homebrewPrefixes = []string{"/usr/local/Cellar/", "/opt/homebrew/", "/home/linuxbrew/.linuxbrew/"}
+
+// packageManagerFor returns "homebrew" or "system" when execPath is managed by a package manager
+func packageManagerFor(execPath string) string {
+	for _, prefix := range homebrewPrefixes {
+		if strings.HasPrefix(execPath, prefix) {
+			return "homebrew"
+		}
+	}
+	if runtime.GOOS == "linux" && strings.HasPrefix(execPath, "/usr/bin/") {
+		return "system"
+	}
+	return ""
+}
+
+// dirWritable reports whether a file can be created in dir
+func dirWritable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".forge-upgrade-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+// printUpgradeInstructions explains how to upgrade an install forge cannot replace itself
+func printUpgradeInstructions(execPath, manager, releaseURL string) {
+	switch manager {
+	case "homebrew":
+		fmt.Printf("%sℹ forge was installed with Homebrew (%s)%s\n", Cyan, execPath, Reset)
+		fmt.Printf("\nTo upgrade, run:\n")
+		fmt.Printf("  %sbrew upgrade forge%s\n", Cyan, Reset)
+	case "system":
+		fmt.Printf("%sℹ forge is managed by your system package manager (%s)%s\n", Cyan, execPath, Reset)
+		fmt.Printf("\nUpgrade it with your package manager, e.g.:\n")
+		fmt.Printf("  %ssudo apt update && sudo apt install --only-upgrade forge%s\n", Cyan, Reset)
+	default:
+		fmt.Printf("%s⚠ %s is not writable, so forge can't replace itself%s\n", Yellow, filepath.Dir(execPath), Reset)
+		fmt.Printf("\nTo upgrade, rerun the install script:\n")
+		fmt.Printf("  %ssh -c \"$(curl -fsSL %s)\"%s\n", Cyan, installScriptURL, Reset)
+		fmt.Printf("\nOr install to a writable directory with %sFORGE_INSTALL_DIR=~/.local/bin%s\n", Cyan, Reset)
+	}
+	if releaseURL != "" {
+		fmt.Printf("\nRelease notes: %s\n", releaseURL)
+	}
 }
 
 // Unused but kept for potential future use
-var _ = bufio.Reader{}
 var _ = sort.Strings
@@ -0,0 +1,1035 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// chdirTemp runs the test from a fresh directory holding files (path -> content)
+func chdirTemp(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// captureStdout returns what fn writes to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-out
+}
+
+// withStdin feeds input to os.Stdin for the rest of the test
+func withStdin(t *testing.T, input string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		r.Close()
+	})
+}
+
+func TestRemoveDependencyAbortExitsWithUsageError(t *testing.T) {
+	const manifest = "package:\n  name: demo\ndependencies:\n  fmt: {}\n"
+	for _, tt := range []struct {
+		name  string
+		input string
+	}{
+		{name: "answered no", input: "n\n"},
+		{name: "stdin closed", input: ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			chdirTemp(t, map[string]string{
+				DefaultCfgFile: manifest,
+				"src/main.cpp": "#include <fmt/core.h>\n",
+			})
+			withStdin(t, tt.input)
+			var sent ForgeConfig
+			server := dependenciesServer(t, &sent)
+
+			err := removeDependency(server.URL, "fmt", false, false)
+			if err == nil {
+				t.Fatal("aborted remove returned no error")
+			}
+			if code := exitCode(err); code != ExitUsage {
+				t.Errorf("exit code = %d, want %d", code, ExitUsage)
+			}
+			data, _ := os.ReadFile(DefaultCfgFile)
+			if string(data) != manifest {
+				t.Errorf("forge.yaml changed after abort:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestRemoveDependencyConfirmed(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	chdirTemp(t, map[string]string{
+		DefaultCfgFile: "package:\n  name: demo\ndependencies:\n  fmt: {}\n",
+		"src/main.cpp": "#include <fmt/core.h>\n",
+	})
+	withStdin(t, "y\n")
+	var sent ForgeConfig
+	server := dependenciesServer(t, &sent)
+
+	if err := removeDependency(server.URL, "fmt", false, false); err != nil {
+		t.Fatalf("removeDependency: %v", err)
+	}
+	data, _ := os.ReadFile(DefaultCfgFile)
+	if strings.Contains(string(data), "fmt") {
+		t.Errorf("fmt still in forge.yaml:\n%s", data)
+	}
+}
+
+func TestRemoveDependencyUsesRecipeIncludes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	chdirTemp(t, map[string]string{
+		DefaultCfgFile: "package:\n  name: demo\nfeatures:\n  cli:\n    dependencies:\n      cli11: {}\n",
+		"src/main.cpp": "#include <fmt/core.h>\n#include <CLI/CLI.hpp>\n",
+	})
+	var sent ForgeConfig
+	server := dependenciesServer(t, &sent)
+
+	out := captureStdout(t, func() {
+		if err := removeDependency(server.URL, "cli11", true, false); err != nil {
+			t.Fatalf("removeDependency: %v", err)
+		}
+	})
+	for _, want := range []string{"feature 'cli'", "src/main.cpp:2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "src/main.cpp:1") {
+		t.Errorf("fmt include reported as a use of cli11:\n%s", out)
+	}
+
+	withStdin(t, "y\n")
+	if err := removeDependency(server.URL, "cli11", false, false); err != nil {
+		t.Fatalf("removeDependency: %v", err)
+	}
+	if data, _ := os.ReadFile(DefaultCfgFile); strings.Contains(string(data), "cli11") {
+		t.Errorf("cli11 still in forge.yaml:\n%s", data)
+	}
+}
+
+func TestRunNewWizardStdinClosedAborts(t *testing.T) {
+	for _, input := range []string{"", "demo\n", "demo\nlib\n17\n"} {
+		withStdin(t, input)
+		_, _, err := runNewWizard("", "demo", false)
+		if err == nil {
+			t.Fatalf("input %q: wizard finished without answers", input)
+		}
+		if code := exitCode(err); code != ExitUsage {
+			t.Errorf("input %q: exit code = %d, want %d", input, code, ExitUsage)
+		}
+	}
+}
+
+func TestRunNewWizard(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"libraries":[{"id":"fmt"},{"id":"spdlog"}]}`))
+	}))
+	defer server.Close()
+	withStdin(t, "tool\nlib\n20\ncatch2\nspdlog, fmt\n")
+
+	name, content, err := runNewWizard(server.URL, "demo", false)
+	if err != nil {
+		t.Fatalf("runNewWizard: %v", err)
+	}
+	if name != "tool" {
+		t.Errorf("name = %q, want tool", name)
+	}
+	config := testConfig(t, content)
+	if config.Package.CppStandard != 20 || !config.Build.SharedLibs || config.Testing.Framework != "catch2" {
+		t.Errorf("unexpected forge.yaml:\n%s", content)
+	}
+	if _, ok := config.Dependencies["fmt"]; !ok {
+		t.Errorf("fmt missing from dependencies:\n%s", content)
+	}
+}
+
+func TestListenDocs(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		loopback bool
+		url      string
+	}{
+		{name: "default host", host: "127.0.0.1", loopback: true, url: "http://localhost:"},
+		{name: "every interface", host: "0.0.0.0", loopback: false, url: "http://localhost:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, url, err := listenDocs(tt.host, "0")
+			if err != nil {
+				t.Fatalf("listenDocs: %v", err)
+			}
+			defer listener.Close()
+			if ip := listener.Addr().(*net.TCPAddr).IP; ip.IsLoopback() != tt.loopback {
+				t.Errorf("listening on %s, loopback = %t, want %t", ip, ip.IsLoopback(), tt.loopback)
+			}
+			if !strings.HasPrefix(url, tt.url) {
+				t.Errorf("url = %q, want prefix %q", url, tt.url)
+			}
+		})
+	}
+}
+
+func TestProfileBuildType(t *testing.T) {
+	const manifest = `package:
+  name: demo
+build:
+  build_type: RelWithDebInfo
+  cxx_flags: -Wshadow
+profiles:
+  release:
+    build_type: Release
+    cxx_flags: -O3 -DNDEBUG
+  checked:
+    lto: true
+`
+	tests := []struct {
+		name          string
+		profile       string
+		release       bool
+		optLevel      string
+		wantBuildType string
+		wantCxxFlags  string
+	}{
+		{name: "no profile ignores build settings", wantBuildType: "Debug", wantCxxFlags: ""},
+		{name: "no profile with --release", release: true, wantBuildType: "Release", wantCxxFlags: ""},
+		{name: "profile overrides build", profile: "release", wantBuildType: "Release", wantCxxFlags: "-O3 -DNDEBUG"},
+		{name: "profile keeps unset build settings", profile: "checked", wantBuildType: "RelWithDebInfo", wantCxxFlags: "-Wshadow"},
+		{name: "-O wins over the profile build type", profile: "release", optLevel: "s", wantBuildType: "MinSizeRel", wantCxxFlags: "-O3 -DNDEBUG -Os"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig(t, manifest)
+			profile, err := applyProfile(&config, tt.profile)
+			if err != nil {
+				t.Fatalf("applyProfile: %v", err)
+			}
+			buildType, cxxFlags := profileBuildType(&config, profile, tt.release, false, tt.optLevel)
+			if buildType != tt.wantBuildType || cxxFlags != tt.wantCxxFlags {
+				t.Errorf("got (%q, %q), want (%q, %q)", buildType, cxxFlags, tt.wantBuildType, tt.wantCxxFlags)
+			}
+		})
+	}
+}
+
+func TestRunRemoteRejectsOptionHost(t *testing.T) {
+	for _, remote := range []string{"-oProxyCommand=touch pwned", "-e:/tmp/app"} {
+		err := runRemote(remote, "build", nil)
+		if err == nil || exitCode(err) != ExitUsage {
+			t.Errorf("runRemote(%q) = %v, want a usage error", remote, err)
+		}
+	}
+}
+
+func TestRemoteDirCheck(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  int
+	}{
+		{name: "missing", want: 0},
+		{name: "empty", files: map[string]string{}, want: 0},
+		{name: "forge project", files: map[string]string{"forge.yaml": "", "src/main.cpp": ""}, want: 0},
+		{name: "toml project", files: map[string]string{"forge.toml": ""}, want: 0},
+		{name: "home directory", files: map[string]string{".bashrc": "", "notes.txt": ""}, want: remoteDirNotProject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "it's here")
+			if tt.files != nil {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				os.MkdirAll(filepath.Dir(path), 0755)
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := exec.Command("sh", "-c", remoteDirCheck(dir)).Run()
+			code := 0
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				code = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if code != tt.want {
+				t.Errorf("exit status = %d, want %d", code, tt.want)
+			}
+			if _, err := os.Stat(dir); err != nil {
+				t.Errorf("directory not created: %v", err)
+			}
+		})
+	}
+}
+
+// dependenciesServer serves the library list and /api/forge/dependencies,
+// decoding each uploaded manifest into sent
+func dependenciesServer(t *testing.T, sent *ForgeConfig) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/libraries":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"libraries":[{"id":"fmt","name":"fmt"},{"id":"spdlog","name":"spdlog"},{"id":"imgui","name":"imgui"},{"id":"cli11","name":"CLI11","usage_example":"#include <CLI/CLI.hpp>\n\nCLI::App app;\n"}]}`))
+		case "/api/forge/dependencies":
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(file)
+			*sent = ForgeConfig{}
+			if err := yaml.Unmarshal(data, sent); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte("# dependencies\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAddDependencyNoSaveEnablesFeature(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	const manifest = "package:\n  name: demo\ndependencies:\n  fmt: {}\n"
+	chdirTemp(t, map[string]string{DefaultCfgFile: manifest})
+
+	var sent ForgeConfig
+	server := dependenciesServer(t, &sent)
+
+	if err := addDependency(server.URL, "spdlog", false, "logging", true); err != nil {
+		t.Fatalf("addDependency: %v", err)
+	}
+	if _, ok := sent.Dependencies["spdlog"]; !ok {
+		t.Errorf("regenerated without the optional dependency: %v", sent.Dependencies)
+	}
+	if data, _ := os.ReadFile(DefaultCfgFile); string(data) != manifest {
+		t.Errorf("--no-save modified forge.yaml:\n%s", data)
+	}
+}
+
+func TestResolveFeatures(t *testing.T) {
+	const manifest = `package:
+  name: demo
+dependencies:
+  fmt:
+    header_only: true
+features:
+  gui:
+    dependencies:
+      imgui: {}
+      fmt: {}
+  net:
+    dependencies:
+      asio: {}
+`
+	tests := []struct {
+		name     string
+		manifest string
+		features string
+		want     []string
+		wantDeps []string
+		wantErr  string
+	}{
+		{name: "none enabled", manifest: manifest, wantDeps: []string{"fmt"}},
+		{name: "one feature", manifest: manifest, features: "gui", want: []string{"gui"}, wantDeps: []string{"fmt", "imgui"}},
+		{name: "several with spaces and repeats", manifest: manifest, features: "net, gui,net", want: []string{"net", "gui"}, wantDeps: []string{"asio", "fmt", "imgui"}},
+		{name: "registry default", manifest: manifest + "registry:\n  features: [net]\n", want: []string{"net"}, wantDeps: []string{"asio", "fmt"}},
+		{name: "flag overrides registry", manifest: manifest + "registry:\n  features: [net]\n", features: "gui", want: []string{"gui"}, wantDeps: []string{"fmt", "imgui"}},
+		{name: "unknown feature", manifest: manifest, features: "tls", wantErr: "unknown feature 'tls' (available: gui, net)"},
+		{name: "no features defined", manifest: "package:\n  name: demo\n", features: "gui", wantErr: "no features defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig(t, tt.manifest)
+			enabled, err := resolveFeatures(&config, tt.features)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveFeatures: %v", err)
+			}
+			if !reflect.DeepEqual(enabled, tt.want) {
+				t.Errorf("enabled = %v, want %v", enabled, tt.want)
+			}
+			deps := getLibraryIDsFromConfig(&config)
+			sort.Strings(deps)
+			if !reflect.DeepEqual(deps, tt.wantDeps) {
+				t.Errorf("dependencies = %v, want %v", deps, tt.wantDeps)
+			}
+			// Options declared in the main dependencies win over the feature's
+			if config.Dependencies["fmt"]["header_only"] != true {
+				t.Errorf("fmt options = %v, want the main dependency's", config.Dependencies["fmt"])
+			}
+		})
+	}
+}
+
+func TestAddDependencyOptionalWritesFeature(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	chdirTemp(t, map[string]string{DefaultCfgFile: "package:\n  name: demo\ndependencies:\n  fmt: {}\n"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/libraries" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"libraries":[{"id":"fmt","name":"fmt"},{"id":"imgui","name":"imgui"},{"id":"cli11","name":"CLI11","usage_example":"#include <CLI/CLI.hpp>\n\nCLI::App app;\n"}]}`))
+			return
+		}
+		w.Write([]byte("# dependencies\n"))
+	}))
+	defer server.Close()
+
+	if err := addDependency(server.URL, "imgui", false, "gui", false); err != nil {
+		t.Fatalf("addDependency: %v", err)
+	}
+	config, err := loadConfig(DefaultCfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config.Features["gui"].Dependencies["imgui"]; !ok {
+		t.Errorf("imgui not added to features.gui: %v", config.Features)
+	}
+	if _, ok := config.Dependencies["imgui"]; ok {
+		t.Errorf("optional imgui added to the main dependencies")
+	}
+	if err := addDependency(server.URL, "fmt", false, "gui", false); err == nil {
+		t.Errorf("adding a main dependency as optional succeeded")
+	}
+}
+
+func TestLoadConfigIncludeMerge(t *testing.T) {
+	dir := chdirTemp(t, map[string]string{
+		"common/base.yaml": `package:
+  cpp_standard: 17
+  namespace: acme
+dependencies:
+  fmt: {}
+  spdlog:
+    version: "1.12"
+build:
+  shared_libs: true
+  warnings: strict
+testing:
+  framework: googletest
+include:
+  public: [include, shared/include]
+`,
+		"common/compiler.yaml": `build:
+  compiler: clang++
+  warnings: off
+`,
+		"app/forge.yaml": `include: [../common/base.yaml, ../common/compiler.yaml]
+package:
+  name: app
+  cpp_standard: 20
+dependencies:
+  spdlog:
+    options:
+      SPDLOG_FMT_EXTERNAL: true
+build:
+  warnings: standard
+`,
+		"lib/forge.yaml": `include:
+  manifests: [../common/base.yaml]
+  private: [internal]
+package:
+  name: lib
+`,
+	})
+
+	config, err := loadConfig(filepath.Join(dir, "app", "forge.yaml"))
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	// Scalars: local over later includes over earlier ones
+	if config.Package.Name != "app" || config.Package.CppStandard != 20 || config.Package.Namespace != "acme" {
+		t.Errorf("package = %+v", config.Package)
+	}
+	if config.Build.Warnings != "standard" || config.Build.Compiler != "clang++" || !config.Build.SharedLibs {
+		t.Errorf("build = warnings %q, compiler %q, shared_libs %v", config.Build.Warnings, config.Build.Compiler, config.Build.SharedLibs)
+	}
+	// Maps: merged key by key, down into a dependency's options
+	wantDeps := map[string]map[string]interface{}{
+		"fmt":    {},
+		"spdlog": {"version": "1.12", "options": map[string]interface{}{"SPDLOG_FMT_EXTERNAL": true}},
+	}
+	if !reflect.DeepEqual(config.Dependencies, wantDeps) {
+		t.Errorf("dependencies = %v, want %v", config.Dependencies, wantDeps)
+	}
+	if config.Testing.Framework != "googletest" {
+		t.Errorf("testing.framework = %q", config.Testing.Framework)
+	}
+	if want := []string{"include", "shared/include"}; !reflect.DeepEqual(config.Include.Public, want) || len(config.Include.Manifests) != 0 {
+		t.Errorf("include = %+v", config.Include)
+	}
+
+	// include.manifests next to header directories
+	config, err = loadConfig(filepath.Join(dir, "lib", "forge.yaml"))
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if config.Package.Name != "lib" || config.Package.CppStandard != 17 {
+		t.Errorf("package = %+v", config.Package)
+	}
+	if !reflect.DeepEqual(config.Include.Public, []string{"include", "shared/include"}) || !reflect.DeepEqual(config.Include.Private, []string{"internal"}) {
+		t.Errorf("include = %+v", config.Include)
+	}
+}
+
+func TestLoadConfigForEditKeepsIncludeList(t *testing.T) {
+	dir := chdirTemp(t, map[string]string{
+		"forge.yaml": "include: [../common.yaml]\npackage:\n  name: app\n",
+	})
+	config, err := loadConfigForEdit(filepath.Join(dir, "forge.yaml"))
+	if err != nil {
+		t.Fatalf("loadConfigForEdit: %v", err)
+	}
+	if !reflect.DeepEqual(config.Include.Manifests, []string{"../common.yaml"}) {
+		t.Fatalf("include = %+v", config.Include)
+	}
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "include:\n    - ../common.yaml\n") {
+		t.Errorf("include list not written back:\n%s", out)
+	}
+}
+
+func TestLoadConfigIncludeErrors(t *testing.T) {
+	dir := chdirTemp(t, map[string]string{
+		"cycle/forge.yaml":   "include: [a.yaml]\npackage:\n  name: app\n",
+		"cycle/a.yaml":       "include: [b.yaml]\n",
+		"cycle/b.yaml":       "include: [a.yaml]\n",
+		"self/forge.yaml":    "include: [forge.yaml]\n",
+		"missing/forge.yaml": "include: [../nowhere.yaml]\n",
+		"invalid/forge.yaml": "include: [{file: a.yaml}]\n",
+	})
+
+	tests := []struct {
+		name    string
+		wantErr []string
+	}{
+		{name: "cycle", wantErr: []string{"include cycle: ", filepath.Join("cycle", "a.yaml") + " -> ", filepath.Join("cycle", "b.yaml") + " -> ", filepath.Join("cycle", "a.yaml")}},
+		{name: "self", wantErr: []string{"include cycle: ", filepath.Join("self", "forge.yaml") + " -> "}},
+		{name: "missing", wantErr: []string{"failed to read ", "nowhere.yaml (included from "}},
+		{name: "invalid", wantErr: []string{"include must list manifest files"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(filepath.Join(dir, tt.name, "forge.yaml"))
+			if err == nil {
+				t.Fatal("loadConfig succeeded")
+			}
+			if exitCode(err) != ExitConfig {
+				t.Errorf("exit code = %d, want %d", exitCode(err), ExitConfig)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeIncludes(t *testing.T) {
+	dir := chdirTemp(t, map[string]string{
+		"base.yaml":    "package:\n  cpp_standard: 17\n  namespace: acme\ndependencies:\n  spdlog:\n    options:\n      SPDLOG_FMT_EXTERNAL: true\n      SPDLOG_NO_EXCEPTIONS: false\n",
+		"cycle-a.yaml": "include: [cycle-b.yaml]\n",
+		"cycle-b.yaml": "include: [cycle-a.yaml]\n",
+	})
+	path := filepath.Join(dir, DefaultCfgFile)
+
+	tests := []struct {
+		name     string
+		manifest string
+		want     map[string]interface{}
+		wantErr  []string
+	}{
+		{
+			name:     "nested map merge",
+			manifest: "include: [base.yaml]\ndependencies:\n  spdlog:\n    options:\n      SPDLOG_NO_EXCEPTIONS: true\n",
+			want: map[string]interface{}{
+				"package": map[string]interface{}{"cpp_standard": 17, "namespace": "acme"},
+				"dependencies": map[string]interface{}{
+					"spdlog": map[string]interface{}{"options": map[string]interface{}{"SPDLOG_FMT_EXTERNAL": true, "SPDLOG_NO_EXCEPTIONS": true}},
+				},
+			},
+		},
+		{
+			name:     "scalar override",
+			manifest: "include: [base.yaml]\npackage:\n  cpp_standard: 20\n",
+			want: map[string]interface{}{
+				"package": map[string]interface{}{"cpp_standard": 20, "namespace": "acme"},
+				"dependencies": map[string]interface{}{
+					"spdlog": map[string]interface{}{"options": map[string]interface{}{"SPDLOG_FMT_EXTERNAL": true, "SPDLOG_NO_EXCEPTIONS": false}},
+				},
+			},
+		},
+		{
+			name:     "cycle",
+			manifest: "include: [cycle-a.yaml]\n",
+			wantErr:  []string{"include cycle: ", "cycle-a.yaml -> ", "cycle-b.yaml -> "},
+		},
+		{
+			name:     "missing file",
+			manifest: "include: [missing.yaml]\n",
+			wantErr:  []string{"failed to read ", "missing.yaml (included from " + path},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mergeIncludes(path, []byte(tt.manifest), false)
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("mergeIncludes succeeded:\n%s", data)
+				}
+				if exitCode(err) != ExitConfig {
+					t.Errorf("exit code = %d, want %d", exitCode(err), ExitConfig)
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("error %q does not contain %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergeIncludes: %v", err)
+			}
+			var got map[string]interface{}
+			if err := yaml.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Manifests without includes are returned byte for byte
+	const plain = "package:\n  name: demo # keep\n"
+	if data, err := mergeIncludes(path, []byte(plain), false); err != nil || string(data) != plain {
+		t.Errorf("mergeIncludes without includes = %q, %v", data, err)
+	}
+}
+
+func TestWatchDirsFromConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     []string
+	}{
+		{name: "defaults", manifest: "package:\n  name: demo\n", want: []string{"src", "include", "tests"}},
+		{
+			name: "configured",
+			manifest: `package:
+  name: demo
+sources: [./lib/core/**/*.cpp, lib/net/*.cpp, app/main.cpp, main.cpp]
+include:
+  public: [api/]
+  private: [internal, lib/core]
+subdirectories: [plugins]
+`,
+			want: []string{"lib/core", "lib/net", "app", "api", "internal", "tests", "plugins"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig(t, tt.manifest)
+			want := make([]string, len(tt.want))
+			for i, dir := range tt.want {
+				want[i] = filepath.FromSlash(dir)
+			}
+			if got := watchDirsFromConfig(&config); !reflect.DeepEqual(got, want) {
+				t.Errorf("watchDirsFromConfig = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReplacePGOFlags(t *testing.T) {
+	tests := []struct {
+		name, flags, pgoFlags, want string
+	}{
+		{name: "add to empty", pgoFlags: "-fprofile-generate=/p", want: "-fprofile-generate=/p"},
+		{name: "add to user flags", flags: "-fuse-ld=lld", pgoFlags: "-fprofile-generate=/p", want: "-fuse-ld=lld -fprofile-generate=/p"},
+		{name: "generate to use", flags: "-fuse-ld=lld -fprofile-generate=/p", pgoFlags: "-fprofile-use=/p -fprofile-correction -Wno-missing-profile", want: "-fuse-ld=lld -fprofile-use=/p -fprofile-correction -Wno-missing-profile"},
+		{name: "reset keeps user flags", flags: "-march=native -fprofile-use=/p -fprofile-correction -Wno-missing-profile -Wl,--as-needed", want: "-march=native -Wl,--as-needed"},
+		{name: "reset clang", flags: "-fprofile-instr-use=/p/default.profdata", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replacePGOFlags(tt.flags, tt.pgoFlags); got != tt.want {
+				t.Errorf("replacePGOFlags(%q, %q) = %q, want %q", tt.flags, tt.pgoFlags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCycloneDXForGraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{name: "executable", manifest: "package:\n  name: demo\n", want: "application"},
+		{name: "shared library", manifest: "package:\n  name: demo\nbuild:\n  shared_libs: true\n", want: "library"},
+		{name: "static library", manifest: "package:\n  name: demo\n  project_type: lib\n", want: "library"},
+		{name: "executable with shared deps", manifest: "package:\n  name: demo\n  project_type: exe\nbuild:\n  shared_libs: true\n", want: "application"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig(t, tt.manifest)
+			bom, err := cycloneDXForGraph(&DependencyGraph{Project: "demo", Dependencies: []ResolvedDependency{}}, &config)
+			if err != nil {
+				t.Fatalf("cycloneDXForGraph: %v", err)
+			}
+			if bom.Metadata.Component.Type != tt.want {
+				t.Errorf("project type = %q, want %q", bom.Metadata.Component.Type, tt.want)
+			}
+		})
+	}
+}
+
+func TestRandomUUID(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id, err := randomUUID()
+		if err != nil {
+			t.Fatalf("randomUUID: %v", err)
+		}
+		if !uuidV4.MatchString(id) {
+			t.Errorf("%q is not a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Errorf("duplicate UUID %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestAddDependencyKeepsDefaultFeatureDependencies(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	chdirTemp(t, map[string]string{DefaultCfgFile: `package:
+  name: demo
+dependencies:
+  fmt: {}
+features:
+  gui:
+    dependencies:
+      imgui: {}
+registry:
+  features: [gui]
+`})
+
+	var sent ForgeConfig
+	server := dependenciesServer(t, &sent)
+
+	if err := addDependency(server.URL, "spdlog", false, "", false); err != nil {
+		t.Fatalf("addDependency: %v", err)
+	}
+	for _, dep := range []string{"fmt", "spdlog", "imgui"} {
+		if _, ok := sent.Dependencies[dep]; !ok {
+			t.Errorf("regenerated without %s: %v", dep, sent.Dependencies)
+		}
+	}
+	config, err := loadConfigForEdit(DefaultCfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config.Dependencies["imgui"]; ok {
+		t.Errorf("default feature dependency saved to forge.yaml: %v", config.Dependencies)
+	}
+}
+
+func TestExpandConfigFeatureDefines(t *testing.T) {
+	chdirTemp(t, map[string]string{DefaultCfgFile: `package:
+  name: demo
+features:
+  telemetry:
+    dependencies:
+      spdlog: {}
+    defines: [-DENABLE_TELEMETRY]
+    cxx_flags: "-fno-omit-frame-pointer -g"
+`})
+
+	var err error
+	out := captureStdout(t, func() { err = expandConfig(DefaultCfgFile, "telemetry", "") })
+	if err != nil {
+		t.Fatalf("expandConfig: %v", err)
+	}
+	var expanded struct {
+		Build struct {
+			FeatureDefines  []string `yaml:"feature_defines"`
+			FeatureCxxFlags []string `yaml:"feature_cxx_flags"`
+		} `yaml:"build"`
+		Dependencies map[string]interface{} `yaml:"dependencies"`
+	}
+	if err := yaml.Unmarshal([]byte(out), &expanded); err != nil {
+		t.Fatalf("expand output is not YAML: %v\n%s", err, out)
+	}
+	if want := []string{"ENABLE_TELEMETRY"}; !reflect.DeepEqual(expanded.Build.FeatureDefines, want) {
+		t.Errorf("feature_defines = %v, want %v", expanded.Build.FeatureDefines, want)
+	}
+	if want := []string{"-fno-omit-frame-pointer", "-g"}; !reflect.DeepEqual(expanded.Build.FeatureCxxFlags, want) {
+		t.Errorf("feature_cxx_flags = %v, want %v", expanded.Build.FeatureCxxFlags, want)
+	}
+	if _, ok := expanded.Dependencies["spdlog"]; !ok {
+		t.Errorf("feature dependency missing:\n%s", out)
+	}
+
+	// Without the feature there is nothing to show
+	out = captureStdout(t, func() { err = expandConfig(DefaultCfgFile, "", "") })
+	if err != nil {
+		t.Fatalf("expandConfig: %v", err)
+	}
+	if strings.Contains(out, "feature_") {
+		t.Errorf("feature settings shown without features:\n%s", out)
+	}
+}
+
+func TestExpandConfig(t *testing.T) {
+	t.Setenv("DEMO_VERSION", "2.1.0")
+	chdirTemp(t, map[string]string{DefaultCfgFile: `package:
+  name: demo
+  version: ${DEMO_VERSION}
+build:
+  build_type: RelWithDebInfo
+  cxx_flags: -march=native
+dependencies:
+  fmt: {}
+features:
+  gui:
+    dependencies:
+      imgui: {}
+profiles:
+  release:
+    build_type: Release
+    lto: true
+`})
+
+	type expandedConfig struct {
+		Package struct {
+			Name        string `yaml:"name"`
+			Version     string `yaml:"version"`
+			CppStandard int    `yaml:"cpp_standard"`
+			Namespace   string `yaml:"namespace"`
+			BinName     string `yaml:"bin_name"`
+		} `yaml:"package"`
+		Build struct {
+			BuildType string `yaml:"build_type"`
+			CxxFlags  string `yaml:"cxx_flags"`
+			LTO       bool   `yaml:"lto"`
+		} `yaml:"build"`
+		Testing struct {
+			Framework string `yaml:"framework"`
+		} `yaml:"testing"`
+		Dependencies map[string]interface{} `yaml:"dependencies"`
+		Features     map[string]interface{} `yaml:"features"`
+		Profiles     map[string]interface{} `yaml:"profiles"`
+	}
+	expand := func(features, profile string) (expandedConfig, string) {
+		t.Helper()
+		var err error
+		out := captureStdout(t, func() { err = expandConfig(DefaultCfgFile, features, profile) })
+		if err != nil {
+			t.Fatalf("expandConfig: %v", err)
+		}
+		var expanded expandedConfig
+		if err := yaml.Unmarshal([]byte(out), &expanded); err != nil {
+			t.Fatalf("expand output is not YAML: %v\n%s", err, out)
+		}
+		return expanded, out
+	}
+
+	// Defaults and env substitution; build_type and cxx_flags need a profile
+	expanded, out := expand("", "")
+	if expanded.Package.Version != "2.1.0" || expanded.Package.CppStandard != 17 || expanded.Package.Namespace != "demo" || expanded.Package.BinName != "demo" {
+		t.Errorf("package = %+v", expanded.Package)
+	}
+	if expanded.Testing.Framework != "none" {
+		t.Errorf("testing.framework = %q, want none", expanded.Testing.Framework)
+	}
+	if strings.Contains(out, "build_type") || strings.Contains(out, "cxx_flags") {
+		t.Errorf("build_type/cxx_flags shown without a profile:\n%s", out)
+	}
+	if _, ok := expanded.Dependencies["imgui"]; ok {
+		t.Errorf("feature dependency enabled without the feature:\n%s", out)
+	}
+
+	// A feature and a profile together
+	expanded, out = expand("gui", "release")
+	if expanded.Build.BuildType != "Release" || expanded.Build.CxxFlags != "-march=native" || !expanded.Build.LTO {
+		t.Errorf("build = %+v", expanded.Build)
+	}
+	if _, ok := expanded.Dependencies["imgui"]; !ok {
+		t.Errorf("feature dependency missing:\n%s", out)
+	}
+	if expanded.Features != nil || expanded.Profiles != nil {
+		t.Errorf("features/profiles not folded in:\n%s", out)
+	}
+	if !strings.Contains(out, "# Features: gui\n") || !strings.Contains(out, "# Profile: release\n") {
+		t.Errorf("missing header comments:\n%s", out)
+	}
+}
+
+func TestDoServerRequestRetries(t *testing.T) {
+	backoff := serverRetryBackoff
+	serverRetryBackoff = time.Millisecond
+	t.Cleanup(func() { serverRetryBackoff = backoff })
+
+	tests := []struct {
+		name         string
+		args         []string
+		statuses     []int // one per attempt, the last repeats
+		wantAttempts int32
+		wantStatus   int
+	}{
+		{name: "5xx retried", args: []string{"--retries", "2"}, statuses: []int{503}, wantAttempts: 3, wantStatus: 503},
+		{name: "5xx then success", statuses: []int{500, 200}, wantAttempts: 2, wantStatus: 200},
+		{name: "4xx not retried", statuses: []int{404}, wantAttempts: 1, wantStatus: 404},
+		{name: "no-retry", args: []string{"--no-retry"}, statuses: []int{503}, wantAttempts: 1, wantStatus: 503},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retries := serverRetries
+			t.Cleanup(func() { serverRetries = retries })
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			addRetryFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer server.Close()
+
+			resp, err := doServerRequest(func() (*http.Request, error) {
+				return http.NewRequest("GET", server.URL, nil)
+			})
+			if err != nil {
+				t.Fatalf("doServerRequest: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDoServerRequestConnectionError(t *testing.T) {
+	backoff, retries := serverRetryBackoff, serverRetries
+	serverRetryBackoff, serverRetries = time.Millisecond, 1
+	t.Cleanup(func() { serverRetryBackoff, serverRetries = backoff, retries })
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	var attempts int
+	_, err := doServerRequest(func() (*http.Request, error) {
+		attempts++
+		return http.NewRequest("GET", url, nil)
+	})
+	if err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+	if code := exitCode(err); code != ExitNetwork {
+		t.Errorf("exit code = %d, want %d", code, ExitNetwork)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunChildInterruptReturnsError(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	}()
+
+	start := time.Now()
+	err := runChild(cmd)
+	if err == nil {
+		t.Fatal("interrupted child returned no error")
+	}
+	if time.Since(start) > childShutdownGrace {
+		t.Errorf("child wasn't stopped by the signal")
+	}
+	// Callers wrap the error with their own exit code; the interruption still wins
+	wrapped := withExitCode(ExitBuild, fmt.Errorf("build failed: %w", err))
+	if code := exitCode(wrapped); code != ExitInterrupted {
+		t.Errorf("exit code = %d, want %d", code, ExitInterrupted)
+	}
+}
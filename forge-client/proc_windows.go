@@ -0,0 +1,25 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op: Ctrl+C already reaches every process on the console
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup can't deliver signals on Windows; an interrupt has already
+// reached the child through the console, anything else stops it
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) error {
+	if sig == os.Interrupt {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+// killProcessGroup kills the child; its own children are left to the console
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
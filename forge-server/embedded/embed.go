@@ -2,5 +2,7 @@ package embedded
 
 import "embed"
 
-//go:embed recipes/*.yaml
+// Recipes are .yaml or .json files
+//
+//go:embed recipes
 var RecipesFS embed.FS
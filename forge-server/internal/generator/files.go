@@ -1,10 +1,14 @@
 package generator
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/ozacod/forge/forge-server/internal/recipe"
+	"gopkg.in/yaml.v3"
 )
 
 func GenerateTestCMake(
@@ -38,10 +42,13 @@ target_include_directories(%s_tests
         ${CMAKE_CURRENT_SOURCE_DIR}/../include
 )
 
-# Link libraries from dependencies.cmake (FORGE_LINK_LIBRARIES + FORGE_TEST_LINK_LIBRARIES)
+# Link libraries from dependencies.cmake (every FORGE_*LINK_LIBRARIES scope + FORGE_TEST_LINK_LIBRARIES)
 target_link_libraries(%s_tests
     PRIVATE
         ${FORGE_LINK_LIBRARIES}
+        ${FORGE_PUBLIC_LINK_LIBRARIES}
+        ${FORGE_PRIVATE_LINK_LIBRARIES}
+        ${FORGE_INTERFACE_LINK_LIBRARIES}
         ${FORGE_TEST_LINK_LIBRARIES}
 )
 
@@ -318,8 +325,15 @@ int main() {
 func GenerateReadme(projectName string, libraries []*recipe.Library, cppStandard int, projectType string) string {
 	var libList strings.Builder
 	if len(libraries) > 0 {
+		libList.WriteString("| Library | Version | Description |\n")
+		libList.WriteString("|---------|---------|-------------|\n")
 		for _, lib := range libraries {
-			libList.WriteString(fmt.Sprintf("- [%s](%s) - %s\n", lib.Name, lib.GitHubURL, lib.Description))
+			// Same tag dependencies.cmake fetches
+			version := "system"
+			if !lib.SystemPackage && lib.FetchContent != nil {
+				version = lib.FetchContent.Tag
+			}
+			libList.WriteString(fmt.Sprintf("| [%s](%s) | %s | %s |\n", lib.Name, lib.GitHubURL, version, lib.Description))
 		}
 	} else {
 		libList.WriteString("No external dependencies.")
@@ -410,7 +424,7 @@ This regenerates .cmake/forge/dependencies.cmake without modifying your CMakeLis
 ## License
 
 MIT License
-`, projectName, cppStandard, libList.String(), projectName, projectName, projectName, projectName, projectName, projectName)
+`, projectName, cppStandard, libList.String(), projectName, projectName, projectName, projectName, projectName)
 	}
 }
 
@@ -452,6 +466,35 @@ Testing/
 `
 }
 
+func GenerateGitAttributes() string {
+	return `# Normalize line endings to LF for all text files
+* text=auto eol=lf
+
+# Windows scripts need CRLF
+*.bat text eol=crlf
+*.cmd text eol=crlf
+*.ps1 text eol=crlf
+
+# Binary files
+*.png binary
+*.jpg binary
+*.jpeg binary
+*.gif binary
+*.ico binary
+*.pdf binary
+*.zip binary
+*.gz binary
+*.a binary
+*.lib binary
+*.so binary
+*.dylib binary
+*.dll binary
+*.exe binary
+`
+}
+
+// clangFormatStyles must match forge-client's copy; both are tested against
+// testdata/clang-format
 var clangFormatStyles = map[string]string{
 	"Google": `BasedOnStyle: Google
 IndentWidth: 4
@@ -517,10 +560,46 @@ SpaceBeforeParens: Always
 `,
 }
 
-func GenerateClangFormat(style string) string {
-	if s, ok := clangFormatStyles[style]; ok {
-		return s
+// GenerateClangFormat renders .clang-format for style with overrides replacing or
+// extending its keys. The header matches the CLI's, so `forge generate` treats the
+// file as its own and keeps it in sync with build.clang_format_overrides.
+func GenerateClangFormat(style string, overrides map[string]any) (string, error) {
+	base, ok := clangFormatStyles[style]
+	if !ok {
+		base = fmt.Sprintf("BasedOnStyle: %s\n", style)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Generated by forge from build.clang_format and build.clang_format_overrides (settings %s)\n", clangFormatDigest(style, overrides)))
+	sb.WriteString("# Edits are kept until those settings change\n")
+	for _, line := range strings.SplitAfter(base, "\n") {
+		key, _, _ := strings.Cut(line, ":")
+		if _, overridden := overrides[key]; overridden && key != "BasedOnStyle" {
+			continue
+		}
+		sb.WriteString(line)
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		if key != "BasedOnStyle" {
+			keys = append(keys, key)
+		}
 	}
-	return clangFormatStyles["Google"]
+	sort.Strings(keys)
+	for _, key := range keys {
+		out, err := yaml.Marshal(map[string]any{key: overrides[key]})
+		if err != nil {
+			return "", fmt.Errorf("invalid clang_format_overrides.%s: %w", key, err)
+		}
+		sb.Write(out)
+	}
+	return sb.String(), nil
 }
 
+// clangFormatDigest identifies a style and its overrides
+func clangFormatDigest(style string, overrides map[string]any) string {
+	data, _ := yaml.Marshal(overrides) // map keys are sorted
+	sum := sha256.Sum256(append([]byte(style+"\n"), data...))
+	return hex.EncodeToString(sum[:6])
+}
@@ -3,11 +3,26 @@ package generator
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/ozacod/forge/forge-server/internal/recipe"
 )
 
+// KnownCppStandards lists the accepted cpp_standard values in ascending order.
+// Adding a new standard only requires appending it here.
+var KnownCppStandards = []int{11, 14, 17, 20, 23, 26}
+
+// IsKnownCppStandard reports whether std is one of KnownCppStandards
+func IsKnownCppStandard(std int) bool {
+	for _, known := range KnownCppStandards {
+		if known == std {
+			return true
+		}
+	}
+	return false
+}
+
 type LibrarySelection struct {
 	LibraryID string         `json:"library_id"`
 	Options   map[string]any `json:"options"`
@@ -87,9 +102,16 @@ include(FetchContent)
 		}
 	}
 
-	// Collect link libraries
-	mainLinkLibs := collectLinkLibraries(mainLibraries)
-	testLinkLibs := collectLinkLibraries(testLibraries)
+	// Group main libraries by their link scope; unscoped ones keep the
+	// target's default keyword (PRIVATE for executables, PUBLIC for libraries)
+	scoped := make(map[string][]LibraryWithOptions)
+	for _, lwo := range mainLibraries {
+		scope, err := linkScope(lwo)
+		if err != nil {
+			return "", err
+		}
+		scoped[scope] = append(scoped[scope], lwo)
+	}
 
 	// Add link library variables
 	sb.WriteString(`# -----------------------------------------------------------------------------
@@ -98,25 +120,13 @@ include(FetchContent)
 
 `)
 
-	if len(mainLinkLibs) > 0 {
-		sb.WriteString("set(FORGE_LINK_LIBRARIES\n")
-		for _, lib := range mainLinkLibs {
-			sb.WriteString(fmt.Sprintf("    %s\n", lib))
-		}
-		sb.WriteString(")\n\n")
-	} else {
-		sb.WriteString("set(FORGE_LINK_LIBRARIES)\n\n")
-	}
-
-	if len(testLinkLibs) > 0 {
-		sb.WriteString("set(FORGE_TEST_LINK_LIBRARIES\n")
-		for _, lib := range testLinkLibs {
-			sb.WriteString(fmt.Sprintf("    %s\n", lib))
-		}
-		sb.WriteString(")\n")
-	} else {
-		sb.WriteString("set(FORGE_TEST_LINK_LIBRARIES)\n")
+	writeLinkVariable(&sb, "FORGE_LINK_LIBRARIES", collectLinkLibraries(scoped[""]))
+	sb.WriteString("\n")
+	for _, scope := range linkScopes {
+		writeLinkVariable(&sb, "FORGE_"+strings.ToUpper(scope)+"_LINK_LIBRARIES", collectLinkLibraries(scoped[scope]))
+		sb.WriteString("\n")
 	}
+	writeLinkVariable(&sb, "FORGE_TEST_LINK_LIBRARIES", collectLinkLibraries(testLibraries))
 
 	return sb.String(), nil
 }
@@ -126,8 +136,36 @@ type LibraryWithOptions struct {
 	Options map[string]any
 }
 
+// GenerateLibraryCMake returns the fragment dependencies.cmake contains for a
+// single library: its declaration and the link variable it would be added to
+func GenerateLibraryCMake(lwo LibraryWithOptions) (string, error) {
+	cmake, err := generateLibraryCMake(lwo.Lib, lwo.Options)
+	if err != nil {
+		return "", err
+	}
+
+	variable := "FORGE_TEST_LINK_LIBRARIES"
+	if lwo.Lib.Category != "testing" {
+		scope, err := linkScope(lwo)
+		if err != nil {
+			return "", err
+		}
+		variable = "FORGE_LINK_LIBRARIES"
+		if scope != "" {
+			variable = "FORGE_" + strings.ToUpper(scope) + "_LINK_LIBRARIES"
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(cmake)
+	sb.WriteString("\n")
+	writeLinkVariable(&sb, variable, collectLinkLibraries([]LibraryWithOptions{lwo}))
+	return sb.String(), nil
+}
+
 func GenerateCMakeLists(
 	projectName string,
+	binName string,
 	cppStandard int,
 	librariesWithOptions []LibraryWithOptions,
 	includeTests bool,
@@ -177,6 +215,11 @@ func GenerateCMakeLists(
 		buildSharedStr = "ON"
 	}
 
+	// The executable defaults to the project name
+	if binName == "" {
+		binName = projectName
+	}
+
 	// Use version from forge.yaml or default
 	version := projectVersion
 	if version == "" {
@@ -230,9 +273,14 @@ target_include_directories(%s
 target_link_libraries(%s
     PRIVATE
         ${FORGE_LINK_LIBRARIES}
+        ${FORGE_PRIVATE_LINK_LIBRARIES}
+    PUBLIC
+        ${FORGE_PUBLIC_LINK_LIBRARIES}
+    INTERFACE
+        ${FORGE_INTERFACE_LINK_LIBRARIES}
 )
 
-`, projectName, projectName, projectName, projectName, projectName))
+`, binName, projectName, projectName, binName, binName))
 	} else {
 		sb.WriteString(fmt.Sprintf(`# =============================================================================
 # Main Library
@@ -252,6 +300,11 @@ target_include_directories(%s
 target_link_libraries(%s
     PUBLIC
         ${FORGE_LINK_LIBRARIES}
+        ${FORGE_PUBLIC_LINK_LIBRARIES}
+    PRIVATE
+        ${FORGE_PRIVATE_LINK_LIBRARIES}
+    INTERFACE
+        ${FORGE_INTERFACE_LINK_LIBRARIES}
 )
 
 `, projectName, projectName, projectName, projectName, projectName))
@@ -286,6 +339,33 @@ add_subdirectory(tests)
 	return sb.String(), nil
 }
 
+// generateSharedOverride wraps FetchContent_MakeAvailable so a single dependency
+// is built static or shared regardless of the project's BUILD_SHARED_LIBS.
+// The override is a normal variable, removed again afterwards so other dependencies
+// see the project's value. A library-specific switch (e.g. SPDLOG_BUILD_SHARED)
+// comes from the recipe: a `shared` option with a cmake_var is set like any other.
+func generateSharedOverride(libID string, shared bool) string {
+	value := "OFF"
+	if shared {
+		value = "ON"
+	}
+	saved := "FORGE_SAVED_BUILD_SHARED_LIBS_" + strings.ToUpper(libID)
+
+	var sb strings.Builder
+	sb.WriteString("if(DEFINED BUILD_SHARED_LIBS)\n")
+	sb.WriteString(fmt.Sprintf("    set(%s ${BUILD_SHARED_LIBS})\n", saved))
+	sb.WriteString("endif()\n")
+	sb.WriteString(fmt.Sprintf("set(BUILD_SHARED_LIBS %s)\n", value))
+	sb.WriteString(fmt.Sprintf("FetchContent_MakeAvailable(%s)\n", libID))
+	sb.WriteString(fmt.Sprintf("if(DEFINED %s)\n", saved))
+	sb.WriteString(fmt.Sprintf("    set(BUILD_SHARED_LIBS ${%s})\n", saved))
+	sb.WriteString(fmt.Sprintf("    unset(%s)\n", saved))
+	sb.WriteString("else()\n")
+	sb.WriteString("    unset(BUILD_SHARED_LIBS)\n")
+	sb.WriteString("endif()\n")
+	return sb.String()
+}
+
 func generateLibraryCMake(lib *recipe.Library, options map[string]any) (string, error) {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("# %s\n", lib.Name))
@@ -323,6 +403,13 @@ func generateLibraryCMake(lib *recipe.Library, options map[string]any) (string,
 		}
 	}
 
+	// Unvalidated cmake_vars passthrough for variables the recipe doesn't model
+	cmakeVars, err := generateCMakeVars(lib.ID, options)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(cmakeVars)
+
 	// Add cmake_pre if present
 	if lib.CMakePre != "" {
 		sb.WriteString(lib.CMakePre)
@@ -347,7 +434,11 @@ func generateLibraryCMake(lib *recipe.Library, options map[string]any) (string,
 				sb.WriteString(fmt.Sprintf("    SOURCE_SUBDIR %s\n", lib.FetchContent.SourceSubdir))
 			}
 			sb.WriteString(")\n")
-			sb.WriteString(fmt.Sprintf("FetchContent_MakeAvailable(%s)\n", lib.ID))
+			if shared, ok := options["shared"].(bool); ok {
+				sb.WriteString(generateSharedOverride(lib.ID, shared))
+			} else {
+				sb.WriteString(fmt.Sprintf("FetchContent_MakeAvailable(%s)\n", lib.ID))
+			}
 		}
 	}
 
@@ -382,6 +473,81 @@ func generateLibraryCMake(lib *recipe.Library, options map[string]any) (string,
 	return sb.String(), nil
 }
 
+// cmakeVarRegex limits cmake_vars names to plain CMake variable names
+var cmakeVarRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// generateCMakeVars emits the dependency's cmake_vars option as cache entries,
+// sorted by name. Booleans become ON/OFF BOOLs, everything else a STRING.
+func generateCMakeVars(libID string, options map[string]any) (string, error) {
+	raw, ok := options["cmake_vars"]
+	if !ok || raw == nil {
+		return "", nil
+	}
+	vars, ok := raw.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("invalid cmake_vars for %s: must be a map of variable names to values", libID)
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		if !cmakeVarRegex.MatchString(name) {
+			return "", fmt.Errorf("invalid cmake_vars name '%s' for %s", name, libID)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		switch v := vars[name].(type) {
+		case bool:
+			value := "OFF"
+			if v {
+				value = "ON"
+			}
+			sb.WriteString(fmt.Sprintf("set(%s %s CACHE BOOL \"\" FORCE)\n", name, value))
+		case nil:
+			sb.WriteString(fmt.Sprintf("set(%s \"\" CACHE STRING \"\" FORCE)\n", name))
+		default:
+			value := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(fmt.Sprint(v))
+			sb.WriteString(fmt.Sprintf("set(%s \"%s\" CACHE STRING \"\" FORCE)\n", name, value))
+		}
+	}
+	return sb.String(), nil
+}
+
+// linkScopes are the values accepted for a dependency's scope option, in emit order
+var linkScopes = []string{"public", "private", "interface"}
+
+// linkScope returns the dependency's target_link_libraries scope, or "" when unset
+func linkScope(lwo LibraryWithOptions) (string, error) {
+	raw, ok := lwo.Options["scope"]
+	if !ok || raw == nil {
+		return "", nil
+	}
+	scope, _ := raw.(string)
+	scope = strings.ToLower(scope)
+	for _, s := range linkScopes {
+		if scope == s {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("invalid scope '%v' for %s: must be public, private or interface", raw, lwo.Lib.ID)
+}
+
+// writeLinkVariable emits set(<name> ...) with one library per line
+func writeLinkVariable(sb *strings.Builder, name string, libs []string) {
+	if len(libs) == 0 {
+		sb.WriteString(fmt.Sprintf("set(%s)\n", name))
+		return
+	}
+	sb.WriteString(fmt.Sprintf("set(%s\n", name))
+	for _, lib := range libs {
+		sb.WriteString(fmt.Sprintf("    %s\n", lib))
+	}
+	sb.WriteString(")\n")
+}
+
 func collectLinkLibraries(librariesWithOptions []LibraryWithOptions) []string {
 	linkLibs := make(map[string]bool)
 	var result []string
@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ozacod/forge/forge-server/internal/recipe"
+)
+
+// fetchedLibrary is a FetchContent recipe for id
+func fetchedLibrary(id string) *recipe.Library {
+	return &recipe.Library{
+		ID:           id,
+		Name:         id,
+		Category:     "utility",
+		FetchContent: &recipe.FetchContent{Repository: "https://github.com/example/" + id, Tag: "v1.0.0"},
+	}
+}
+
+func TestGenerateDependenciesCMakeMixedShared(t *testing.T) {
+	spdlog := fetchedLibrary("spdlog")
+	spdlog.Options = []recipe.LibraryOption{{ID: "shared", Type: "boolean", CMakeVar: "SPDLOG_BUILD_SHARED"}}
+	libs := []LibraryWithOptions{
+		{Lib: spdlog, Options: map[string]any{"shared": false}},
+		{Lib: fetchedLibrary("fmt"), Options: map[string]any{"shared": true}},
+		{Lib: fetchedLibrary("json"), Options: map[string]any{}},
+	}
+	out, err := GenerateDependenciesCMake(libs, false, "none", recipe.NewLoader(t.TempDir()))
+	if err != nil {
+		t.Fatalf("GenerateDependenciesCMake: %v", err)
+	}
+
+	for _, want := range []string{
+		// The recipe's own shared switch is set from its option
+		"set(SPDLOG_BUILD_SHARED OFF)\n",
+		"if(DEFINED BUILD_SHARED_LIBS)\n" +
+			"    set(FORGE_SAVED_BUILD_SHARED_LIBS_SPDLOG ${BUILD_SHARED_LIBS})\n" +
+			"endif()\n" +
+			"set(BUILD_SHARED_LIBS OFF)\n" +
+			"FetchContent_MakeAvailable(spdlog)\n" +
+			"if(DEFINED FORGE_SAVED_BUILD_SHARED_LIBS_SPDLOG)\n" +
+			"    set(BUILD_SHARED_LIBS ${FORGE_SAVED_BUILD_SHARED_LIBS_SPDLOG})\n" +
+			"    unset(FORGE_SAVED_BUILD_SHARED_LIBS_SPDLOG)\n" +
+			"else()\n" +
+			"    unset(BUILD_SHARED_LIBS)\n" +
+			"endif()\n",
+		"set(BUILD_SHARED_LIBS ON)\nFetchContent_MakeAvailable(fmt)\n",
+		")\nFetchContent_MakeAvailable(json)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dependencies.cmake does not contain:\n%s\ngot:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "FMT_BUILD_SHARED") || strings.Contains(out, "JSON_BUILD_SHARED") {
+		t.Errorf("shared switch guessed for a recipe that declares none:\n%s", out)
+	}
+	if strings.Contains(out, "CACHE BOOL \"Build shared libraries\" FORCE") {
+		t.Errorf("override writes the BUILD_SHARED_LIBS cache entry:\n%s", out)
+	}
+}
@@ -4,21 +4,27 @@ import (
 	"archive/zip"
 	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/ozacod/forge/forge-server/internal/recipe"
 )
 
+// CreateProjectZip builds the server's part of a project. binName is part of the
+// request (and the cache key) so the layout can follow package.bin_name; none of
+// the files in the ZIP name the executable today.
 func CreateProjectZip(
 	projectName string,
+	binName string,
 	cppStandard int,
 	librarySelections []LibrarySelection,
 	includeTests bool,
 	testingFramework string,
 	buildShared bool,
 	clangFormatStyle string,
+	clangFormatOverrides map[string]any,
 	projectType string,
 	projectVersion string,
-	flat bool,
+	prefix string,
 	loader *recipe.Loader,
 ) ([]byte, error) {
 	// Get library objects with their options
@@ -76,21 +82,32 @@ func CreateProjectZip(
 	var zipBuffer bytes.Buffer
 	zw := zip.NewWriter(&zipBuffer)
 
-	// Use empty prefix for flat mode (CLI), project_name for wrapped mode (web UI)
-	prefix := ""
-	if !flat {
-		prefix = projectName + "/"
+	// Empty prefix for flat mode (CLI), project_name/ for wrapped mode (web UI)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
 	}
 
-	// Only generate dependencies.cmake - all other files are generated by the client
+	// Only generate dependencies.cmake, .clang-format and .gitattributes - all other files are generated by the client
 	// The client (forge-client/generator.go) generates all project files locally
 	// and only requests dependencies.cmake from the server (which requires recipe data)
 	depsCMake, err := GenerateDependenciesCMake(librariesWithOptions, includeTests, testingFramework, loader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate dependencies.cmake: %w", err)
 	}
-	if err := writeZipFile(zw, prefix+".cmake/forge/dependencies.cmake", depsCMake); err != nil {
-		return nil, err
+	clangFormat, err := GenerateClangFormat(clangFormatStyle, clangFormatOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate .clang-format: %w", err)
+	}
+
+	files := []struct{ name, content string }{
+		{".cmake/forge/dependencies.cmake", depsCMake},
+		{".clang-format", clangFormat},
+		{".gitattributes", GenerateGitAttributes()},
+	}
+	for _, f := range files {
+		if err := writeZipFile(zw, prefix+f.name, f.content); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := zw.Close(); err != nil {
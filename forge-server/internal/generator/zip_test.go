@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/forge/forge-server/internal/recipe"
+	"gopkg.in/yaml.v3"
+)
+
+// unzip returns the ZIP's entries by name
+func unzip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestCreateProjectZipContents(t *testing.T) {
+	loader := recipe.NewLoader(t.TempDir())
+
+	for _, binName := range []string{"", "demo_cli"} {
+		data, err := CreateProjectZip("demo", binName, 17, nil, false, "none", false, "Google", nil, "exe", "1.2.3", "demo", loader)
+		if err != nil {
+			t.Fatalf("CreateProjectZip: %v", err)
+		}
+		var names []string
+		for name := range unzip(t, data) {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		want := []string{"demo/.clang-format", "demo/.cmake/forge/dependencies.cmake", "demo/.gitattributes"}
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("bin_name %q: zip holds %v, want %v", binName, names, want)
+		}
+	}
+}
+
+func TestGenerateCMakeListsBinName(t *testing.T) {
+	loader := recipe.NewLoader(t.TempDir())
+
+	tests := []struct {
+		name    string
+		binName string
+		want    string
+	}{
+		{name: "defaults to project name", binName: "", want: "add_executable(demo\n"},
+		{name: "bin_name", binName: "demo_cli", want: "add_executable(demo_cli\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmake, err := GenerateCMakeLists("demo", tt.binName, 17, nil, false, "none", false, "exe", "1.2.3", loader)
+			if err != nil {
+				t.Fatalf("GenerateCMakeLists: %v", err)
+			}
+			if !strings.Contains(cmake, tt.want) {
+				t.Errorf("CMakeLists.txt does not contain %q:\n%s", tt.want, cmake)
+			}
+		})
+	}
+}
+
+func TestCreateProjectZipClangFormatOverrides(t *testing.T) {
+	loader := recipe.NewLoader(t.TempDir())
+
+	overrides := map[string]any{"ColumnLimit": 120, "SortIncludes": false}
+	data, err := CreateProjectZip("demo", "", 17, nil, false, "none", false, "LLVM", overrides, "exe", "1.0.0", "", loader)
+	if err != nil {
+		t.Fatalf("CreateProjectZip: %v", err)
+	}
+	clangFormat := unzip(t, data)[".clang-format"]
+
+	for _, want := range []string{"# Generated by forge ", "BasedOnStyle: LLVM\n", "ColumnLimit: 120\n", "SortIncludes: false\n"} {
+		if !strings.Contains(clangFormat, want) {
+			t.Errorf(".clang-format does not contain %q:\n%s", want, clangFormat)
+		}
+	}
+	if strings.Count(clangFormat, "ColumnLimit:") != 1 {
+		t.Errorf("overridden key kept its style value:\n%s", clangFormat)
+	}
+}
+
+// clangFormatCase is an entry of testdata/clang-format/cases.yaml
+type clangFormatCase struct {
+	Name      string         `yaml:"name"`
+	Style     string         `yaml:"style"`
+	Overrides map[string]any `yaml:"overrides"`
+}
+
+// TestGenerateClangFormatGolden pins the styles to files forge-client's copy is
+// checked against too, so the CLI and the server write the same .clang-format
+func TestGenerateClangFormatGolden(t *testing.T) {
+	dir := filepath.Join("testdata", "clang-format")
+	data, err := os.ReadFile(filepath.Join(dir, "cases.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []clangFormatCase
+	if err := yaml.Unmarshal(data, &cases); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join(dir, tt.Name+".clang-format"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := GenerateClangFormat(tt.Style, tt.Overrides)
+			if err != nil {
+				t.Fatalf("GenerateClangFormat: %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("GenerateClangFormat(%q) =\n%s\nwant\n%s", tt.Style, got, want)
+			}
+		})
+	}
+}
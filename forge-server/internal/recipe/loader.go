@@ -8,7 +8,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -42,6 +44,9 @@ type Library struct {
 	Description     string          `yaml:"description" json:"description"`
 	Category        string          `yaml:"category" json:"category"`
 	GitHubURL       string          `yaml:"github_url" json:"github_url"`
+	License         string          `yaml:"license" json:"license,omitempty"` // SPDX expression
+	VcpkgName       string          `yaml:"vcpkg_name" json:"vcpkg_name,omitempty"`
+	ConanRef        string          `yaml:"conan_ref" json:"conan_ref,omitempty"`
 	CppStandard     int             `yaml:"cpp_standard" json:"cpp_standard"`
 	HeaderOnly      bool            `yaml:"header_only" json:"header_only"`
 	Stars           int             `yaml:"-" json:"stars,omitempty"`
@@ -54,6 +59,9 @@ type Library struct {
 	CMakePost       string          `yaml:"cmake_post" json:"cmake_post,omitempty"`
 	SystemPackage   bool            `yaml:"system_package" json:"system_package,omitempty"`
 	FindPackageName string          `yaml:"find_package_name" json:"find_package_name,omitempty"`
+	UsageExample    string          `yaml:"usage_example" json:"usage_example,omitempty"`
+	Deprecated      bool            `yaml:"deprecated" json:"deprecated,omitempty"`
+	ReplacedBy      string          `yaml:"replaced_by" json:"replaced_by,omitempty"`
 }
 
 type Category struct {
@@ -81,6 +89,7 @@ var Categories = []Category{
 }
 
 type Loader struct {
+	mu         sync.RWMutex
 	recipesDir string
 	fs         fs.FS
 	libraries  map[string]*Library
@@ -108,71 +117,276 @@ func NewLoaderWithFS(recipesFS fs.FS, recipesDir string) *Loader {
 	}
 }
 
+// RecipesDir returns the directory recipes are read from, or "" when they come
+// from an embedded filesystem
+func (l *Loader) RecipesDir() string {
+	if l.fs != nil {
+		return ""
+	}
+	return l.recipesDir
+}
+
 func (l *Loader) LoadRecipes() error {
+	l.mu.RLock()
+	loaded := l.loaded
+	l.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if l.loaded {
 		return nil
 	}
 
+	libraries, err := l.readRecipes()
+	if err != nil {
+		return err
+	}
+	l.libraries = libraries
+	l.loaded = true
+	return nil
+}
+
+// recipeWorkers bounds the pool that parses recipe files
+var recipeWorkers = runtime.NumCPU()
+
+// readRecipes parses every recipe file into a fresh map without touching
+// the loader's state, so callers can swap it in under the write lock.
+func (l *Loader) readRecipes() (map[string]*Library, error) {
 	var entries []fs.DirEntry
 	var err error
 
 	if l.fs != nil {
 		entries, err = fs.ReadDir(l.fs, l.recipesDir)
 		if err != nil {
-			return fmt.Errorf("failed to read embedded recipes directory: %w", err)
+			return nil, fmt.Errorf("failed to read embedded recipes directory: %w", err)
 		}
 	} else {
 		if _, err := os.Stat(l.recipesDir); os.IsNotExist(err) {
-			return fmt.Errorf("recipes directory not found: %s", l.recipesDir)
+			return nil, fmt.Errorf("recipes directory not found: %s", l.recipesDir)
 		}
 		entries, err = os.ReadDir(l.recipesDir)
 		if err != nil {
-			return fmt.Errorf("failed to read recipes directory: %w", err)
+			return nil, fmt.Errorf("failed to read recipes directory: %w", err)
 		}
 	}
 
+	// Files starting with _ aren't libraries, but recipes may extend them
+	var paths []string
+	basePaths := make(map[string]string)
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
+		if entry.IsDir() || !isRecipeFile(entry.Name()) {
 			continue
 		}
 		if strings.HasPrefix(entry.Name(), "_") {
+			basePaths[recipeBaseName(entry.Name())] = filepath.Join(l.recipesDir, entry.Name())
 			continue
 		}
+		paths = append(paths, filepath.Join(l.recipesDir, entry.Name()))
+	}
 
-		filepath := filepath.Join(l.recipesDir, entry.Name())
-		lib, err := l.loadRecipeFile(filepath)
-		if err != nil {
-			fmt.Printf("Warning: Failed to load recipe %s: %v\n", filepath, err)
-			continue
-		}
+	// First pass: read the bases, keeping load errors for recipes that extend them
+	bases := make(map[string]recipeBase, len(basePaths))
+	for name, path := range basePaths {
+		doc, err := l.readRecipeDoc(path)
+		bases[name] = recipeBase{doc: doc, err: err}
+	}
+
+	// Parse recipes on a bounded worker pool. Results are stored by index so
+	// that duplicate IDs resolve in directory order, as with sequential loading.
+	results := make([]*Library, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := recipeWorkers
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				lib, err := l.loadRecipeFile(paths[i], bases)
+				if err != nil {
+					fmt.Printf("Warning: Failed to load recipe %s: %v\n", paths[i], err)
+					continue
+				}
+				results[i] = lib
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	libraries := make(map[string]*Library, len(results))
+	for _, lib := range results {
 		if lib != nil {
-			l.libraries[lib.ID] = lib
+			libraries[lib.ID] = lib
 		}
 	}
 
-	l.loaded = true
-	return nil
+	return libraries, nil
 }
 
-func (l *Loader) loadRecipeFile(filepath string) (*Library, error) {
-	var data []byte
-	var err error
-
+func (l *Loader) readFile(path string) ([]byte, error) {
 	if l.fs != nil {
-		data, err = fs.ReadFile(l.fs, filepath)
+		return fs.ReadFile(l.fs, path)
+	}
+	return os.ReadFile(path)
+}
+
+// loadRecipeFile parses a recipe, merging in the bases it extends
+func (l *Loader) loadRecipeFile(path string, bases map[string]recipeBase) (*Library, error) {
+	data, err := l.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	isJSON := strings.HasSuffix(path, ".json")
+	doc, err := parseRecipeDoc(data, isJSON)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc["extends"]; ok {
+		if doc, err = applyExtends(doc, bases, nil); err != nil {
+			return nil, err
+		}
+		if isJSON {
+			data, err = json.Marshal(doc)
+		} else {
+			data, err = yaml.Marshal(doc)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if isJSON {
+		return ParseRecipeJSON(data)
+	}
+	return ParseRecipe(data)
+}
+
+// recipeBase is a _-prefixed recipe that others can extend
+type recipeBase struct {
+	doc map[string]any
+	err error
+}
+
+// recipeBaseName is how recipes refer to a base file: its name without extension
+func recipeBaseName(file string) string {
+	return strings.TrimSuffix(file, filepath.Ext(file))
+}
+
+func (l *Loader) readRecipeDoc(path string) (map[string]any, error) {
+	data, err := l.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseRecipeDoc(data, strings.HasSuffix(path, ".json"))
+}
+
+// parseRecipeDoc decodes a recipe into a generic document for merging
+func parseRecipeDoc(data []byte, isJSON bool) (map[string]any, error) {
+	doc := make(map[string]any)
+	var err error
+	if isJSON {
+		err = json.Unmarshal(data, &doc)
 	} else {
-		data, err = os.ReadFile(filepath)
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, err
 	}
+	return doc, nil
+}
 
+// applyExtends merges the base named by doc's extends field under doc, resolving
+// the base's own extends first. chain holds the bases already being resolved.
+func applyExtends(doc map[string]any, bases map[string]recipeBase, chain []string) (map[string]any, error) {
+	value, ok := doc["extends"]
+	if !ok {
+		return doc, nil
+	}
+	name, ok := value.(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("extends must name a base recipe")
+	}
+	for _, seen := range chain {
+		if seen == name {
+			return nil, fmt.Errorf("cyclic extends: %s", strings.Join(append(chain, name), " -> "))
+		}
+	}
+
+	base, ok := bases[name]
+	if !ok {
+		return nil, fmt.Errorf("extends unknown base '%s' (expected %s.yaml or %s.json)", name, name, name)
+	}
+	if base.err != nil {
+		return nil, fmt.Errorf("base '%s': %w", name, base.err)
+	}
+	resolved, err := applyExtends(base.doc, bases, append(chain, name))
 	if err != nil {
 		return nil, err
 	}
 
+	merged := mergeRecipeDocs(resolved, doc)
+	delete(merged, "extends")
+	return merged, nil
+}
+
+// mergeRecipeDocs returns base with over applied: nested mappings merge key by
+// key, anything else (including lists) in over replaces the base value
+func mergeRecipeDocs(base, over map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range over {
+		baseMap, baseIsMap := merged[k].(map[string]any)
+		overMap, overIsMap := v.(map[string]any)
+		if baseIsMap && overIsMap {
+			merged[k] = mergeRecipeDocs(baseMap, overMap)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// isRecipeFile reports whether name is a recipe the loader picks up
+func isRecipeFile(name string) bool {
+	return strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".json")
+}
+
+// ParseRecipe decodes a recipe YAML document and fills in defaults
+func ParseRecipe(data []byte) (*Library, error) {
 	var lib Library
 	if err := yaml.Unmarshal(data, &lib); err != nil {
 		return nil, err
 	}
+	return withDefaults(&lib)
+}
 
+// ParseRecipeJSON decodes a recipe JSON document (same fields as the YAML
+// form) and fills in defaults
+func ParseRecipeJSON(data []byte) (*Library, error) {
+	var lib Library
+	if err := json.Unmarshal(data, &lib); err != nil {
+		return nil, err
+	}
+	lib.Stars = 0 // fetched from GitHub, never read from a recipe
+	return withDefaults(&lib)
+}
+
+// withDefaults validates a decoded recipe and fills in missing fields
+func withDefaults(lib *Library) (*Library, error) {
 	if lib.ID == "" {
 		return nil, fmt.Errorf("missing id field")
 	}
@@ -200,45 +414,122 @@ func (l *Loader) loadRecipeFile(filepath string) (*Library, error) {
 		lib.Alternatives = []string{}
 	}
 
-	return &lib, nil
+	return lib, nil
+}
+
+// WithOverrides returns a loader serving the same recipes with libs replacing
+// (or adding to) them by ID. The receiver is left untouched.
+func (l *Loader) WithOverrides(libs []*Library) (*Loader, error) {
+	if err := l.LoadRecipes(); err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	libraries := make(map[string]*Library, len(l.libraries)+len(libs))
+	for id, lib := range l.libraries {
+		libraries[id] = lib
+	}
+	l.mu.RUnlock()
+
+	for _, lib := range libs {
+		libraries[lib.ID] = lib
+	}
+	return &Loader{
+		recipesDir: l.recipesDir,
+		fs:         l.fs,
+		libraries:  libraries,
+		loaded:     true,
+	}, nil
 }
 
 func (l *Loader) GetAllLibraries() ([]*Library, error) {
 	if err := l.LoadRecipes(); err != nil {
 		return nil, err
 	}
+	l.mu.RLock()
 	libraries := make([]*Library, 0, len(l.libraries))
 	for _, lib := range l.libraries {
-		// Fetch GitHub stars if GitHub URL is available
-		if lib.GitHubURL != "" {
-			stars, err := fetchGitHubStars(lib.GitHubURL)
-			if err == nil {
-				lib.Stars = stars
-			}
+		libraries = append(libraries, lib)
+	}
+	l.mu.RUnlock()
+
+	for i, lib := range libraries {
+		libraries[i] = withStars(lib)
+	}
+	return libraries, nil
+}
+
+// fetchStars looks up a repository's GitHub stars; a variable so tests can stub the API
+var fetchStars = fetchGitHubStars
+
+// withStars returns a copy of lib with its GitHub stars filled in. The loaded
+// recipes are shared by every request and by ReloadRecipes, so they are never
+// written to outside the lock.
+func withStars(lib *Library) *Library {
+	c := *lib
+	if c.GitHubURL != "" {
+		if stars, err := fetchStars(c.GitHubURL); err == nil {
+			c.Stars = stars
+		}
+	}
+	return &c
+}
+
+// FilterLibraries returns libraries matching all non-zero filters: exact category,
+// tag membership and cpp_standard <= stdMax. Stars are only fetched for the matches.
+func (l *Loader) FilterLibraries(category, tag string, stdMax int) ([]*Library, error) {
+	if err := l.LoadRecipes(); err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	libraries := make([]*Library, 0, len(l.libraries))
+	for _, lib := range l.libraries {
+		if category != "" && lib.Category != category {
+			continue
+		}
+		if stdMax > 0 && lib.CppStandard > stdMax {
+			continue
+		}
+		if tag != "" && !hasTag(lib, tag) {
+			continue
 		}
 		libraries = append(libraries, lib)
 	}
+	l.mu.RUnlock()
+
+	for i, lib := range libraries {
+		libraries[i] = withStars(lib)
+	}
 	return libraries, nil
 }
 
+func hasTag(lib *Library, tag string) bool {
+	for _, t := range lib.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
 func (l *Loader) GetLibraryByID(id string) (*Library, error) {
 	if err := l.LoadRecipes(); err != nil {
 		return nil, err
 	}
+	l.mu.RLock()
 	lib := l.libraries[id]
-	if lib != nil && lib.GitHubURL != "" {
-		stars, err := fetchGitHubStars(lib.GitHubURL)
-		if err == nil {
-			lib.Stars = stars
-		}
+	l.mu.RUnlock()
+	if lib == nil {
+		return nil, nil
 	}
-	return lib, nil
+	return withStars(lib), nil
 }
 
 func (l *Loader) GetLibrariesByCategory(category string) ([]*Library, error) {
 	if err := l.LoadRecipes(); err != nil {
 		return nil, err
 	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	var result []*Library
 	for _, lib := range l.libraries {
 		if lib.Category == category {
@@ -253,6 +544,8 @@ func (l *Loader) SearchLibraries(query string) ([]*Library, error) {
 		return nil, err
 	}
 	query = strings.ToLower(query)
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	var result []*Library
 	for _, lib := range l.libraries {
 		if strings.Contains(strings.ToLower(lib.Name), query) ||
@@ -270,10 +563,26 @@ func (l *Loader) SearchLibraries(query string) ([]*Library, error) {
 	return result, nil
 }
 
+// Count returns the number of loaded libraries without triggering a load
+func (l *Loader) Count() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.libraries)
+}
+
+// ReloadRecipes re-reads all recipes and atomically replaces the loaded set.
+// Readers keep seeing the previous recipes until the new map is swapped in.
 func (l *Loader) ReloadRecipes() error {
-	l.libraries = make(map[string]*Library)
-	l.loaded = false
-	return l.LoadRecipes()
+	libraries, err := l.readRecipes()
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.libraries = libraries
+	l.loaded = true
+	l.mu.Unlock()
+	return nil
 }
 
 // fetchGitHubStars fetches the number of stars from GitHub API
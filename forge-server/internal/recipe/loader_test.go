@@ -0,0 +1,366 @@
+package recipe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeRecipes writes count recipes with a GitHub URL into dir
+func writeRecipes(t testing.TB, dir string, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("lib%d", i)
+		recipe := fmt.Sprintf("id: %s\ncategory: utility\ngithub_url: https://github.com/example/%s\ntags: [test]\n", id, id)
+		if err := os.WriteFile(filepath.Join(dir, id+".yaml"), []byte(recipe), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// stubStars replaces the GitHub API with a fixed star count for the test
+func stubStars(t *testing.T, stars int) {
+	t.Helper()
+	orig := fetchStars
+	fetchStars = func(string) (int, error) { return stars, nil }
+	t.Cleanup(func() { fetchStars = orig })
+}
+
+// TestConcurrentReloadAndRead is meant for go test -race: readers fill in stars
+// while the recipe set is reloaded underneath them
+func TestConcurrentReloadAndRead(t *testing.T) {
+	stubStars(t, 42)
+	dir := t.TempDir()
+	writeRecipes(t, dir, 20)
+	loader := NewLoader(dir)
+	if err := loader.LoadRecipes(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				libs, err := loader.GetAllLibraries()
+				if err != nil {
+					errs <- err
+					return
+				}
+				for _, lib := range libs {
+					if lib.Stars != 42 {
+						errs <- fmt.Errorf("%s has %d stars, want 42", lib.ID, lib.Stars)
+						return
+					}
+				}
+				if _, err := loader.GetLibraryByID("lib3"); err != nil {
+					errs <- err
+					return
+				}
+				filtered, err := loader.FilterLibraries("utility", "test", 17)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(filtered) != 20 {
+					errs <- fmt.Errorf("FilterLibraries returned %d libraries, want 20", len(filtered))
+					return
+				}
+				if _, err := loader.SearchLibraries("lib"); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if err := loader.ReloadRecipes(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestReadsDoNotModifyLoadedRecipes(t *testing.T) {
+	stubStars(t, 7)
+	dir := t.TempDir()
+	writeRecipes(t, dir, 1)
+	loader := NewLoader(dir)
+
+	lib, err := loader.GetLibraryByID("lib0")
+	if err != nil || lib == nil {
+		t.Fatalf("GetLibraryByID: %v, %v", lib, err)
+	}
+	if lib.Stars != 7 {
+		t.Errorf("returned library has %d stars, want 7", lib.Stars)
+	}
+
+	loader.mu.RLock()
+	shared := loader.libraries["lib0"]
+	loader.mu.RUnlock()
+	if shared == lib {
+		t.Error("GetLibraryByID returned the shared recipe instead of a copy")
+	}
+	if shared.Stars != 0 {
+		t.Errorf("shared recipe was modified: %d stars", shared.Stars)
+	}
+}
+
+func TestGetLibraryByIDUnknown(t *testing.T) {
+	loader := NewLoader(t.TempDir())
+	lib, err := loader.GetLibraryByID("missing")
+	if err != nil || lib != nil {
+		t.Errorf("GetLibraryByID(missing) = %v, %v; want nil, nil", lib, err)
+	}
+}
+
+func TestFilterLibraries(t *testing.T) {
+	stubStars(t, 3)
+	dir := t.TempDir()
+	recipes := map[string]string{
+		"json":  "id: json\ncategory: serialization\ncpp_standard: 11\ntags: [json, parsing]\ngithub_url: https://github.com/example/json\n",
+		"yaml":  "id: yaml\ncategory: serialization\ncpp_standard: 17\ntags: [yaml]\n",
+		"range": "id: range\ncategory: utility\ncpp_standard: 20\ntags: [ranges]\n",
+	}
+	for id, recipe := range recipes {
+		if err := os.WriteFile(filepath.Join(dir, id+".yaml"), []byte(recipe), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loader := NewLoader(dir)
+
+	tests := []struct {
+		name     string
+		category string
+		tag      string
+		stdMax   int
+		want     []string
+	}{
+		{name: "no filters", want: []string{"json", "range", "yaml"}},
+		{name: "category", category: "serialization", want: []string{"json", "yaml"}},
+		{name: "tag is case-insensitive", tag: "JSON", want: []string{"json"}},
+		{name: "std max", stdMax: 17, want: []string{"json", "yaml"}},
+		{name: "filters combine", category: "serialization", stdMax: 14, want: []string{"json"}},
+		{name: "no match", category: "gui", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			libs, err := loader.FilterLibraries(tt.category, tt.tag, tt.stdMax)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, lib := range libs {
+				got = append(got, lib.ID)
+				if lib.ID == "json" && lib.Stars != 3 {
+					t.Errorf("json has %d stars, want 3", lib.Stars)
+				}
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterLibraries(%q, %q, %d) = %v, want %v", tt.category, tt.tag, tt.stdMax, got, tt.want)
+			}
+		})
+	}
+
+	loader.mu.RLock()
+	defer loader.mu.RUnlock()
+	if stars := loader.libraries["json"].Stars; stars != 0 {
+		t.Errorf("FilterLibraries modified the shared recipe: %d stars", stars)
+	}
+}
+
+// writeFiles writes name -> content into dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadJSONRecipeAlongsideYAML(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"fmt.yaml": "id: fmt\nname: fmt\ncategory: formatting\n",
+		"nlohmann_json.json": `{
+  "id": "nlohmann_json",
+  "name": "nlohmann/json",
+  "category": "serialization",
+  "cpp_standard": 11,
+  "header_only": true,
+  "tags": ["json"],
+  "fetch_content": {"repository": "https://github.com/nlohmann/json", "tag": "v3.11.3"},
+  "link_libraries": ["nlohmann_json::nlohmann_json"]
+}`,
+		"_base.json": `{"category": "utility"}`,
+		"notes.txt":  "not a recipe",
+	})
+	loader := NewLoader(dir)
+	if err := loader.LoadRecipes(); err != nil {
+		t.Fatal(err)
+	}
+
+	if loader.Count() != 2 {
+		t.Errorf("loaded %d recipes, want fmt and nlohmann_json", loader.Count())
+	}
+	lib, err := loader.GetLibraryByID("nlohmann_json")
+	if err != nil || lib == nil {
+		t.Fatalf("JSON recipe not loaded: %v", err)
+	}
+	want := &Library{
+		ID:            "nlohmann_json",
+		Name:          "nlohmann/json",
+		Category:      "serialization",
+		CppStandard:   11,
+		HeaderOnly:    true,
+		Tags:          []string{"json"},
+		FetchContent:  &FetchContent{Repository: "https://github.com/nlohmann/json", Tag: "v3.11.3"},
+		LinkLibraries: []string{"nlohmann_json::nlohmann_json"},
+		Alternatives:  []string{},
+		Options:       []LibraryOption{},
+	}
+	if !reflect.DeepEqual(lib, want) {
+		t.Errorf("JSON recipe = %+v, want %+v", lib, want)
+	}
+	if lib, _ := loader.GetLibraryByID("fmt"); lib == nil {
+		t.Error("YAML recipe next to the JSON one not loaded")
+	}
+}
+
+func TestExtendsOverridePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"_header_only.yaml": `category: utility
+cpp_standard: 11
+header_only: true
+tags: [header-only]
+fetch_content:
+  repository: https://github.com/example/base
+  tag: v1.0.0
+`,
+		"_modern.yaml": "extends: _header_only\ncpp_standard: 17\n",
+		"lib.yaml": `id: lib
+extends: _modern
+tags: [modern]
+fetch_content:
+  tag: v2.0.0
+`,
+		"tool.json": `{"id": "tool", "extends": "_header_only", "header_only": false}`,
+	})
+	loader := NewLoader(dir)
+	if err := loader.LoadRecipes(); err != nil {
+		t.Fatal(err)
+	}
+
+	lib, _ := loader.GetLibraryByID("lib")
+	if lib == nil {
+		t.Fatal("recipe extending a base was not loaded")
+	}
+	if lib.Category != "utility" || !lib.HeaderOnly {
+		t.Errorf("fields only the base sets weren't inherited: %+v", lib)
+	}
+	if lib.CppStandard != 17 {
+		t.Errorf("cpp_standard = %d, want the intermediate base's 17", lib.CppStandard)
+	}
+	if !reflect.DeepEqual(lib.Tags, []string{"modern"}) {
+		t.Errorf("tags = %v, want the recipe's list to replace the base's", lib.Tags)
+	}
+	if lib.FetchContent == nil || lib.FetchContent.Repository != "https://github.com/example/base" || lib.FetchContent.Tag != "v2.0.0" {
+		t.Errorf("fetch_content = %+v, want the base repository with the recipe's tag", lib.FetchContent)
+	}
+
+	tool, _ := loader.GetLibraryByID("tool")
+	if tool == nil || tool.HeaderOnly || tool.Category != "utility" {
+		t.Errorf("JSON recipe extending a YAML base = %+v", tool)
+	}
+}
+
+func TestExtendsErrors(t *testing.T) {
+	bases := map[string]recipeBase{
+		"_a":      {doc: map[string]any{"extends": "_b"}},
+		"_b":      {doc: map[string]any{"extends": "_a"}},
+		"_broken": {err: fmt.Errorf("yaml: line 1: did not find expected key")},
+	}
+	tests := []struct {
+		name    string
+		extends any
+		wantErr string
+	}{
+		{name: "missing parent", extends: "_missing", wantErr: "extends unknown base '_missing'"},
+		{name: "cycle", extends: "_a", wantErr: "cyclic extends: _a -> _b -> _a"},
+		{name: "unparsable parent", extends: "_broken", wantErr: "base '_broken': yaml"},
+		{name: "not a name", extends: 3, wantErr: "extends must name a base recipe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := applyExtends(map[string]any{"id": "lib", "extends": tt.extends}, bases, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A recipe whose base is missing is skipped, the rest still load
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"orphan.yaml": "id: orphan\nextends: _missing\n",
+		"fmt.yaml":    "id: fmt\n",
+	})
+	loader := NewLoader(dir)
+	if err := loader.LoadRecipes(); err != nil {
+		t.Fatal(err)
+	}
+	if lib, _ := loader.GetLibraryByID("orphan"); lib != nil {
+		t.Error("recipe extending a missing base was loaded")
+	}
+	if lib, _ := loader.GetLibraryByID("fmt"); lib == nil {
+		t.Error("unrelated recipe not loaded")
+	}
+}
+
+// BenchmarkLoadRecipes compares a cold load of 200 recipes parsed sequentially
+// and on the worker pool
+func BenchmarkLoadRecipes(b *testing.B) {
+	dir := b.TempDir()
+	writeRecipes(b, dir, 200)
+
+	counts := []int{1}
+	if recipeWorkers > 1 {
+		counts = append(counts, recipeWorkers)
+	}
+	for _, workers := range counts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			orig := recipeWorkers
+			recipeWorkers = workers
+			defer func() { recipeWorkers = orig }()
+
+			for i := 0; i < b.N; i++ {
+				loader := NewLoader(dir)
+				if err := loader.LoadRecipes(); err != nil {
+					b.Fatal(err)
+				}
+				if loader.Count() != 200 {
+					b.Fatalf("loaded %d recipes, want 200", loader.Count())
+				}
+			}
+		})
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gin-contrib/cors"
@@ -14,6 +17,7 @@ import (
 	"github.com/ozacod/forge/forge-server/embedded"
 	"github.com/ozacod/forge/forge-server/internal/generator"
 	"github.com/ozacod/forge/forge-server/internal/recipe"
+	"github.com/pelletier/go-toml/v2"
 	"gopkg.in/yaml.v3"
 )
 
@@ -24,6 +28,9 @@ const (
 
 var projectNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
 
+// envVarRegex matches $$, ${VAR}, ${VAR:-default} and $VAR
+var envVarRegex = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
 type ProjectConfig struct {
 	ProjectName      string             `json:"project_name" binding:"required"`
 	CppStandard      int                `json:"cpp_standard"`
@@ -33,6 +40,7 @@ type ProjectConfig struct {
 	BuildShared      bool               `json:"build_shared"`
 	ClangFormatStyle string             `json:"clang_format_style"`
 	ProjectType      string             `json:"project_type"`
+	BinName          string             `json:"bin_name"`
 }
 
 type LibrarySelection struct {
@@ -43,13 +51,15 @@ type LibrarySelection struct {
 type ForgeYAML struct {
 	Package struct {
 		Name        string `yaml:"name"`
+		BinName     string `yaml:"bin_name"`
 		Version     string `yaml:"version"`
 		CppStandard int    `yaml:"cpp_standard"`
 		ProjectType string `yaml:"project_type"`
 	} `yaml:"package"`
 	Build struct {
-		SharedLibs  bool   `yaml:"shared_libs"`
-		ClangFormat string `yaml:"clang_format"`
+		SharedLibs           bool           `yaml:"shared_libs"`
+		ClangFormat          string         `yaml:"clang_format"`
+		ClangFormatOverrides map[string]any `yaml:"clang_format_overrides"`
 	} `yaml:"build"`
 	Testing struct {
 		Framework string `yaml:"framework"`
@@ -59,22 +69,47 @@ type ForgeYAML struct {
 
 // SetupServer initializes the Gin engine and loads recipes
 func SetupServer() (*gin.Engine, error) {
-	// Use embedded recipes
-	loader := recipe.NewLoaderWithFS(embedded.RecipesFS, "recipes")
+	// Recipes are embedded in the binary; FORGE_RECIPES_DIR serves them from disk instead
+	var loader *recipe.Loader
+	if recipesDir := os.Getenv("FORGE_RECIPES_DIR"); recipesDir != "" {
+		loader = recipe.NewLoader(recipesDir)
+	} else {
+		loader = recipe.NewLoaderWithFS(embedded.RecipesFS, "recipes")
+	}
 
-	// Load recipes
+	// Load recipes, failing fast unless an empty registry is explicitly allowed
 	if err := loader.LoadRecipes(); err != nil {
+		if os.Getenv("FORGE_ALLOW_EMPTY_RECIPES") != "1" {
+			return nil, fmt.Errorf("failed to load recipes: %w", err)
+		}
 		fmt.Printf("Warning: Failed to load recipes: %v\n", err)
 	}
 
+	// Generated ZIPs are cached by their inputs until recipes are reloaded
+	cache := newZipCache()
+
+	// Recipe development: reload whenever a file in FORGE_RECIPES_DIR changes
+	if os.Getenv("FORGE_WATCH_RECIPES") == "1" {
+		if err := watchRecipes(loader, cache); err != nil {
+			return nil, err
+		}
+	}
+
 	// Setup Gin router
 	r := gin.Default()
 
-	// CORS middleware
+	// CORS middleware; FORGE_CORS_ORIGINS (comma-separated) restricts the allowed origins
 	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
+	if origins := corsOrigins(os.Getenv("FORGE_CORS_ORIGINS")); len(origins) > 0 {
+		config.AllowOrigins = origins
+	} else {
+		config.AllowAllOrigins = true
+	}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
 	config.AllowHeaders = []string{"*"}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid FORGE_CORS_ORIGINS: %w", err)
+	}
 	r.Use(cors.New(config))
 
 	// API routes
@@ -84,19 +119,23 @@ func SetupServer() (*gin.Engine, error) {
 		api.GET("/version", getVersion)
 		api.GET("/libraries", getAllLibraries(loader))
 		api.GET("/libraries/:id", getLibrary(loader))
+		api.GET("/libraries/:id/cmake", getLibraryCMake(loader))
 		api.GET("/categories", getCategories)
 		api.GET("/categories/:id/libraries", getCategoryLibraries(loader))
 		api.GET("/search", searchLibraries(loader))
-		api.POST("/reload-recipes", reloadRecipes(loader))
-		api.POST("/generate", generateProject(loader))
+		api.POST("/reload-recipes", reloadRecipes(loader, cache))
+		api.POST("/generate", generateProject(loader, cache))
 		api.POST("/preview", previewCMake(loader))
 		api.GET("/preview", previewCMakeLegacy(loader))
-		api.POST("/forge", generateFromForgeYAML(loader))
+		api.POST("/forge", generateFromForgeYAML(loader, cache))
 		api.POST("/forge/dependencies", generateDependenciesOnly(loader))
 		api.GET("/forge/template", getForgeTemplate)
 		api.GET("/forge/example/:template", getForgeExample)
 	}
 
+	// Health check for load balancers and orchestration
+	r.GET("/healthz", healthz(loader, cache))
+
 	// Static file serving
 	staticDir := "static"
 	if envDir := os.Getenv("FORGE_STATIC_DIR"); envDir != "" {
@@ -144,6 +183,17 @@ func SetupServer() (*gin.Engine, error) {
 	return r, nil
 }
 
+// corsOrigins splits a comma-separated origin list, dropping blanks and trailing slashes
+func corsOrigins(value string) []string {
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
 func apiRoot(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message":     "Forge API - C++ Project Generator",
@@ -164,7 +214,28 @@ func getVersion(c *gin.Context) {
 
 func getAllLibraries(loader *recipe.Loader) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		libraries, err := loader.GetAllLibraries()
+		category := c.Query("category")
+		tag := c.Query("tag")
+		stdParam := c.Query("std_max")
+		if stdParam == "" {
+			stdParam = c.Query("std")
+		}
+
+		stdMax := 0
+		if stdParam != "" {
+			if _, err := fmt.Sscanf(stdParam, "%d", &stdMax); err != nil || stdMax <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Invalid std_max '%s': must be a C++ standard like 17", stdParam)})
+				return
+			}
+		}
+
+		var libraries []*recipe.Library
+		var err error
+		if category == "" && tag == "" && stdMax == 0 {
+			libraries, err = loader.GetAllLibraries()
+		} else {
+			libraries, err = loader.FilterLibraries(category, tag, stdMax)
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -185,6 +256,74 @@ func getLibrary(loader *recipe.Loader) gin.HandlerFunc {
 	}
 }
 
+// getLibraryCMake renders the dependencies.cmake fragment of one library. Query
+// parameters set its options (e.g. ?header_only=true&scope=public).
+func getLibraryCMake(loader *recipe.Loader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		lib, err := loader.GetLibraryByID(id)
+		if err != nil || lib == nil {
+			c.JSON(http.StatusNotFound, gin.H{"detail": fmt.Sprintf("Library '%s' not found", id)})
+			return
+		}
+
+		options := make(map[string]any)
+		for name, values := range c.Request.URL.Query() {
+			value, err := parseOptionValue(lib, name, values[len(values)-1])
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+				return
+			}
+			options[name] = value
+		}
+
+		cmake, err := generator.GenerateLibraryCMake(generator.LibraryWithOptions{Lib: lib, Options: options})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+			return
+		}
+		c.String(http.StatusOK, cmake)
+	}
+}
+
+// parseOptionValue converts a query parameter to the type of the library option
+// it names; shared and scope are accepted for every library
+func parseOptionValue(lib *recipe.Library, name, raw string) (any, error) {
+	optType := ""
+	switch name {
+	case "shared":
+		optType = "boolean"
+	case "scope":
+		optType = "string"
+	}
+	for _, opt := range lib.Options {
+		if opt.ID == name {
+			optType = opt.Type
+			if opt.Type == "choice" && len(opt.Choices) > 0 && !slices.Contains(opt.Choices, raw) {
+				return nil, fmt.Errorf("invalid value '%s' for option %s (choices: %s)", raw, name, strings.Join(opt.Choices, ", "))
+			}
+		}
+	}
+
+	switch optType {
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("option %s must be true or false", name)
+		}
+		return b, nil
+	case "integer":
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("option %s must be an integer", name)
+		}
+		return n, nil
+	case "string", "choice":
+		return raw, nil
+	}
+	return nil, fmt.Errorf("unknown option '%s' for %s", name, lib.ID)
+}
+
 func getCategories(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"categories": recipe.Categories})
 }
@@ -225,12 +364,54 @@ func searchLibraries(loader *recipe.Loader) gin.HandlerFunc {
 	}
 }
 
-func reloadRecipes(loader *recipe.Loader) gin.HandlerFunc {
+var zipPrefixRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)*/?$`)
+
+// zipPrefix resolves the ?layout= and ?prefix= query params of /api/forge
+func zipPrefix(layout, prefix, projectName string) (string, error) {
+	if prefix != "" {
+		if !zipPrefixRegex.MatchString(prefix) || strings.Contains(prefix, "..") {
+			return "", fmt.Errorf("invalid prefix '%s': must be a relative path", prefix)
+		}
+		return prefix, nil
+	}
+
+	switch layout {
+	case "flat":
+		return "", nil
+	case "wrapped":
+		return projectName + "/", nil
+	default:
+		return "", fmt.Errorf("invalid layout '%s': use flat or wrapped", layout)
+	}
+}
+
+// healthz reports 503 when no recipes are loaded so a broken deployment is detectable
+func healthz(loader *recipe.Loader, cache *zipCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count := loader.Count()
+		if count == 0 {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":    "unavailable",
+				"recipes":   0,
+				"zip_cache": cache.stats(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "ok",
+			"recipes":   count,
+			"zip_cache": cache.stats(),
+		})
+	}
+}
+
+func reloadRecipes(loader *recipe.Loader, cache *zipCache) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if err := loader.ReloadRecipes(); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		cache.purge()
 		libraries, _ := loader.GetAllLibraries()
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Recipes reloaded",
@@ -239,7 +420,7 @@ func reloadRecipes(loader *recipe.Loader) gin.HandlerFunc {
 	}
 }
 
-func generateProject(loader *recipe.Loader) gin.HandlerFunc {
+func generateProject(loader *recipe.Loader, cache *zipCache) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var config ProjectConfig
 		if err := c.ShouldBindJSON(&config); err != nil {
@@ -254,11 +435,21 @@ func generateProject(loader *recipe.Loader) gin.HandlerFunc {
 			})
 			return
 		}
+		if config.BinName != "" && !projectNameRegex.MatchString(config.BinName) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"detail": "bin_name must start with a letter and contain only letters, numbers, and underscores",
+			})
+			return
+		}
 
 		// Set defaults
 		if config.CppStandard == 0 {
 			config.CppStandard = 17
 		}
+		if err := validateCppStandard(config.CppStandard); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+			return
+		}
 		if config.TestingFramework == "" {
 			config.TestingFramework = "googletest"
 		}
@@ -296,19 +487,19 @@ func generateProject(loader *recipe.Loader) gin.HandlerFunc {
 		}
 
 		// Generate ZIP
-		zipData, err := generator.CreateProjectZip(
-			config.ProjectName,
-			config.CppStandard,
-			selections,
-			config.IncludeTests,
-			config.TestingFramework,
-			config.BuildShared,
-			config.ClangFormatStyle,
-			config.ProjectType,
-			"1.0.0", // default version for web UI
-			false,   // not flat for web UI
-			loader,
-		)
+		zipData, err := cache.createProjectZip(zipRequest{
+			ProjectName:      config.ProjectName,
+			BinName:          config.BinName,
+			CppStandard:      config.CppStandard,
+			Selections:       selections,
+			IncludeTests:     config.IncludeTests,
+			TestingFramework: config.TestingFramework,
+			BuildShared:      config.BuildShared,
+			ClangFormatStyle: config.ClangFormatStyle,
+			ProjectType:      config.ProjectType,
+			ProjectVersion:   "1.0.0",                  // default version for web UI
+			Prefix:           config.ProjectName + "/", // wrapped for web UI
+		}, loader)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"detail": fmt.Sprintf("Failed to generate project: %v", err)})
 			return
@@ -339,6 +530,10 @@ func previewCMake(loader *recipe.Loader) gin.HandlerFunc {
 		if config.CppStandard == 0 {
 			config.CppStandard = 17
 		}
+		if err := validateCppStandard(config.CppStandard); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+			return
+		}
 		if config.TestingFramework == "" {
 			config.TestingFramework = "googletest"
 		}
@@ -364,6 +559,7 @@ func previewCMake(loader *recipe.Loader) gin.HandlerFunc {
 
 		cmakeContent, err := generator.GenerateCMakeLists(
 			config.ProjectName,
+			config.BinName,
 			config.CppStandard,
 			librariesWithOptions,
 			config.IncludeTests,
@@ -400,7 +596,16 @@ func previewCMakeLegacy(loader *recipe.Loader) gin.HandlerFunc {
 
 		cppStandard := 17
 		if std := c.Query("cpp_standard"); std != "" {
-			fmt.Sscanf(std, "%d", &cppStandard)
+			n, err := strconv.Atoi(std)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Invalid cpp_standard '%s'", std)})
+				return
+			}
+			cppStandard = n
+		}
+		if err := validateCppStandard(cppStandard); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+			return
 		}
 
 		includeTests := c.DefaultQuery("include_tests", "true") == "true"
@@ -426,6 +631,7 @@ func previewCMakeLegacy(loader *recipe.Loader) gin.HandlerFunc {
 
 		cmakeContent, err := generator.GenerateCMakeLists(
 			projectName,
+			"",
 			cppStandard,
 			librariesWithOptions,
 			includeTests,
@@ -444,31 +650,32 @@ func previewCMakeLegacy(loader *recipe.Loader) gin.HandlerFunc {
 	}
 }
 
-func generateFromForgeYAML(loader *recipe.Loader) gin.HandlerFunc {
+func generateFromForgeYAML(loader *recipe.Loader, cache *zipCache) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		file, err := c.FormFile("file")
+		filename, data, err := readManifest(c)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Failed to read file: %v", err)})
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
 			return
 		}
 
-		f, err := file.Open()
+		forgeYAML, err := parseForgeManifest(filename, data)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Failed to open file: %v", err)})
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
 			return
 		}
-		defer f.Close()
 
-		data, err := io.ReadAll(f)
+		// Project-local recipes take precedence over the registry, as for /api/forge/dependencies
+		overrides, err := recipeOverrides(c)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Failed to read file: %v", err)})
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
 			return
 		}
-
-		var forgeYAML ForgeYAML
-		if err := yaml.Unmarshal(data, &forgeYAML); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Invalid YAML format: %v", err)})
-			return
+		reqLoader := loader
+		if len(overrides) > 0 {
+			if reqLoader, err = loader.WithOverrides(overrides); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+				return
+			}
 		}
 
 		// Extract package info
@@ -484,11 +691,21 @@ func generateFromForgeYAML(loader *recipe.Loader) gin.HandlerFunc {
 			})
 			return
 		}
+		if binName := forgeYAML.Package.BinName; binName != "" && !projectNameRegex.MatchString(binName) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"detail": "bin_name must start with a letter and contain only letters, numbers, and underscores",
+			})
+			return
+		}
 
 		cppStandard := forgeYAML.Package.CppStandard
 		if cppStandard == 0 {
 			cppStandard = 17
 		}
+		if err := validateCppStandard(cppStandard); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+			return
+		}
 
 		projectType := forgeYAML.Package.ProjectType
 		if projectType == "" {
@@ -511,13 +728,18 @@ func generateFromForgeYAML(loader *recipe.Loader) gin.HandlerFunc {
 			testingFramework = "googletest"
 		}
 		includeTests := testingFramework != "none"
+		if err := validateTestingFramework(reqLoader, testingFramework); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"detail": err.Error()})
+			return
+		}
 
 		// Extract dependencies
 		var selections []generator.LibrarySelection
 		var invalidLibs []string
 
-		for libID, options := range forgeYAML.Dependencies {
-			lib, err := loader.GetLibraryByID(libID)
+		for _, libID := range dependencyIDs(forgeYAML.Dependencies) {
+			options := forgeYAML.Dependencies[libID]
+			lib, err := reqLoader.GetLibraryByID(libID)
 			if err != nil || lib == nil {
 				invalidLibs = append(invalidLibs, libID)
 				continue
@@ -547,20 +769,28 @@ func generateFromForgeYAML(loader *recipe.Loader) gin.HandlerFunc {
 			projectVersion = "1.0.0"
 		}
 
-		// Generate ZIP (flat=True for CLI usage)
-		zipData, err := generator.CreateProjectZip(
-			projectName,
-			cppStandard,
-			selections,
-			includeTests,
-			testingFramework,
-			buildShared,
-			clangFormatStyle,
-			projectType,
-			projectVersion,
-			true, // flat for CLI
-			loader,
-		)
+		// Zip layout: flat (default, for the CLI), wrapped in projectName/, or a custom prefix
+		prefix, err := zipPrefix(c.DefaultQuery("layout", "flat"), c.Query("prefix"), projectName)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
+			return
+		}
+
+		zipData, err := cache.createProjectZip(zipRequest{
+			ProjectName:          projectName,
+			BinName:              forgeYAML.Package.BinName,
+			CppStandard:          cppStandard,
+			Selections:           selections,
+			IncludeTests:         includeTests,
+			TestingFramework:     testingFramework,
+			BuildShared:          buildShared,
+			ClangFormatStyle:     clangFormatStyle,
+			ClangFormatOverrides: forgeYAML.Build.ClangFormatOverrides,
+			ProjectType:          projectType,
+			ProjectVersion:       projectVersion,
+			Prefix:               prefix,
+			Overrides:            overrides,
+		}, reqLoader)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"detail": fmt.Sprintf("Failed to generate project: %v", err)})
 			return
@@ -571,31 +801,79 @@ func generateFromForgeYAML(loader *recipe.Loader) gin.HandlerFunc {
 	}
 }
 
+// dependencyIDs returns the manifest's dependency IDs sorted, so the generated
+// FetchContent declarations and link order are stable across requests
+func dependencyIDs(deps map[string]any) []string {
+	ids := make([]string, 0, len(deps))
+	for id := range deps {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// validateCppStandard rejects a cpp_standard outside generator.KnownCppStandards.
+// Every generate and preview path calls it, whichever way the request arrives.
+func validateCppStandard(std int) error {
+	if !generator.IsKnownCppStandard(std) {
+		return fmt.Errorf("Unsupported cpp_standard %d (supported: %v)", std, generator.KnownCppStandards)
+	}
+	return nil
+}
+
+// validateTestingFramework rejects a testing.framework that isn't a recipe in
+// the testing category; otherwise the project would get test scaffolding
+// without a framework to build it against
+func validateTestingFramework(loader *recipe.Loader, framework string) error {
+	if framework == "none" {
+		return nil
+	}
+	lib, err := loader.GetLibraryByID(framework)
+	if err != nil {
+		return err
+	}
+	if lib != nil && lib.Category == "testing" {
+		return nil
+	}
+	libs, err := loader.GetLibrariesByCategory("testing")
+	if err != nil {
+		return err
+	}
+	valid := make([]string, 0, len(libs)+1)
+	for _, l := range libs {
+		valid = append(valid, l.ID)
+	}
+	sort.Strings(valid)
+	valid = append(valid, "none")
+	return fmt.Errorf("Unknown testing framework '%s' (valid: %s)", framework, strings.Join(valid, ", "))
+}
+
 func generateDependenciesOnly(loader *recipe.Loader) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		file, err := c.FormFile("file")
+		filename, data, err := readManifest(c)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Failed to read file: %v", err)})
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
 			return
 		}
 
-		f, err := file.Open()
+		forgeYAML, err := parseForgeManifest(filename, data)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Failed to open file: %v", err)})
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
 			return
 		}
-		defer f.Close()
 
-		data, err := io.ReadAll(f)
+		// Project-local recipes take precedence over the registry for this request
+		overrides, err := recipeOverrides(c)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Failed to read file: %v", err)})
+			c.JSON(http.StatusBadRequest, gin.H{"detail": err.Error()})
 			return
 		}
-
-		var forgeYAML ForgeYAML
-		if err := yaml.Unmarshal(data, &forgeYAML); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"detail": fmt.Sprintf("Invalid YAML format: %v", err)})
-			return
+		reqLoader := loader
+		if len(overrides) > 0 {
+			if reqLoader, err = loader.WithOverrides(overrides); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
+				return
+			}
 		}
 
 		// Extract testing config
@@ -604,11 +882,16 @@ func generateDependenciesOnly(loader *recipe.Loader) gin.HandlerFunc {
 			testingFramework = "none"
 		}
 		includeTests := testingFramework != "none"
+		if err := validateTestingFramework(reqLoader, testingFramework); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"detail": err.Error()})
+			return
+		}
 
 		// Parse dependencies
 		var librariesWithOptions []generator.LibraryWithOptions
-		for libID, libOptions := range forgeYAML.Dependencies {
-			lib, err := loader.GetLibraryByID(libID)
+		for _, libID := range dependencyIDs(forgeYAML.Dependencies) {
+			libOptions := forgeYAML.Dependencies[libID]
+			lib, err := reqLoader.GetLibraryByID(libID)
 			if err == nil && lib != nil {
 				opts := make(map[string]any)
 				if optionsMap, ok := libOptions.(map[string]any); ok {
@@ -626,7 +909,7 @@ func generateDependenciesOnly(loader *recipe.Loader) gin.HandlerFunc {
 			librariesWithOptions,
 			includeTests,
 			testingFramework,
-			loader,
+			reqLoader,
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"detail": err.Error()})
@@ -647,7 +930,7 @@ func getForgeTemplate(c *gin.Context) {
 package:
   name: my_library
   version: "0.1.0"
-  cpp_standard: 17  # 11, 14, 17, 20, or 23
+  cpp_standard: 17  # 11, 14, 17, 20, 23, or 26
   project_type: lib  # lib = library only (no executable)
 
 build:
@@ -670,7 +953,7 @@ dependencies:
 package:
   name: my_awesome_project
   version: "1.0.0"
-  cpp_standard: 17  # 11, 14, 17, 20, or 23
+  cpp_standard: 17  # 11, 14, 17, 20, 23, or 26
   project_type: exe  # exe = executable, lib = library only
 
 build:
@@ -802,18 +1085,19 @@ dependencies:
 		"data-processing": fmt.Sprintf(`# Data processing project
 package:
   name: data_processor
-  cpp_standard: 17
+  cpp_standard: 20
   project_type: %s
 
 build:
-  clang_format: Google
+  clang_format: LLVM
 
 testing:
   framework: catch2
 
 dependencies:
-  fast_float: {}
-  nlohmann_json: {}
+  simdjson: {}
+  range_v3: {}
+  taskflow: {}
   fmt: {}
   spdlog:
     spdlog_header_only: true
@@ -822,9 +1106,151 @@ dependencies:
 
 	content, ok := templates[templateName]
 	if !ok {
-		c.JSON(http.StatusNotFound, gin.H{"detail": "Template not found"})
+		keys := make([]string, 0, len(templates))
+		for k := range templates {
+			keys = append(keys, k)
+		}
+		c.JSON(http.StatusNotFound, gin.H{
+			"detail": fmt.Sprintf("Template '%s' not found. Available: %s", templateName, strings.Join(keys, ", ")),
+		})
 		return
 	}
 
 	c.String(http.StatusOK, content)
 }
+
+// readManifest returns the uploaded manifest and its filename, dispatching on Content-Type:
+// multipart/form-data with a "file" field, a raw application/x-yaml, text/yaml or
+// application/toml body, or application/json with a "yaml" (or "toml") string field
+func readManifest(c *gin.Context) (string, []byte, error) {
+	switch c.ContentType() {
+	case "application/x-yaml", "application/yaml", "text/yaml", "text/x-yaml":
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", nil, fmt.Errorf("Failed to read body: %v", err)
+		}
+		return "forge.yaml", data, nil
+	case "application/toml":
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", nil, fmt.Errorf("Failed to read body: %v", err)
+		}
+		return "forge.toml", data, nil
+	case "application/json":
+		var body struct {
+			YAML string `json:"yaml"`
+			TOML string `json:"toml"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return "", nil, fmt.Errorf("Invalid JSON body: %v", err)
+		}
+		if body.TOML != "" {
+			return "forge.toml", []byte(body.TOML), nil
+		}
+		if body.YAML == "" {
+			return "", nil, fmt.Errorf("JSON body must contain a \"yaml\" field")
+		}
+		return "forge.yaml", []byte(body.YAML), nil
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return "", nil, fmt.Errorf("Failed to read file: %v", err)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return "", nil, fmt.Errorf("Failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", nil, fmt.Errorf("Failed to read file: %v", err)
+	}
+	return file.Filename, data, nil
+}
+
+// recipeOverrides parses the optional multipart "recipe" files sent alongside
+// forge.yaml (a project's .forge/recipes/, already merged by the client)
+func recipeOverrides(c *gin.Context) ([]*recipe.Library, error) {
+	if c.Request.MultipartForm == nil {
+		return nil, nil
+	}
+	var libs []*recipe.Library
+	for _, file := range c.Request.MultipartForm.File["recipe"] {
+		f, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open recipe %s: %v", file.Filename, err)
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read recipe %s: %v", file.Filename, err)
+		}
+		parse := recipe.ParseRecipe
+		if strings.HasSuffix(file.Filename, ".json") {
+			parse = recipe.ParseRecipeJSON
+		}
+		lib, err := parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid recipe %s: %v", file.Filename, err)
+		}
+		libs = append(libs, lib)
+	}
+	return libs, nil
+}
+
+// parseForgeManifest unescapes $$ and parses a forge.yaml,
+// or a forge.toml when the uploaded filename ends in .toml
+func parseForgeManifest(filename string, data []byte) (*ForgeYAML, error) {
+	format := "YAML"
+	if strings.HasSuffix(filename, ".toml") {
+		format = "TOML"
+	}
+
+	expanded, err := unescapeDollars(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s format: %v", format, err)
+	}
+
+	yamlData := []byte(expanded)
+	if format == "TOML" {
+		// Convert through a generic document so ForgeYAML's yaml tags apply to both formats
+		var doc map[string]any
+		if err := toml.Unmarshal(yamlData, &doc); err != nil {
+			return nil, fmt.Errorf("Invalid TOML format: %v", err)
+		}
+		if yamlData, err = yaml.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("Invalid TOML format: %v", err)
+		}
+	}
+
+	var forgeYAML ForgeYAML
+	if err := yaml.Unmarshal(yamlData, &forgeYAML); err != nil {
+		return nil, fmt.Errorf("Invalid %s format: %v", format, err)
+	}
+	return &forgeYAML, nil
+}
+
+// unescapeDollars undoes the client's $$ escaping. Environment variables are
+// expanded by the client only: the server must never resolve them against its
+// own environment, so any remaining ${VAR} or $VAR reference is rejected.
+func unescapeDollars(s string) (string, error) {
+	var refs []string
+	seen := make(map[string]bool)
+	out := envVarRegex.ReplaceAllStringFunc(s, func(m string) string {
+		if m == "$$" {
+			return "$"
+		}
+		if !seen[m] {
+			seen[m] = true
+			refs = append(refs, m)
+		}
+		return m
+	})
+	if len(refs) > 0 {
+		return "", fmt.Errorf("environment variables are not expanded by the server: %s (resolve them before uploading, or write $$ for a literal $)", strings.Join(refs, ", "))
+	}
+	return out, nil
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ozacod/forge/forge-server/internal/recipe"
+)
+
+func TestUnescapeDollars(t *testing.T) {
+	t.Setenv("FORGE_TEST_SECRET", "hunter2")
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr string
+	}{
+		{name: "plain", in: "name: demo\n", want: "name: demo\n"},
+		{name: "escaped dollar", in: "define: PRICE=$$5\n", want: "define: PRICE=$5\n"},
+		{name: "escaped reference", in: "rpath: $${ORIGIN}\n", want: "rpath: ${ORIGIN}\n"},
+		{name: "braced reference", in: "key: ${FORGE_TEST_SECRET}\n", wantErr: "${FORGE_TEST_SECRET}"},
+		{name: "bare reference", in: "key: $FORGE_TEST_SECRET\n", wantErr: "$FORGE_TEST_SECRET"},
+		{name: "reference with default", in: "version: ${V:-1.0}\n", wantErr: "${V:-1.0}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unescapeDollars(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("unescapeDollars(%q) error = %v, want one naming %s", tt.in, err, tt.wantErr)
+				}
+				if strings.Contains(err.Error(), "hunter2") {
+					t.Fatalf("error leaks the server environment: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unescapeDollars(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("unescapeDollars(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseForgeManifestDoesNotReadServerEnvironment(t *testing.T) {
+	t.Setenv("FORGE_TEST_SECRET", "hunter2")
+
+	if _, err := parseForgeManifest("forge.yaml", []byte("package:\n  name: ${FORGE_TEST_SECRET}\n")); err == nil {
+		t.Fatal("manifest with an environment reference was accepted")
+	}
+	manifest, err := parseForgeManifest("forge.yaml", []byte("package:\n  name: demo\n  version: 1.0.0-$$5\n"))
+	if err != nil {
+		t.Fatalf("parseForgeManifest: %v", err)
+	}
+	if manifest.Package.Version != "1.0.0-$5" {
+		t.Errorf("version = %q, want %q", manifest.Package.Version, "1.0.0-$5")
+	}
+}
+
+func TestUnsupportedCppStandardRejectedOnEveryPath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	loader := recipe.NewLoader(t.TempDir())
+	r := gin.New()
+	r.POST("/api/generate", generateProject(loader, newZipCache()))
+	r.POST("/api/preview", previewCMake(loader))
+	r.GET("/api/preview", previewCMakeLegacy(loader))
+	r.POST("/api/forge", generateFromForgeYAML(loader, newZipCache()))
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+	}{
+		{name: "generate", method: http.MethodPost, target: "/api/generate", contentType: "application/json", body: `{"project_name":"demo","cpp_standard":99}`},
+		{name: "preview", method: http.MethodPost, target: "/api/preview", contentType: "application/json", body: `{"project_name":"demo","cpp_standard":99}`},
+		{name: "preview query", method: http.MethodGet, target: "/api/preview?project_name=demo&cpp_standard=99"},
+		{name: "preview query not a number", method: http.MethodGet, target: "/api/preview?project_name=demo&cpp_standard=latest"},
+		{name: "forge", method: http.MethodPost, target: "/api/forge", contentType: "application/x-yaml", body: "package:\n  name: demo\n  cpp_standard: 99\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, http.StatusBadRequest, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), "cpp_standard") {
+				t.Errorf("body %s does not name cpp_standard", w.Body)
+			}
+		})
+	}
+}
+
+// multipartManifest builds a /api/forge(/dependencies) upload of manifest plus recipe files
+func multipartManifest(t *testing.T, manifest string, recipes map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile("file", "forge.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(manifest))
+	for name, content := range recipes {
+		part, err := w.CreateFormFile("recipe", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf, w.FormDataContentType()
+}
+
+func TestRecipeOverridesOnEveryForgePath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	registry := "id: mylib\ncategory: utility\nfetch_content:\n  repository: https://example.com/mylib.git\n  tag: v1.0.0\n"
+	if err := os.WriteFile(filepath.Join(dir, "mylib.yaml"), []byte(registry), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loader := recipe.NewLoader(dir)
+	if err := loader.LoadRecipes(); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.POST("/api/forge", generateFromForgeYAML(loader, newZipCache()))
+	r.POST("/api/forge/dependencies", generateDependenciesOnly(loader))
+
+	const manifest = "package:\n  name: demo\ntesting:\n  framework: none\ndependencies:\n  mylib: {}\n  local_only: {}\n"
+	overrides := map[string]string{
+		"mylib.yaml":      "id: mylib\ncategory: utility\nfetch_content:\n  repository: https://example.com/mylib.git\n  tag: v2.0.0\n",
+		"local_only.yaml": "id: local_only\ncategory: utility\nfetch_content:\n  repository: https://example.com/local_only.git\n  tag: v0.1.0\n",
+	}
+
+	// dependenciesCMake returns dependencies.cmake from either endpoint
+	dependenciesCMake := func(t *testing.T, target string, recipes map[string]string) (int, string) {
+		t.Helper()
+		body, contentType := multipartManifest(t, manifest, recipes)
+		req := httptest.NewRequest(http.MethodPost, target, body)
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || target == "/api/forge/dependencies" {
+			return rec.Code, rec.Body.String()
+		}
+		zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		if err != nil {
+			t.Fatalf("invalid zip: %v", err)
+		}
+		for _, f := range zr.File {
+			if f.Name == ".cmake/forge/dependencies.cmake" {
+				rc, _ := f.Open()
+				data, _ := io.ReadAll(rc)
+				rc.Close()
+				return rec.Code, string(data)
+			}
+		}
+		t.Fatal("zip has no dependencies.cmake")
+		return 0, ""
+	}
+
+	for _, target := range []string{"/api/forge", "/api/forge/dependencies"} {
+		t.Run(target, func(t *testing.T) {
+			code, out := dependenciesCMake(t, target, overrides)
+			if code != http.StatusOK {
+				t.Fatalf("status %d: %s", code, out)
+			}
+			for _, want := range []string{"v2.0.0", "local_only.git"} {
+				if !strings.Contains(out, want) {
+					t.Errorf("dependencies.cmake does not use the override (%s):\n%s", want, out)
+				}
+			}
+			if strings.Contains(out, "v1.0.0") {
+				t.Errorf("registry recipe used despite the override:\n%s", out)
+			}
+
+			// The override is per request: the registry recipe is back without it
+			code, out = dependenciesCMake(t, target, map[string]string{"local_only.yaml": overrides["local_only.yaml"]})
+			if code != http.StatusOK || !strings.Contains(out, "v1.0.0") {
+				t.Errorf("without the mylib override: status %d:\n%s", code, out)
+			}
+		})
+	}
+}
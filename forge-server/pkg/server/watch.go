@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ozacod/forge/forge-server/internal/recipe"
+)
+
+// recipeReloadDelay debounces the burst of events a single editor save produces
+const recipeReloadDelay = 300 * time.Millisecond
+
+// watchRecipes reloads the loader's recipes whenever a file in its directory
+// changes. Reloads run one at a time on the watcher goroutine and swap the
+// recipe set under the loader's lock, so requests never see a partial set.
+func watchRecipes(loader *recipe.Loader, cache *zipCache) error {
+	dir := loader.RecipesDir()
+	if dir == "" {
+		return fmt.Errorf("FORGE_WATCH_RECIPES requires FORGE_RECIPES_DIR: embedded recipes can't be watched")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start recipe watcher: %w", err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	fmt.Printf("Watching %s for recipe changes\n", dir)
+
+	go func() {
+		defer watcher.Close()
+		var pending <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				pending = time.After(recipeReloadDelay)
+			case <-pending:
+				pending = nil
+				if err := loader.ReloadRecipes(); err != nil {
+					fmt.Printf("Warning: Failed to reload recipes: %v\n", err)
+					continue
+				}
+				cache.purge()
+				fmt.Printf("Recipes reloaded (%d libraries)\n", loader.Count())
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Printf("Warning: Recipe watcher error: %v\n", err)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ozacod/forge/forge-server/internal/generator"
+	"github.com/ozacod/forge/forge-server/internal/recipe"
+)
+
+// defaultZipCacheSize is the number of generated ZIPs kept when FORGE_ZIP_CACHE_SIZE is unset
+const defaultZipCacheSize = 128
+
+// zipRequest holds every input of generator.CreateProjectZip that affects its output
+type zipRequest struct {
+	ProjectName          string                       `json:"project_name"`
+	BinName              string                       `json:"bin_name"`
+	CppStandard          int                          `json:"cpp_standard"`
+	Selections           []generator.LibrarySelection `json:"selections"`
+	IncludeTests         bool                         `json:"include_tests"`
+	TestingFramework     string                       `json:"testing_framework"`
+	BuildShared          bool                         `json:"build_shared"`
+	ClangFormatStyle     string                       `json:"clang_format_style"`
+	ClangFormatOverrides map[string]any               `json:"clang_format_overrides"`
+	ProjectType          string                       `json:"project_type"`
+	ProjectVersion       string                       `json:"project_version"`
+	Prefix               string                       `json:"prefix"`
+	// Project-local recipes the request was generated with
+	Overrides []*recipe.Library `json:"overrides,omitempty"`
+}
+
+// key hashes the request; selections are sorted and options marshal with sorted keys
+func (r zipRequest) key() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type zipCacheEntry struct {
+	key  string
+	data []byte
+}
+
+// zipCache is a content-addressed LRU of generated project ZIPs. It is purged
+// whenever recipes are reloaded, since the same inputs may then generate differently.
+type zipCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front = most recently used
+	entries map[string]*list.Element
+	hits    uint64
+	misses  uint64
+	// generation counts purges (recipe reloads); a ZIP generated before the
+	// latest one may use the old recipes and is not cached
+	generation uint64
+}
+
+// newZipCache creates a cache of FORGE_ZIP_CACHE_SIZE entries (0 disables caching)
+func newZipCache() *zipCache {
+	size := defaultZipCacheSize
+	if v := os.Getenv("FORGE_ZIP_CACHE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			size = n
+		}
+	}
+	return &zipCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (z *zipCache) get(key string) ([]byte, bool) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if el, ok := z.entries[key]; ok {
+		z.order.MoveToFront(el)
+		z.hits++
+		return el.Value.(*zipCacheEntry).data, true
+	}
+	z.misses++
+	return nil, false
+}
+
+// currentGeneration returns the generation to pass to put for a ZIP generated from now on
+func (z *zipCache) currentGeneration() uint64 {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.generation
+}
+
+// put caches data under key, unless recipes were reloaded since generation
+func (z *zipCache) put(key string, data []byte, generation uint64) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.size == 0 || generation != z.generation {
+		return
+	}
+	if el, ok := z.entries[key]; ok {
+		el.Value.(*zipCacheEntry).data = data
+		z.order.MoveToFront(el)
+		return
+	}
+	z.entries[key] = z.order.PushFront(&zipCacheEntry{key: key, data: data})
+	for z.order.Len() > z.size {
+		oldest := z.order.Back()
+		z.order.Remove(oldest)
+		delete(z.entries, oldest.Value.(*zipCacheEntry).key)
+	}
+}
+
+// purge drops every entry, keeping the hit/miss counters
+func (z *zipCache) purge() {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.order.Init()
+	z.entries = make(map[string]*list.Element)
+	z.generation++
+}
+
+// stats returns the counters reported by /healthz
+func (z *zipCache) stats() map[string]any {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return map[string]any{
+		"entries": z.order.Len(),
+		"size":    z.size,
+		"hits":    z.hits,
+		"misses":  z.misses,
+	}
+}
+
+// createProjectZip returns the cached ZIP for req or generates and caches it
+func (z *zipCache) createProjectZip(req zipRequest, loader *recipe.Loader) ([]byte, error) {
+	sort.SliceStable(req.Selections, func(i, j int) bool {
+		return req.Selections[i].LibraryID < req.Selections[j].LibraryID
+	})
+
+	generation := z.currentGeneration()
+	key, err := req.key()
+	if err == nil {
+		if data, ok := z.get(key); ok {
+			return data, nil
+		}
+	}
+
+	data, err := generator.CreateProjectZip(
+		req.ProjectName,
+		req.BinName,
+		req.CppStandard,
+		req.Selections,
+		req.IncludeTests,
+		req.TestingFramework,
+		req.BuildShared,
+		req.ClangFormatStyle,
+		req.ClangFormatOverrides,
+		req.ProjectType,
+		req.ProjectVersion,
+		req.Prefix,
+		loader,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		z.put(key, data, generation)
+	}
+	return data, nil
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ozacod/forge/forge-server/internal/generator"
+	"github.com/ozacod/forge/forge-server/internal/recipe"
+)
+
+// testZipLoader returns a loaded registry holding mylib at tag
+func testZipLoader(t *testing.T, tag string) (*recipe.Loader, string) {
+	t.Helper()
+	dir := t.TempDir()
+	writeZipRecipe(t, dir, tag)
+	loader := recipe.NewLoader(dir)
+	if err := loader.LoadRecipes(); err != nil {
+		t.Fatal(err)
+	}
+	return loader, dir
+}
+
+func writeZipRecipe(t *testing.T, dir, tag string) {
+	t.Helper()
+	content := "id: mylib\ncategory: utility\nfetch_content:\n  repository: https://example.com/mylib.git\n  tag: " + tag + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "mylib.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testZipRequest(name string) zipRequest {
+	return zipRequest{
+		ProjectName:      name,
+		CppStandard:      17,
+		Selections:       []generator.LibrarySelection{{LibraryID: "mylib"}},
+		TestingFramework: "none",
+	}
+}
+
+func TestZipCacheHitAndMiss(t *testing.T) {
+	loader, _ := testZipLoader(t, "v1.0.0")
+	cache := newZipCache()
+
+	first, err := cache.createProjectZip(testZipRequest("demo"), loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := cache.createProjectZip(testZipRequest("demo"), loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("cache hit returned a different ZIP")
+	}
+	if _, err := cache.createProjectZip(testZipRequest("other"), loader); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := cache.stats()
+	if stats["hits"] != uint64(1) || stats["misses"] != uint64(2) || stats["entries"] != 2 {
+		t.Errorf("stats = %v, want 1 hit, 2 misses, 2 entries", stats)
+	}
+}
+
+func TestZipCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Setenv("FORGE_ZIP_CACHE_SIZE", "2")
+	cache := newZipCache()
+	generation := cache.currentGeneration()
+
+	cache.put("a", []byte("a"), generation)
+	cache.put("b", []byte("b"), generation)
+	cache.get("a")
+	cache.put("c", []byte("c"), generation)
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("least recently used entry b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("entry %s was evicted", key)
+		}
+	}
+}
+
+func TestZipCachePurgedOnReload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	loader, dir := testZipLoader(t, "v1.0.0")
+	cache := newZipCache()
+	r := gin.New()
+	r.POST("/api/reload", reloadRecipes(loader, cache))
+
+	before, err := cache.createProjectZip(testZipRequest("demo"), loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeZipRecipe(t, dir, "v2.0.0")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/reload", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("reload status = %d: %s", w.Code, w.Body)
+	}
+	if entries := cache.stats()["entries"]; entries != 0 {
+		t.Errorf("cache holds %v entries after reload, want 0", entries)
+	}
+
+	after, err := cache.createProjectZip(testZipRequest("demo"), loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(before, after) {
+		t.Error("ZIP generated from the old recipes was served after reload")
+	}
+}
+
+func TestZipCacheSkipsPutFromBeforeReload(t *testing.T) {
+	cache := newZipCache()
+	generation := cache.currentGeneration()
+
+	// A reload lands while the ZIP is being generated from the old recipes
+	cache.purge()
+	cache.put("stale", []byte("old recipes"), generation)
+
+	if _, ok := cache.get("stale"); ok {
+		t.Error("ZIP generated before the reload was cached")
+	}
+	cache.put("fresh", []byte("new recipes"), cache.currentGeneration())
+	if _, ok := cache.get("fresh"); !ok {
+		t.Error("ZIP generated after the reload was not cached")
+	}
+}